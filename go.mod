@@ -4,8 +4,10 @@ go 1.14
 
 require (
 	github.com/bgentry/speakeasy v0.1.0 // indirect
+	github.com/blevesearch/bleve v1.0.14
 	github.com/denisenkom/go-mssqldb v0.0.0-20200206145737-bbfc9a55622e
 	github.com/editorconfig/editorconfig-core-go/v2 v2.3.1
+	github.com/emersion/go-imap v1.0.5
 	github.com/fatih/color v1.9.0 // indirect
 	github.com/go-macaron/binding v1.1.0
 	github.com/go-macaron/cache v0.0.0-20190810181446-10f7c57e2196
@@ -6,6 +6,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"reflect"
 	"runtime"
 
@@ -31,6 +32,9 @@ to make automatic initialization process more smoothly`,
 			subcmdRewriteAuthorizedKeys,
 			subcmdSyncRepositoryHooks,
 			subcmdReinitMissingRepositories,
+			subcmdRebuildIssueIndex,
+			subcmdExportIssues,
+			subcmdImportIssues,
 		},
 	}
 
@@ -130,6 +134,40 @@ to make automatic initialization process more smoothly`,
 			stringFlag("config, c", "", "Custom configuration file path"),
 		},
 	}
+
+	subcmdRebuildIssueIndex = cli.Command{
+		Name:  "rebuild-issue-index",
+		Usage: "Rebuild full-text search index of issues and pull requests (caution: the web server must be stopped)",
+		Action: adminDashboardOperation(
+			db.RebuildIssueIndex,
+			"Search index of issues and pull requests has been rebuilt successfully",
+		),
+		Flags: []cli.Flag{
+			stringFlag("config, c", "", "Custom configuration file path"),
+		},
+	}
+
+	subcmdExportIssues = cli.Command{
+		Name:   "export-issues",
+		Usage:  "Export issues of a repository to an archive",
+		Action: runExportIssues,
+		Flags: []cli.Flag{
+			stringFlag("repo", "", "Repository in the form of <owner>/<name>"),
+			stringFlag("path, p", "", "Path of the archive file to be created"),
+			stringFlag("config, c", "", "Custom configuration file path"),
+		},
+	}
+
+	subcmdImportIssues = cli.Command{
+		Name:   "import-issues",
+		Usage:  "Import issues from an archive to a repository",
+		Action: runImportIssues,
+		Flags: []cli.Flag{
+			stringFlag("repo", "", "Repository in the form of <owner>/<name>"),
+			stringFlag("path, p", "", "Path of the archive file created by export-issues"),
+			stringFlag("config, c", "", "Custom configuration file path"),
+		},
+	}
 )
 
 func runCreateUser(c *cli.Context) error {
@@ -186,3 +224,75 @@ func adminDashboardOperation(operation func() error, successMessage string) func
 		return nil
 	}
 }
+
+// loadIssueArchiveRepository initializes database and returns the repository given
+// by "--repo" for commands of issue archives.
+func loadIssueArchiveRepository(c *cli.Context) (*db.Repository, error) {
+	if !c.IsSet("repo") {
+		return nil, errors.New("Repository is not specified")
+	} else if !c.IsSet("path") {
+		return nil, errors.New("Path is not specified")
+	}
+
+	err := conf.Init(c.String("config"))
+	if err != nil {
+		return nil, errors.Wrap(err, "init configuration")
+	}
+	conf.InitLogging(true)
+
+	if err = db.SetEngine(); err != nil {
+		return nil, errors.Wrap(err, "set engine")
+	}
+
+	repo, err := db.GetRepositoryByRef(c.String("repo"))
+	if err != nil {
+		return nil, errors.Wrap(err, "get repository")
+	}
+	return repo, nil
+}
+
+func runExportIssues(c *cli.Context) error {
+	repo, err := loadIssueArchiveRepository(c)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(c.String("path"))
+	if err != nil {
+		return errors.Wrap(err, "create archive")
+	}
+	defer f.Close()
+
+	if err = db.ExportIssues(repo, f); err != nil {
+		return errors.Wrap(err, "export issues")
+	}
+
+	fmt.Printf("Issues of '%s' have been exported to '%s'\n", repo.FullName(), c.String("path"))
+	return nil
+}
+
+func runImportIssues(c *cli.Context) error {
+	repo, err := loadIssueArchiveRepository(c)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(c.String("path"))
+	if err != nil {
+		return errors.Wrap(err, "open archive")
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return errors.Wrap(err, "stat archive")
+	}
+
+	n, err := db.ImportIssues(repo, f, fi.Size())
+	if err != nil {
+		return errors.Wrap(err, "import issues")
+	}
+
+	fmt.Printf("%d issue(s) have been imported to '%s'\n", n, repo.FullName())
+	return nil
+}
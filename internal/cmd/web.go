@@ -184,6 +184,11 @@ func runWeb(c *cli.Context) error {
 		m.Combo("/install", route.InstallInit).Get(route.Install).
 			Post(bindIgnErr(form.Install{}), route.InstallPost)
 		m.Get("/^:type(issues|pulls)$", reqSignIn, user.Issues)
+		m.Group("/notifications", func() {
+			m.Get("", user.Notifications)
+			m.Post("/read_all", user.NotificationsReadAllPost)
+			m.Post("/:id/status", user.NotificationStatusPost)
+		}, reqSignIn)
 
 		// ***** START: User *****
 		m.Group("/user", func() {
@@ -250,6 +255,23 @@ func runWeb(c *cli.Context) error {
 
 		reqAdmin := context.Toggle(&context.ToggleOptions{SignInRequired: true, AdminRequired: true})
 
+		webhookRoutes := func() {
+			m.Group("", func() {
+				m.Get("", repo.Webhooks)
+				m.Post("/delete", repo.DeleteWebhook)
+				m.Get("/:type/new", repo.WebhooksNew)
+				m.Post("/gogs/new", bindIgnErr(form.NewWebhook{}), repo.WebhooksNewPost)
+				m.Post("/slack/new", bindIgnErr(form.NewSlackHook{}), repo.WebhooksSlackNewPost)
+				m.Post("/discord/new", bindIgnErr(form.NewDiscordHook{}), repo.WebhooksDiscordNewPost)
+				m.Post("/dingtalk/new", bindIgnErr(form.NewDingtalkHook{}), repo.WebhooksDingtalkNewPost)
+				m.Get("/:id", repo.WebhooksEdit)
+				m.Post("/gogs/:id", bindIgnErr(form.NewWebhook{}), repo.WebhooksEditPost)
+				m.Post("/slack/:id", bindIgnErr(form.NewSlackHook{}), repo.WebhooksSlackEditPost)
+				m.Post("/discord/:id", bindIgnErr(form.NewDiscordHook{}), repo.WebhooksDiscordEditPost)
+				m.Post("/dingtalk/:id", bindIgnErr(form.NewDingtalkHook{}), repo.WebhooksDingtalkEditPost)
+			}, repo.InjectOrgRepoContext())
+		}
+
 		// ***** START: Admin *****
 		m.Group("/admin", func() {
 			m.Combo("").Get(admin.Dashboard).Post(admin.Operation) // "/admin"
@@ -286,6 +308,12 @@ func runWeb(c *cli.Context) error {
 				m.Post("/delete", admin.DeleteNotices)
 				m.Get("/empty", admin.EmptyNotices)
 			})
+
+			m.Group("/hooks", func() {
+				webhookRoutes()
+
+				m.Post("/:id/redelivery", repo.InjectOrgRepoContext(), repo.RedeliveryWebhook)
+			}, admin.HooksContext)
 		}, reqAdmin)
 		// ***** END: Admin *****
 
@@ -337,23 +365,6 @@ func runWeb(c *cli.Context) error {
 		reqRepoAdmin := context.RequireRepoAdmin()
 		reqRepoWriter := context.RequireRepoWriter()
 
-		webhookRoutes := func() {
-			m.Group("", func() {
-				m.Get("", repo.Webhooks)
-				m.Post("/delete", repo.DeleteWebhook)
-				m.Get("/:type/new", repo.WebhooksNew)
-				m.Post("/gogs/new", bindIgnErr(form.NewWebhook{}), repo.WebhooksNewPost)
-				m.Post("/slack/new", bindIgnErr(form.NewSlackHook{}), repo.WebhooksSlackNewPost)
-				m.Post("/discord/new", bindIgnErr(form.NewDiscordHook{}), repo.WebhooksDiscordNewPost)
-				m.Post("/dingtalk/new", bindIgnErr(form.NewDingtalkHook{}), repo.WebhooksDingtalkNewPost)
-				m.Get("/:id", repo.WebhooksEdit)
-				m.Post("/gogs/:id", bindIgnErr(form.NewWebhook{}), repo.WebhooksEditPost)
-				m.Post("/slack/:id", bindIgnErr(form.NewSlackHook{}), repo.WebhooksSlackEditPost)
-				m.Post("/discord/:id", bindIgnErr(form.NewDiscordHook{}), repo.WebhooksDiscordEditPost)
-				m.Post("/dingtalk/:id", bindIgnErr(form.NewDingtalkHook{}), repo.WebhooksDingtalkEditPost)
-			}, repo.InjectOrgRepoContext())
-		}
-
 		// ***** START: Organization *****
 		m.Group("/org", func() {
 			m.Group("", func() {
@@ -441,7 +452,7 @@ func runWeb(c *cli.Context) error {
 
 					m.Group("/:id", func() {
 						m.Post("/test", repo.TestWebhook)
-						m.Post("/redelivery", repo.RedeliveryWebhook)
+						m.Post("/redelivery", repo.InjectOrgRepoContext(), repo.RedeliveryWebhook)
 					})
 
 					m.Group("/git", func() {
@@ -457,6 +468,12 @@ func runWeb(c *cli.Context) error {
 					m.Post("/delete", repo.DeleteDeployKey)
 				})
 
+				m.Group("/issues", func() {
+					m.Get("", repo.SettingsIssues)
+					m.Get("/export", repo.SettingsIssuesExport)
+					m.Post("/import", repo.SettingsIssuesImportPost)
+				})
+
 			}, func(c *context.Context) {
 				c.Data["PageIsSettings"] = true
 			})
@@ -480,11 +497,13 @@ func runWeb(c *cli.Context) error {
 					m.Post("/title", repo.UpdateIssueTitle)
 					m.Post("/content", repo.UpdateIssueContent)
 					m.Combo("/comments").Post(bindIgnErr(form.CreateComment{}), repo.NewComment)
+					m.Post("/reactions/:action", repo.ChangeIssueReaction)
 				})
 			})
 			m.Group("/comments/:id", func() {
 				m.Post("", repo.UpdateCommentContent)
 				m.Post("/delete", repo.DeleteComment)
+				m.Post("/reactions/:action", repo.ChangeCommentReaction)
 			})
 		}, reqSignIn, context.RepoAssignment(true))
 		m.Group("/:username/:reponame", func() {
@@ -498,10 +517,21 @@ func runWeb(c *cli.Context) error {
 			// FIXME: should use different URLs but mostly same logic for comments of issue and pull reuqest.
 			// So they can apply their own enable/disable logic on routers.
 			m.Group("/issues", func() {
+				m.Post("/bulk", reqRepoWriter, repo.BulkEditIssues)
 				m.Group("/:index", func() {
 					m.Post("/label", repo.UpdateIssueLabel)
 					m.Post("/milestone", repo.UpdateIssueMilestone)
 					m.Post("/assignee", repo.UpdateIssueAssignee)
+					m.Post("/dependency", repo.AddIssueDependency)
+					m.Post("/dependency/delete", repo.RemoveIssueDependency)
+					m.Post("/lock", repo.LockIssue)
+					m.Post("/pin", repo.PinIssue)
+					m.Post("/transfer", repo.TransferIssue)
+					m.Group("/times", func() {
+						m.Post("/add", bindIgnErr(form.AddTrackedTime{}), repo.AddIssueTrackedTime)
+						m.Post("/stopwatch/toggle", repo.ToggleIssueStopwatch)
+						m.Post("/stopwatch/cancel", repo.CancelIssueStopwatch)
+					})
 				}, reqRepoWriter)
 			})
 			m.Group("/labels", func() {
@@ -597,6 +627,16 @@ func runWeb(c *cli.Context) error {
 				m.Get("/commits", context.RepoRef(), repo.ViewPullCommits)
 				m.Get("/files", context.RepoRef(), repo.ViewPullFiles)
 				m.Post("/merge", reqRepoWriter, repo.MergePullRequest)
+				m.Post("/draft", reqSignIn, repo.SetPullRequestDraft)
+				m.Group("/auto_merge", func() {
+					m.Post("", repo.ScheduleAutoMerge)
+					m.Post("/cancel", repo.CancelAutoMerge)
+				}, reqRepoWriter)
+				m.Group("/reviews", func() {
+					m.Post("", bindIgnErr(form.CreateReview{}), repo.CreateReview)
+					m.Post("/:id/dismiss", reqRepoWriter, repo.DismissReview)
+				}, reqSignIn)
+				m.Post("/code_comments", reqSignIn, bindIgnErr(form.CreateCodeComment{}), repo.CreateCodeComment)
 			}, repo.MustAllowPulls)
 
 			m.Group("", func() {
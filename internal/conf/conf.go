@@ -231,6 +231,24 @@ func Init(customConf string) error {
 		Email.FromEmail = parsed.Address
 	}
 
+	if err = File.Section("email.incoming").MapTo(&IncomingEmail); err != nil {
+		return errors.Wrap(err, "mapping [email.incoming] section")
+	}
+
+	if IncomingEmail.Enabled {
+		if !strings.Contains(IncomingEmail.ReplyToAddress, "%{token}") {
+			return errors.Errorf("reply-to address %q of incoming email must contain \"%%{token}\"", IncomingEmail.ReplyToAddress)
+		}
+
+		switch IncomingEmail.Protocol {
+		case "maildir":
+			IncomingEmail.MaildirPath = ensureAbs(IncomingEmail.MaildirPath)
+		case "imap":
+		default:
+			return errors.Errorf("unsupported protocol %q of incoming email", IncomingEmail.Protocol)
+		}
+	}
+
 	// ***********************************
 	// ----- Authentication settings -----
 	// ***********************************
@@ -76,6 +76,7 @@ var (
 		EnableLocalPathMigration bool
 		EnableRawFileRenderMode  bool
 		CommitsFetchConcurrency  int
+		MaxPinnedIssues          int
 
 		// Repository editor settings
 		Editor struct {
@@ -135,6 +136,22 @@ var (
 		Passwd string
 	}
 
+	// Incoming email settings
+	IncomingEmail struct {
+		Enabled        bool
+		ReplyToAddress string
+		Protocol       string
+		PollInterval   time.Duration
+
+		MaildirPath string
+
+		IMAPHost       string `ini:"IMAP_HOST"`
+		IMAPUser       string `ini:"IMAP_USER"`
+		IMAPPassword   string `ini:"IMAP_PASSWORD"`
+		IMAPMailbox    string `ini:"IMAP_MAILBOX"`
+		IMAPSkipVerify bool   `ini:"IMAP_SKIP_VERIFY"`
+	}
+
 	// Authentication settings
 	Auth struct {
 		ActivateCodeLives         int
@@ -248,6 +265,10 @@ var (
 		DeliverTimeout int
 		SkipTLSVerify  bool `ini:"SKIP_TLS_VERIFY"`
 		PagingNum      int
+
+		MaxAttempts      int
+		RetryInterval    time.Duration
+		MaxRetryInterval time.Duration
 	}
 
 	// Markdown settings
@@ -131,6 +131,12 @@ func (c *Context) HasValue(name string) bool {
 // HTML responses template with given status.
 func (c *Context) HTML(status int, name string) {
 	log.Trace("Template: %s", name)
+
+	// The count is only needed by the page header, it is not loaded for requests
+	// that do not render a page.
+	if c.IsLogged && c.Data["NotificationUnreadCount"] == nil {
+		c.Data["NotificationUnreadCount"] = db.CountUnreadNotifications(c.User.ID)
+	}
 	c.Context.HTML(status, name)
 }
 
@@ -136,6 +136,15 @@ func (user *User) GetAccessibleRepositories(limit int) (repos []*Repository, _ e
 	return repos, sess.Join("INNER", "access", "access.user_id = ? AND access.repo_id = repository.id", user.ID).Find(&repos)
 }
 
+// GetAccessibleRepoIDs returns IDs of repositories that the user owns or has access to.
+func (u *User) GetAccessibleRepoIDs() ([]int64, error) {
+	ids := make([]int64, 0, 10)
+	return ids, x.Table("repository").
+		Where("owner_id = ? OR id IN (SELECT repo_id FROM access WHERE user_id = ?)", u.ID, u.ID).
+		Cols("id").
+		Find(&ids)
+}
+
 func maxAccessMode(modes ...AccessMode) AccessMode {
 	max := AccessModeNone
 	for _, mode := range modes {
@@ -398,6 +398,10 @@ func UpdateIssuesCommit(doer *User, repo *Repository, commits []*PushCommit) err
 			}
 
 			if err = issue.ChangeStatus(doer, repo, true); err != nil {
+				// Issues that are blocked by open issues are left open.
+				if IsErrIssueHasOpenBlockers(err) {
+					continue
+				}
 				return err
 			}
 		}
@@ -7,7 +7,6 @@ package db
 import (
 	"fmt"
 	"io"
-	"mime/multipart"
 	"os"
 	"path"
 	"time"
@@ -53,35 +52,43 @@ func (attach *Attachment) LocalPath() string {
 	return AttachmentLocalPath(attach.UUID)
 }
 
-// NewAttachment creates a new attachment object.
-func NewAttachment(name string, buf []byte, file multipart.File) (_ *Attachment, err error) {
+// NewAttachment creates a new attachment object, the content of the file is the
+// given buffer followed by the rest of the reader.
+func NewAttachment(name string, buf []byte, r io.Reader) (_ *Attachment, err error) {
 	attach := &Attachment{
 		UUID: gouuid.NewV4().String(),
 		Name: name,
 	}
+	if err = attach.saveFile(buf, r); err != nil {
+		return nil, err
+	}
 
-	localPath := attach.LocalPath()
-	if err = os.MkdirAll(path.Dir(localPath), os.ModePerm); err != nil {
-		return nil, fmt.Errorf("MkdirAll: %v", err)
+	if _, err := x.Insert(attach); err != nil {
+		return nil, err
+	}
+
+	return attach, nil
+}
+
+// saveFile writes given content to the local path of the attachment.
+func (a *Attachment) saveFile(buf []byte, r io.Reader) error {
+	localPath := a.LocalPath()
+	if err := os.MkdirAll(path.Dir(localPath), os.ModePerm); err != nil {
+		return fmt.Errorf("MkdirAll: %v", err)
 	}
 
 	fw, err := os.Create(localPath)
 	if err != nil {
-		return nil, fmt.Errorf("Create: %v", err)
+		return fmt.Errorf("Create: %v", err)
 	}
 	defer fw.Close()
 
 	if _, err = fw.Write(buf); err != nil {
-		return nil, fmt.Errorf("Write: %v", err)
-	} else if _, err = io.Copy(fw, file); err != nil {
-		return nil, fmt.Errorf("Copy: %v", err)
+		return fmt.Errorf("Write: %v", err)
+	} else if _, err = io.Copy(fw, r); err != nil {
+		return fmt.Errorf("Copy: %v", err)
 	}
-
-	if _, err := x.Insert(attach); err != nil {
-		return nil, err
-	}
-
-	return attach, nil
+	return nil
 }
 
 var _ errutil.NotFound = (*ErrAttachmentNotExist)(nil)
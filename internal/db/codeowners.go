@@ -0,0 +1,343 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "unknwon.dev/clog/v2"
+
+	"github.com/gogs/git-module"
+
+	"gogs.io/gogs/internal/gitutil"
+)
+
+// codeOwnersPaths is the list of paths where the CODEOWNERS file is looked up,
+// the first one exists wins.
+var codeOwnersPaths = []string{"CODEOWNERS", "docs/CODEOWNERS", ".gogs/CODEOWNERS"}
+
+type codeOwnersRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// CodeOwners represents the parsed content of a CODEOWNERS file.
+type CodeOwners struct {
+	rules []*codeOwnersRule
+}
+
+// codeOwnersPatternToRegexp converts a gitignore-style pattern to a regular expression.
+func codeOwnersPatternToRegexp(pattern string) (*regexp.Regexp, error) {
+	anchored := strings.HasPrefix(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	isDir := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	if pattern == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+
+	// A pattern without slash in the middle matches at any level.
+	if !strings.Contains(pattern, "/") && !anchored {
+		pattern = "**/" + pattern
+	}
+
+	var buf strings.Builder
+	buf.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			buf.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			buf.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			buf.WriteString("[^/]*")
+		case pattern[i] == '?':
+			buf.WriteString("[^/]")
+		default:
+			buf.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	if isDir {
+		buf.WriteString("/.*$")
+	} else {
+		// The pattern may also match a directory, which owns everything inside.
+		buf.WriteString("(/.*)?$")
+	}
+	return regexp.Compile(buf.String())
+}
+
+// isValidCodeOwner returns true if given owner is a user name (@user), a team of
+// the organization (@org/team) or an email address.
+func isValidCodeOwner(owner string) bool {
+	if strings.HasPrefix(owner, "@") {
+		name := owner[1:]
+		if i := strings.Index(name, "/"); i > -1 {
+			return i > 0 && i < len(name)-1 && !strings.Contains(name[i+1:], "/")
+		}
+		return name != ""
+	}
+	i := strings.Index(owner, "@")
+	return i > 0 && i < len(owner)-1
+}
+
+// ParseCodeOwners parses the content of a CODEOWNERS file. Each line consists of
+// a gitignore-style pattern followed by owners, which are user names (@user),
+// teams of the organization (@org/team) or email addresses. Invalid owners and
+// lines without any valid owner are ignored.
+func ParseCodeOwners(data []byte) *CodeOwners {
+	codeOwners := new(CodeOwners)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i > -1 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		owners := make([]string, 0, len(fields)-1)
+		for _, owner := range fields[1:] {
+			if isValidCodeOwner(owner) {
+				owners = append(owners, owner)
+			}
+		}
+		if len(owners) == 0 {
+			log.Trace("ParseCodeOwners: skip pattern %q without valid owners", fields[0])
+			continue
+		}
+
+		pattern, err := codeOwnersPatternToRegexp(fields[0])
+		if err != nil {
+			log.Trace("ParseCodeOwners: skip invalid pattern %q: %v", fields[0], err)
+			continue
+		}
+		codeOwners.rules = append(codeOwners.rules, &codeOwnersRule{
+			pattern: pattern,
+			owners:  owners,
+		})
+	}
+	return codeOwners
+}
+
+// Match returns owners of given file path. The last matching rule takes precedence.
+func (o *CodeOwners) Match(path string) []string {
+	for i := len(o.rules) - 1; i >= 0; i-- {
+		if o.rules[i].pattern.MatchString(path) {
+			return o.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// GetCodeOwners returns the parsed CODEOWNERS file in given branch of the repository.
+// It returns nil if the branch does not have a CODEOWNERS file.
+func (repo *Repository) GetCodeOwners(branch string) (*CodeOwners, error) {
+	gitRepo, err := git.Open(repo.RepoPath())
+	if err != nil {
+		return nil, fmt.Errorf("open repository: %v", err)
+	}
+	commit, err := gitRepo.BranchCommit(branch)
+	if err != nil {
+		return nil, fmt.Errorf("get branch commit: %v", err)
+	}
+
+	for _, path := range codeOwnersPaths {
+		blob, err := commit.Blob(path)
+		if err != nil {
+			if gitutil.IsErrRevisionNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("get blob %q: %v", path, err)
+		}
+
+		data, err := blob.Bytes()
+		if err != nil {
+			return nil, fmt.Errorf("read blob %q: %v", path, err)
+		}
+		return ParseCodeOwners(data), nil
+	}
+	return nil, nil
+}
+
+// codeOwnerSet is a set of resolved code owners.
+type codeOwnerSet struct {
+	users map[int64]*User
+	teams map[int64]*Team
+}
+
+// has returns true if given user is in the set, either directly or as a team member.
+func (s *codeOwnerSet) has(userID int64) bool {
+	if s.users[userID] != nil {
+		return true
+	}
+	for _, t := range s.teams {
+		if t.IsMember(userID) {
+			return true
+		}
+	}
+	return false
+}
+
+// unapprovedCodeOwnerFiles returns sorted files whose code owners are not any of
+// given approvers.
+func unapprovedCodeOwnerFiles(owners map[string]*codeOwnerSet, approverIDs []int64) []string {
+	var files []string
+nextFile:
+	for file, set := range owners {
+		for _, id := range approverIDs {
+			if set.has(id) {
+				continue nextFile
+			}
+		}
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// resolveCodeOwners resolves given owners in the context of the repository.
+// Owners that do not exist or cannot access the repository are skipped.
+func (repo *Repository) resolveCodeOwners(owners []string, cache map[string]*codeOwnerSet) *codeOwnerSet {
+	set := &codeOwnerSet{
+		users: make(map[int64]*User),
+		teams: make(map[int64]*Team),
+	}
+	for _, owner := range owners {
+		if cached, ok := cache[owner]; ok {
+			for id, u := range cached.users {
+				set.users[id] = u
+			}
+			for id, t := range cached.teams {
+				set.teams[id] = t
+			}
+			continue
+		}
+
+		resolved := &codeOwnerSet{
+			users: make(map[int64]*User),
+			teams: make(map[int64]*Team),
+		}
+		cache[owner] = resolved
+
+		var err error
+		switch {
+		case strings.HasPrefix(owner, "@") && strings.Contains(owner, "/"):
+			// Only teams of the organization who owns the repository are able to review.
+			fields := strings.SplitN(owner[1:], "/", 2)
+			repoOwner := repo.MustOwner()
+			if !repoOwner.IsOrganization() || !strings.EqualFold(fields[0], repoOwner.Name) {
+				continue
+			}
+			var t *Team
+			t, err = GetTeamOfOrgByName(repo.OwnerID, fields[1])
+			if err == nil && t.HasRepository(repo.ID) {
+				resolved.teams[t.ID] = t
+			}
+		default:
+			var u *User
+			if strings.HasPrefix(owner, "@") {
+				u, err = GetUserByName(owner[1:])
+			} else {
+				u, err = GetUserByEmail(owner)
+			}
+			if err == nil && !u.IsOrganization() && repo.HasAccess(u.ID) {
+				resolved.users[u.ID] = u
+			}
+		}
+		if err != nil && !IsErrUserNotExist(err) && !IsErrTeamNotExist(err) {
+			log.Error("Failed to resolve code owner %q [repo_id: %d]: %v", owner, repo.ID, err)
+		}
+
+		for id, u := range resolved.users {
+			set.users[id] = u
+		}
+		for id, t := range resolved.teams {
+			set.teams[id] = t
+		}
+	}
+	return set
+}
+
+// changedFiles returns the list of files changed by the pull request.
+func (pr *PullRequest) changedFiles() ([]string, error) {
+	if pr.HeadRepo == nil {
+		return nil, fmt.Errorf("head repository [%d] does not exist", pr.HeadRepoID)
+	}
+	return gitutil.Module.RepoDiffNameOnly(pr.HeadRepo.RepoPath(), pr.MergeBase, pr.HeadBranch)
+}
+
+// codeOwnersOfChangedFiles returns resolved code owners of each changed file of the pull request,
+// files without owners are omitted. It returns nil if the base branch has no CODEOWNERS file.
+func (pr *PullRequest) codeOwnersOfChangedFiles() (map[string]*codeOwnerSet, error) {
+	codeOwners, err := pr.BaseRepo.GetCodeOwners(pr.BaseBranch)
+	if err != nil {
+		return nil, fmt.Errorf("get code owners: %v", err)
+	} else if codeOwners == nil {
+		return nil, nil
+	}
+
+	files, err := pr.changedFiles()
+	if err != nil {
+		return nil, fmt.Errorf("get changed files: %v", err)
+	}
+
+	cache := make(map[string]*codeOwnerSet)
+	owners := make(map[string]*codeOwnerSet, len(files))
+	for _, file := range files {
+		set := pr.BaseRepo.resolveCodeOwners(codeOwners.Match(file), cache)
+		if len(set.users) > 0 || len(set.teams) > 0 {
+			owners[file] = set
+		}
+	}
+	return owners, nil
+}
+
+// requestCodeOwnerReviews requests reviews from code owners of files changed by
+// the pull request, the poster is never requested.
+func (pr *PullRequest) requestCodeOwnerReviews() error {
+	if err := pr.LoadIssue(); err != nil {
+		return fmt.Errorf("load issue: %v", err)
+	} else if err = pr.LoadAttributes(); err != nil {
+		return fmt.Errorf("load attributes: %v", err)
+	}
+
+	owners, err := pr.codeOwnersOfChangedFiles()
+	if err != nil {
+		return err
+	} else if len(owners) == 0 {
+		return nil
+	}
+
+	userIDs := make([]int64, 0, len(owners))
+	teamIDs := make([]int64, 0, len(owners))
+	seen := make(map[string]bool)
+	for _, set := range owners {
+		for id := range set.users {
+			key := fmt.Sprintf("user:%d", id)
+			if !seen[key] && id != pr.Issue.PosterID {
+				userIDs = append(userIDs, id)
+			}
+			seen[key] = true
+		}
+		for id := range set.teams {
+			key := fmt.Sprintf("team:%d", id)
+			if !seen[key] {
+				teamIDs = append(teamIDs, id)
+			}
+			seen[key] = true
+		}
+	}
+
+	return requestReviews(pr.IssueID, userIDs, teamIDs, true)
+}
@@ -0,0 +1,120 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeOwners_Match(t *testing.T) {
+	codeOwners := ParseCodeOwners([]byte(`
+# Default owners
+*           @alice
+
+*.go        @bob bob@example.com # Go files
+/docs/      @org/writers
+build/      @carol
+/Makefile   @dave
+src/**/*.md @erin
+invalid
+`))
+
+	tests := []struct {
+		path      string
+		expOwners []string
+	}{
+		{path: "README.md", expOwners: []string{"@alice"}},
+		{path: "main.go", expOwners: []string{"@bob", "bob@example.com"}},
+		{path: "internal/db/pull.go", expOwners: []string{"@bob", "bob@example.com"}},
+		{path: "docs/README.md", expOwners: []string{"@org/writers"}},
+		{path: "internal/docs/README.md", expOwners: []string{"@alice"}},
+		{path: "build/ci.yml", expOwners: []string{"@carol"}},
+		{path: "tools/build/ci.yml", expOwners: []string{"@carol"}},
+		{path: "Makefile", expOwners: []string{"@dave"}},
+		{path: "tools/Makefile", expOwners: []string{"@alice"}},
+		{path: "src/a/b/c.md", expOwners: []string{"@erin"}},
+		{path: "src/c.md", expOwners: []string{"@erin"}},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			assert.Equal(t, test.expOwners, codeOwners.Match(test.path))
+		})
+	}
+
+	assert.Nil(t, ParseCodeOwners([]byte("/docs/ @org/writers")).Match("main.go"))
+}
+
+func TestParseCodeOwners(t *testing.T) {
+	codeOwners := ParseCodeOwners([]byte(`
+# Comment lines and blank lines are ignored
+
+   # Indented comment
+*.go    @alice # Trailing comment
+*.md    @ alice @org/ @/team @org/docs/extra bob@example.com
+*.txt   alice @
+/docs/
+`))
+
+	owners := make([][]string, len(codeOwners.rules))
+	for i := range codeOwners.rules {
+		owners[i] = codeOwners.rules[i].owners
+	}
+	assert.Equal(t, [][]string{
+		{"@alice"},
+		{"bob@example.com"},
+	}, owners)
+
+	assert.Nil(t, codeOwners.Match("notes.txt"))
+	assert.Nil(t, codeOwners.Match("docs/README"))
+}
+
+func Test_isValidCodeOwner(t *testing.T) {
+	tests := []struct {
+		owner  string
+		expVal bool
+	}{
+		{owner: "@alice", expVal: true},
+		{owner: "@org/team", expVal: true},
+		{owner: "alice@example.com", expVal: true},
+		{owner: "@", expVal: false},
+		{owner: "alice", expVal: false},
+		{owner: "@org/", expVal: false},
+		{owner: "@/team", expVal: false},
+		{owner: "@org/team/sub", expVal: false},
+		{owner: "alice@", expVal: false},
+	}
+	for _, test := range tests {
+		t.Run(test.owner, func(t *testing.T) {
+			assert.Equal(t, test.expVal, isValidCodeOwner(test.owner))
+		})
+	}
+}
+
+func Test_unapprovedCodeOwnerFiles(t *testing.T) {
+	alice := &User{ID: 1, Name: "alice"}
+	bob := &User{ID: 2, Name: "bob"}
+	owners := map[string]*codeOwnerSet{
+		"main.go":   {users: map[int64]*User{alice.ID: alice}},
+		"README.md": {users: map[int64]*User{bob.ID: bob}},
+		"Makefile":  {users: map[int64]*User{alice.ID: alice, bob.ID: bob}},
+	}
+
+	assert.Equal(t, []string{"Makefile", "README.md", "main.go"}, unapprovedCodeOwnerFiles(owners, nil))
+	assert.Equal(t, []string{"README.md"}, unapprovedCodeOwnerFiles(owners, []int64{alice.ID}))
+	assert.Equal(t, []string{"main.go"}, unapprovedCodeOwnerFiles(owners, []int64{bob.ID, 3}))
+	assert.Nil(t, unapprovedCodeOwnerFiles(owners, []int64{alice.ID, bob.ID}))
+	assert.Nil(t, unapprovedCodeOwnerFiles(nil, []int64{alice.ID}))
+
+	t.Run("merge requirements", func(t *testing.T) {
+		reqs := &MergeRequirements{UnapprovedCodeOwnerFiles: unapprovedCodeOwnerFiles(owners, []int64{alice.ID})}
+		assert.False(t, reqs.IsSatisfied())
+		assert.False(t, reqs.CanMerge())
+
+		reqs.UnapprovedCodeOwnerFiles = unapprovedCodeOwnerFiles(owners, []int64{alice.ID, bob.ID})
+		assert.True(t, reqs.IsSatisfied())
+	})
+}
@@ -36,6 +36,17 @@ const (
 	COMMENT_TYPE_COMMENT_REF
 	// Reference from a pull request
 	COMMENT_TYPE_PULL_REF
+	// Submitted review of a pull request
+	COMMENT_TYPE_REVIEW
+	// Comment on a line of pull request diff
+	COMMENT_TYPE_CODE
+	// Lock conversation, the content is the reason
+	COMMENT_TYPE_LOCK
+	COMMENT_TYPE_UNLOCK
+	// Transfer from another repository, the content is the former reference
+	COMMENT_TYPE_TRANSFER
+	// Dismissal of a submitted review of a pull request
+	COMMENT_TYPE_REVIEW_DISMISSED
 )
 
 type CommentTag int
@@ -68,7 +79,18 @@ type Comment struct {
 	// Reference issue in commit message
 	CommitSHA string `xorm:"VARCHAR(40)"`
 
+	// For pull request reviews
+	ReviewID int64   `xorm:"INDEX"`
+	Review   *Review `xorm:"-" json:"-"`
+
+	// For code comments, which are anchored to a line of the diff at CommitSHA.
+	TreePath   string
+	Side       DiffSide `xorm:"VARCHAR(5)"`
+	Patch      string   `xorm:"TEXT"`
+	IsOutdated bool
+
 	Attachments []*Attachment `xorm:"-" json:"-"`
+	Reactions   ReactionList  `xorm:"-" json:"-"`
 
 	// For view issue page.
 	ShowTag CommentTag `xorm:"-" json:"-"`
@@ -118,6 +140,13 @@ func (c *Comment) loadAttributes(e Engine) (err error) {
 		}
 	}
 
+	if c.ReviewID > 0 && c.Review == nil {
+		c.Review, err = getReviewByID(e, c.ReviewID)
+		if err != nil && !IsErrReviewNotExist(err) {
+			return fmt.Errorf("getReviewByID [%d]: %v", c.ReviewID, err)
+		}
+	}
+
 	if c.Attachments == nil {
 		c.Attachments, err = getAttachmentsByCommentID(e, c.ID)
 		if err != nil {
@@ -136,16 +165,26 @@ func (c *Comment) HTMLURL() string {
 	return fmt.Sprintf("%s#issuecomment-%d", c.Issue.HTMLURL(), c.ID)
 }
 
+// APIComment represents the API format of a comment with its reactions.
+type APIComment struct {
+	*api.Comment
+	Reactions []*APIReaction `json:"reactions"`
+}
+
 // This method assumes following fields have been assigned with valid values:
 // Required - Poster, Issue
-func (c *Comment) APIFormat() *api.Comment {
-	return &api.Comment{
-		ID:      c.ID,
-		HTMLURL: c.HTMLURL(),
-		Poster:  c.Poster.APIFormat(),
-		Body:    c.Content,
-		Created: c.Created,
-		Updated: c.Updated,
+// Optional - Reactions
+func (c *Comment) APIFormat() *APIComment {
+	return &APIComment{
+		Comment: &api.Comment{
+			ID:      c.ID,
+			HTMLURL: c.HTMLURL(),
+			Poster:  c.Poster.APIFormat(),
+			Body:    c.Content,
+			Created: c.Created,
+			Updated: c.Updated,
+		},
+		Reactions: c.Reactions.APIFormat(),
 	}
 }
 
@@ -167,7 +206,7 @@ func (c *Comment) EventTag() string {
 // and mentioned people.
 func (cmt *Comment) mailParticipants(e Engine, opType ActionType, issue *Issue) (err error) {
 	mentions := markup.FindAllMentions(cmt.Content)
-	if err = updateIssueMentions(e, cmt.IssueID, mentions); err != nil {
+	if err = updateIssueMentions(e, issue, cmt.PosterID, mentions); err != nil {
 		return fmt.Errorf("UpdateIssueMentions [%d]: %v", cmt.IssueID, err)
 	}
 
@@ -196,6 +235,10 @@ func createComment(e *xorm.Session, opts *CreateCommentOptions) (_ *Comment, err
 		CommitSHA: opts.CommitSHA,
 		Line:      opts.LineNum,
 		Content:   opts.Content,
+		ReviewID:  opts.ReviewID,
+		TreePath:  opts.TreePath,
+		Side:      opts.Side,
+		Patch:     opts.Patch,
 	}
 	if _, err = e.Insert(comment); err != nil {
 		return nil, err
@@ -215,11 +258,13 @@ func createComment(e *xorm.Session, opts *CreateCommentOptions) (_ *Comment, err
 
 	// Check comment type.
 	switch opts.Type {
-	case COMMENT_TYPE_COMMENT:
+	case COMMENT_TYPE_COMMENT, COMMENT_TYPE_CODE:
 		act.OpType = ACTION_COMMENT_ISSUE
 
-		if _, err = e.Exec("UPDATE `issue` SET num_comments=num_comments+1 WHERE id=?", opts.Issue.ID); err != nil {
-			return nil, err
+		if !opts.IsPending {
+			if _, err = e.Exec("UPDATE `issue` SET num_comments=num_comments+1 WHERE id=?", opts.Issue.ID); err != nil {
+				return nil, err
+			}
 		}
 
 		// Check attachments
@@ -280,7 +325,7 @@ func createComment(e *xorm.Session, opts *CreateCommentOptions) (_ *Comment, err
 	}
 
 	// Notify watchers for whatever action comes in, ignore if no action type.
-	if act.OpType > 0 {
+	if act.OpType > 0 && !opts.IsPending {
 		if err = notifyWatchers(e, act); err != nil {
 			log.Error("notifyWatchers: %v", err)
 		}
@@ -315,7 +360,14 @@ type CreateCommentOptions struct {
 	CommitSHA   string
 	LineNum     int64
 	Content     string
+	ReviewID    int64
+	TreePath    string
+	Side        DiffSide
+	Patch       string
 	Attachments []string // UUIDs of attachments
+	// Code comments of a pending review are neither counted nor announced
+	// until the review is submitted.
+	IsPending bool
 }
 
 // CreateComment creates comment of issue or commit.
@@ -331,7 +383,29 @@ func CreateComment(opts *CreateCommentOptions) (comment *Comment, err error) {
 		return nil, err
 	}
 
-	return comment, sess.Commit()
+	if err = sess.Commit(); err != nil {
+		return nil, err
+	}
+
+	switch comment.Type {
+	case COMMENT_TYPE_COMMENT, COMMENT_TYPE_REVIEW, COMMENT_TYPE_CODE:
+		if !opts.IsPending {
+			updateIssueIndexer(comment.IssueID)
+		}
+	}
+	return comment, nil
+}
+
+func (c *Comment) sendCreatedWebhook(doer *User, repo *Repository, issue *Issue) {
+	if err := PrepareWebhooks(repo, HOOK_EVENT_ISSUE_COMMENT, &api.IssueCommentPayload{
+		Action:     api.HOOK_ISSUE_COMMENT_CREATED,
+		Issue:      issue.APIFormat(),
+		Comment:    c.APIFormat().Comment,
+		Repository: repo.APIFormat(nil),
+		Sender:     doer.APIFormat(),
+	}); err != nil {
+		log.Error("PrepareWebhooks [comment_id: %d]: %v", c.ID, err)
+	}
 }
 
 // CreateIssueComment creates a plain issue comment.
@@ -349,16 +423,7 @@ func CreateIssueComment(doer *User, repo *Repository, issue *Issue, content stri
 	}
 
 	comment.Issue = issue
-	if err = PrepareWebhooks(repo, HOOK_EVENT_ISSUE_COMMENT, &api.IssueCommentPayload{
-		Action:     api.HOOK_ISSUE_COMMENT_CREATED,
-		Issue:      issue.APIFormat(),
-		Comment:    comment.APIFormat(),
-		Repository: repo.APIFormat(nil),
-		Sender:     doer.APIFormat(),
-	}); err != nil {
-		log.Error("PrepareWebhooks [comment_id: %d]: %v", comment.ID, err)
-	}
-
+	comment.sendCreatedWebhook(doer, repo, issue)
 	return comment, nil
 }
 
@@ -482,13 +547,17 @@ func UpdateComment(doer *User, c *Comment, oldContent string) (err error) {
 	if _, err = x.Id(c.ID).AllCols().Update(c); err != nil {
 		return err
 	}
+	if c.IsPending() {
+		return nil
+	}
+	updateIssueIndexer(c.IssueID)
 
 	if err = c.Issue.LoadAttributes(); err != nil {
 		log.Error("Issue.LoadAttributes [issue_id: %d]: %v", c.IssueID, err)
 	} else if err = PrepareWebhooks(c.Issue.Repo, HOOK_EVENT_ISSUE_COMMENT, &api.IssueCommentPayload{
 		Action:  api.HOOK_ISSUE_COMMENT_EDITED,
 		Issue:   c.Issue.APIFormat(),
-		Comment: c.APIFormat(),
+		Comment: c.APIFormat().Comment,
 		Changes: &api.ChangesPayload{
 			Body: &api.ChangesFromPayload{
 				From: oldContent,
@@ -521,9 +590,11 @@ func DeleteCommentByID(doer *User, id int64) error {
 
 	if _, err = sess.ID(comment.ID).Delete(new(Comment)); err != nil {
 		return err
+	} else if _, err = sess.Delete(&Reaction{CommentID: comment.ID}); err != nil {
+		return err
 	}
 
-	if comment.Type == COMMENT_TYPE_COMMENT {
+	if (comment.Type == COMMENT_TYPE_COMMENT || comment.Type == COMMENT_TYPE_CODE) && !comment.IsPending() {
 		if _, err = sess.Exec("UPDATE `issue` SET num_comments = num_comments - 1 WHERE id = ?", comment.IssueID); err != nil {
 			return err
 		}
@@ -532,18 +603,22 @@ func DeleteCommentByID(doer *User, id int64) error {
 	if err = sess.Commit(); err != nil {
 		return fmt.Errorf("commit: %v", err)
 	}
+	updateIssueIndexer(comment.IssueID)
 
 	_, err = DeleteAttachmentsByComment(comment.ID, true)
 	if err != nil {
 		log.Error("Failed to delete attachments by comment[%d]: %v", comment.ID, err)
 	}
 
+	if comment.IsPending() {
+		return nil
+	}
 	if err = comment.Issue.LoadAttributes(); err != nil {
 		log.Error("Issue.LoadAttributes [issue_id: %d]: %v", comment.IssueID, err)
 	} else if err = PrepareWebhooks(comment.Issue.Repo, HOOK_EVENT_ISSUE_COMMENT, &api.IssueCommentPayload{
 		Action:     api.HOOK_ISSUE_COMMENT_DELETED,
 		Issue:      comment.Issue.APIFormat(),
-		Comment:    comment.APIFormat(),
+		Comment:    comment.APIFormat().Comment,
 		Repository: comment.Issue.Repo.APIFormat(nil),
 		Sender:     doer.APIFormat(),
 	}); err != nil {
@@ -0,0 +1,314 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	log "unknwon.dev/clog/v2"
+	"xorm.io/xorm"
+
+	api "github.com/gogs/go-gogs-client"
+)
+
+// CommitStatusState represents the state of a commit status.
+type CommitStatusState string
+
+const (
+	COMMIT_STATUS_PENDING CommitStatusState = "pending"
+	COMMIT_STATUS_SUCCESS CommitStatusState = "success"
+	COMMIT_STATUS_ERROR   CommitStatusState = "error"
+	COMMIT_STATUS_FAILURE CommitStatusState = "failure"
+)
+
+// IsValidCommitStatusState returns true if given name is a valid commit status state.
+func IsValidCommitStatusState(name string) bool {
+	switch CommitStatusState(name) {
+	case COMMIT_STATUS_PENDING, COMMIT_STATUS_SUCCESS, COMMIT_STATUS_ERROR, COMMIT_STATUS_FAILURE:
+		return true
+	}
+	return false
+}
+
+// CommitStatus represents a status reported by external services (e.g. CI) for a commit.
+type CommitStatus struct {
+	ID          int64
+	RepoID      int64             `xorm:"INDEX(repo_sha)"`
+	SHA         string            `xorm:"VARCHAR(40) INDEX(repo_sha)"`
+	State       CommitStatusState `xorm:"VARCHAR(7)"`
+	Context     string
+	TargetURL   string `xorm:"TEXT"`
+	Description string `xorm:"TEXT"`
+	CreatorID   int64
+	Creator     *User `xorm:"-" json:"-"`
+
+	Created     time.Time `xorm:"-" json:"-"`
+	CreatedUnix int64
+	Updated     time.Time `xorm:"-" json:"-"`
+	UpdatedUnix int64
+}
+
+func (s *CommitStatus) BeforeInsert() {
+	s.CreatedUnix = time.Now().Unix()
+	s.UpdatedUnix = s.CreatedUnix
+}
+
+func (s *CommitStatus) BeforeUpdate() {
+	s.UpdatedUnix = time.Now().Unix()
+}
+
+func (s *CommitStatus) AfterSet(colName string, _ xorm.Cell) {
+	switch colName {
+	case "created_unix":
+		s.Created = time.Unix(s.CreatedUnix, 0).Local()
+	case "updated_unix":
+		s.Updated = time.Unix(s.UpdatedUnix, 0).Local()
+	}
+}
+
+func (s *CommitStatus) loadAttributes(e Engine) (err error) {
+	if s.Creator == nil {
+		s.Creator, err = getUserByID(e, s.CreatorID)
+		if err != nil {
+			if IsErrUserNotExist(err) {
+				s.CreatorID = -1
+				s.Creator = NewGhostUser()
+			} else {
+				return fmt.Errorf("getUserByID.(Creator) [%d]: %v", s.CreatorID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// IsSuccess returns true if the status has succeeded.
+func (s *CommitStatus) IsSuccess() bool {
+	return s.State == COMMIT_STATUS_SUCCESS
+}
+
+// IsFailure returns true if the status has failed or errored.
+func (s *CommitStatus) IsFailure() bool {
+	return s.State == COMMIT_STATUS_FAILURE || s.State == COMMIT_STATUS_ERROR
+}
+
+// GetLatestCommitStatuses returns the most recent status of each context
+// reported for the commit in given repositories, ordered by context.
+func GetLatestCommitStatuses(sha string, repoIDs ...int64) ([]*CommitStatus, error) {
+	statuses := make([]*CommitStatus, 0, 5)
+	if err := x.Where("sha = ?", sha).In("repo_id", repoIDs).Desc("id").Find(&statuses); err != nil {
+		return nil, err
+	}
+
+	latest := make([]*CommitStatus, 0, len(statuses))
+	seen := make(map[string]bool, len(statuses))
+	for _, s := range statuses {
+		if seen[s.Context] {
+			continue
+		}
+		seen[s.Context] = true
+
+		if err := s.loadAttributes(x); err != nil {
+			return nil, fmt.Errorf("loadAttributes [%d]: %v", s.ID, err)
+		}
+		latest = append(latest, s)
+	}
+
+	sort.Slice(latest, func(i, j int) bool {
+		return latest[i].Context < latest[j].Context
+	})
+	return latest, nil
+}
+
+// APICommitStatus represents a commit status in API responses.
+type APICommitStatus struct {
+	ID          int64     `json:"id"`
+	State       string    `json:"state"`
+	Context     string    `json:"context"`
+	TargetURL   string    `json:"target_url"`
+	Description string    `json:"description"`
+	Creator     *api.User `json:"creator"`
+	Created     time.Time `json:"created_at"`
+	Updated     time.Time `json:"updated_at"`
+}
+
+// This method assumes following fields have been assigned with valid values:
+// Required - Creator
+func (s *CommitStatus) APIFormat() *APICommitStatus {
+	return &APICommitStatus{
+		ID:          s.ID,
+		State:       string(s.State),
+		Context:     s.Context,
+		TargetURL:   s.TargetURL,
+		Description: s.Description,
+		Creator:     s.Creator.APIFormat(),
+		Created:     s.Created,
+		Updated:     s.Updated,
+	}
+}
+
+type CreateCommitStatusOptions struct {
+	State       CommitStatusState
+	Context     string
+	TargetURL   string
+	Description string
+}
+
+// CreateCommitStatus creates a new status for the commit in given repository.
+// Statuses are never updated, the latest one of each context takes effect.
+func CreateCommitStatus(repo *Repository, creator *User, sha string, opts CreateCommitStatusOptions) (*CommitStatus, error) {
+	if !IsValidCommitStatusState(string(opts.State)) {
+		return nil, fmt.Errorf("invalid state %q", opts.State)
+	}
+	if opts.Context == "" {
+		opts.Context = "default"
+	}
+
+	status := &CommitStatus{
+		RepoID:      repo.ID,
+		SHA:         sha,
+		State:       opts.State,
+		Context:     opts.Context,
+		TargetURL:   opts.TargetURL,
+		Description: opts.Description,
+		CreatorID:   creator.ID,
+		Creator:     creator,
+	}
+	if _, err := x.Insert(status); err != nil {
+		return nil, err
+	}
+
+	if err := addAutoMergeTasksBySHA(repo.ID, sha); err != nil {
+		log.Error("addAutoMergeTasksBySHA [repo_id: %d, sha: %s]: %v", repo.ID, sha, err)
+	}
+	return status, nil
+}
+
+// GetCommitStatuses returns all statuses reported for the commit in given repository,
+// most recent first.
+func GetCommitStatuses(repoID int64, sha string) ([]*CommitStatus, error) {
+	statuses := make([]*CommitStatus, 0, 5)
+	if err := x.Where("repo_id = ? AND sha = ?", repoID, sha).Desc("id").Find(&statuses); err != nil {
+		return nil, err
+	}
+
+	for _, s := range statuses {
+		if err := s.loadAttributes(x); err != nil {
+			return nil, fmt.Errorf("loadAttributes [%d]: %v", s.ID, err)
+		}
+	}
+	return statuses, nil
+}
+
+// CombinedCommitStatus represents the overall state of a commit
+// that is combined from the latest status of each context.
+type CombinedCommitStatus struct {
+	SHA      string
+	State    CommitStatusState
+	Statuses []*CommitStatus
+}
+
+// newCombinedCommitStatus combines given latest statuses of each context:
+// it fails if any context has failed or errored, is pending if there is no
+// status or any context is pending, and succeeds otherwise.
+func newCombinedCommitStatus(sha string, statuses []*CommitStatus) *CombinedCommitStatus {
+	combined := &CombinedCommitStatus{
+		SHA:      sha,
+		State:    COMMIT_STATUS_SUCCESS,
+		Statuses: statuses,
+	}
+	if len(statuses) == 0 {
+		combined.State = COMMIT_STATUS_PENDING
+		return combined
+	}
+
+	for _, s := range statuses {
+		switch s.State {
+		case COMMIT_STATUS_ERROR, COMMIT_STATUS_FAILURE:
+			combined.State = COMMIT_STATUS_FAILURE
+			return combined
+		case COMMIT_STATUS_PENDING:
+			combined.State = COMMIT_STATUS_PENDING
+		}
+	}
+	return combined
+}
+
+func (s *CombinedCommitStatus) IsPending() bool {
+	return s.State == COMMIT_STATUS_PENDING
+}
+
+func (s *CombinedCommitStatus) IsSuccess() bool {
+	return s.State == COMMIT_STATUS_SUCCESS
+}
+
+func (s *CombinedCommitStatus) IsFailure() bool {
+	return s.State == COMMIT_STATUS_FAILURE
+}
+
+// GetCombinedCommitStatus returns the combined status of the commit in given repositories.
+func GetCombinedCommitStatus(sha string, repoIDs ...int64) (*CombinedCommitStatus, error) {
+	statuses, err := GetLatestCommitStatuses(sha, repoIDs...)
+	if err != nil {
+		return nil, err
+	}
+	return newCombinedCommitStatus(sha, statuses), nil
+}
+
+// GetCombinedCommitStatusesBySHAs returns combined statuses of commits that have
+// any status reported in given repositories, indexed by SHA.
+func GetCombinedCommitStatusesBySHAs(shas []string, repoIDs ...int64) (map[string]*CombinedCommitStatus, error) {
+	if len(shas) == 0 {
+		return map[string]*CombinedCommitStatus{}, nil
+	}
+
+	statuses := make([]*CommitStatus, 0, len(shas))
+	if err := x.In("repo_id", repoIDs).In("sha", shas).Desc("id").Find(&statuses); err != nil {
+		return nil, err
+	}
+
+	latest := make(map[string][]*CommitStatus, len(shas))
+	seen := make(map[string]bool, len(statuses))
+	for _, s := range statuses {
+		key := s.SHA + ":" + s.Context
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		latest[s.SHA] = append(latest[s.SHA], s)
+	}
+
+	combined := make(map[string]*CombinedCommitStatus, len(latest))
+	for sha, statuses := range latest {
+		combined[sha] = newCombinedCommitStatus(sha, statuses)
+	}
+	return combined, nil
+}
+
+// APICombinedCommitStatus represents the combined status of a commit in API responses.
+type APICombinedCommitStatus struct {
+	State      string             `json:"state"`
+	SHA        string             `json:"sha"`
+	TotalCount int                `json:"total_count"`
+	Statuses   []*APICommitStatus `json:"statuses"`
+	Repository *api.Repository    `json:"repository"`
+}
+
+// This method assumes following fields have been assigned with valid values:
+// Required - Creator of each status
+func (s *CombinedCommitStatus) APIFormat(repo *Repository) *APICombinedCommitStatus {
+	apiStatuses := make([]*APICommitStatus, len(s.Statuses))
+	for i := range s.Statuses {
+		apiStatuses[i] = s.Statuses[i].APIFormat()
+	}
+	return &APICombinedCommitStatus{
+		State:      string(s.State),
+		SHA:        s.SHA,
+		TotalCount: len(s.Statuses),
+		Statuses:   apiStatuses,
+		Repository: repo.APIFormat(nil),
+	}
+}
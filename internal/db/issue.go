@@ -6,6 +6,7 @@ package db
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -40,9 +41,14 @@ type Issue struct {
 	MilestoneID     int64
 	Milestone       *Milestone `xorm:"-" json:"-"`
 	Priority        int
-	AssigneeID      int64
-	Assignee        *User `xorm:"-" json:"-"`
+	AssigneeID      int64   // Deprecated: Use Assignees instead, it is kept as the earliest assignee.
+	Assignee        *User   `xorm:"-" json:"-"`
+	Assignees       []*User `xorm:"-" json:"-"`
+	AssigneeIDs     []int64 `xorm:"-" json:"-"` // Assignees to be assigned when creating the issue.
 	IsClosed        bool
+	IsLocked        bool
+	LockReason      string
+	PinOrder        int          // Position among pinned issues starting from 1, 0 means not pinned.
 	IsRead          bool         `xorm:"-" json:"-"`
 	IsPull          bool         // Indicates whether is a pull request or not.
 	PullRequest     *PullRequest `xorm:"-" json:"-"`
@@ -57,6 +63,7 @@ type Issue struct {
 
 	Attachments []*Attachment `xorm:"-" json:"-"`
 	Comments    []*Comment    `xorm:"-" json:"-"`
+	Reactions   ReactionList  `xorm:"-" json:"-"`
 }
 
 func (issue *Issue) BeforeInsert() {
@@ -114,10 +121,13 @@ func (issue *Issue) loadAttributes(e Engine) (err error) {
 		}
 	}
 
-	if issue.Assignee == nil && issue.AssigneeID > 0 {
-		issue.Assignee, err = getUserByID(e, issue.AssigneeID)
+	if issue.Assignees == nil {
+		issue.Assignees, err = getAssigneesByIssueID(e, issue.ID)
 		if err != nil {
-			return fmt.Errorf("getUserByID.(assignee) [%d]: %v", issue.AssigneeID, err)
+			return fmt.Errorf("getAssigneesByIssueID [%d]: %v", issue.ID, err)
+		}
+		if len(issue.Assignees) > 0 {
+			issue.Assignee = issue.Assignees[0]
 		}
 	}
 
@@ -390,16 +400,20 @@ func (issue *Issue) ReplaceLabels(labels []*Label) (err error) {
 	return sess.Commit()
 }
 
-func (issue *Issue) GetAssignee() (err error) {
-	if issue.AssigneeID == 0 || issue.Assignee != nil {
+// GetAssignees loads assignees of the issue.
+func (issue *Issue) GetAssignees() (err error) {
+	if issue.Assignees != nil {
 		return nil
 	}
 
-	issue.Assignee, err = GetUserByID(issue.AssigneeID)
-	if IsErrUserNotExist(err) {
-		return nil
+	issue.Assignees, err = GetAssigneesByIssueID(issue.ID)
+	if err != nil {
+		return err
 	}
-	return err
+	if len(issue.Assignees) > 0 {
+		issue.Assignee = issue.Assignees[0]
+	}
+	return nil
 }
 
 // ReadBy sets issue to be read by given user.
@@ -460,6 +474,15 @@ func (issue *Issue) changeStatus(e *xorm.Session, doer *User, repo *Repository,
 
 // ChangeStatus changes issue status to open or closed.
 func (issue *Issue) ChangeStatus(doer *User, repo *Repository, isClosed bool) (err error) {
+	if isClosed && repo.IssuesBlockCloseByDependencies {
+		count, err := issue.countOpenBlockers(x)
+		if err != nil {
+			return fmt.Errorf("count open blockers: %v", err)
+		} else if count > 0 {
+			return ErrIssueHasOpenBlockers{args: map[string]interface{}{"issueID": issue.ID, "count": count}}
+		}
+	}
+
 	sess := x.NewSession()
 	defer sess.Close()
 	if err = sess.Begin(); err != nil {
@@ -516,6 +539,7 @@ func (issue *Issue) ChangeTitle(doer *User, title string) (err error) {
 	if err = UpdateIssueCols(issue, "name"); err != nil {
 		return fmt.Errorf("UpdateIssueCols: %v", err)
 	}
+	updateIssueIndexer(issue.ID)
 
 	if issue.IsPull {
 		issue.PullRequest.Issue = issue
@@ -558,6 +582,7 @@ func (issue *Issue) ChangeContent(doer *User, content string) (err error) {
 	if err = UpdateIssueCols(issue, "content"); err != nil {
 		return fmt.Errorf("UpdateIssueCols: %v", err)
 	}
+	updateIssueIndexer(issue.ID)
 
 	if issue.IsPull {
 		issue.PullRequest.Issue = issue
@@ -594,55 +619,6 @@ func (issue *Issue) ChangeContent(doer *User, content string) (err error) {
 	return nil
 }
 
-func (issue *Issue) ChangeAssignee(doer *User, assigneeID int64) (err error) {
-	issue.AssigneeID = assigneeID
-	if err = UpdateIssueUserByAssignee(issue); err != nil {
-		return fmt.Errorf("UpdateIssueUserByAssignee: %v", err)
-	}
-
-	issue.Assignee, err = GetUserByID(issue.AssigneeID)
-	if err != nil && !IsErrUserNotExist(err) {
-		log.Error("Failed to get user by ID: %v", err)
-		return nil
-	}
-
-	// Error not nil here means user does not exist, which is remove assignee.
-	isRemoveAssignee := err != nil
-	if issue.IsPull {
-		issue.PullRequest.Issue = issue
-		apiPullRequest := &api.PullRequestPayload{
-			Index:       issue.Index,
-			PullRequest: issue.PullRequest.APIFormat(),
-			Repository:  issue.Repo.APIFormat(nil),
-			Sender:      doer.APIFormat(),
-		}
-		if isRemoveAssignee {
-			apiPullRequest.Action = api.HOOK_ISSUE_UNASSIGNED
-		} else {
-			apiPullRequest.Action = api.HOOK_ISSUE_ASSIGNED
-		}
-		err = PrepareWebhooks(issue.Repo, HOOK_EVENT_PULL_REQUEST, apiPullRequest)
-	} else {
-		apiIssues := &api.IssuesPayload{
-			Index:      issue.Index,
-			Issue:      issue.APIFormat(),
-			Repository: issue.Repo.APIFormat(nil),
-			Sender:     doer.APIFormat(),
-		}
-		if isRemoveAssignee {
-			apiIssues.Action = api.HOOK_ISSUE_UNASSIGNED
-		} else {
-			apiIssues.Action = api.HOOK_ISSUE_ASSIGNED
-		}
-		err = PrepareWebhooks(issue.Repo, HOOK_EVENT_ISSUES, apiIssues)
-	}
-	if err != nil {
-		log.Error("PrepareWebhooks [is_pull: %v, remove_assignee: %v]: %v", issue.IsPull, isRemoveAssignee, err)
-	}
-
-	return nil
-}
-
 type NewIssueOptions struct {
 	Repo        *Repository
 	Issue       *Issue
@@ -672,24 +648,21 @@ func newIssue(e *xorm.Session, opts NewIssueOptions) (err error) {
 		}
 	}
 
+	// Assume invalid assignees and drop silently.
+	assigneeIDs := opts.Issue.AssigneeIDs
 	if opts.Issue.AssigneeID > 0 {
-		assignee, err := getUserByID(e, opts.Issue.AssigneeID)
-		if err != nil && !IsErrUserNotExist(err) {
-			return fmt.Errorf("get user by ID: %v", err)
-		}
-
-		// Assume assignee is invalid and drop silently.
-		opts.Issue.AssigneeID = 0
-		if assignee != nil {
-			valid, err := hasAccess(e, assignee.ID, opts.Repo, AccessModeRead)
-			if err != nil {
-				return fmt.Errorf("hasAccess [user_id: %d, repo_id: %d]: %v", assignee.ID, opts.Repo.ID, err)
-			}
-			if valid {
-				opts.Issue.AssigneeID = assignee.ID
-				opts.Issue.Assignee = assignee
-			}
-		}
+		assigneeIDs = append([]int64{opts.Issue.AssigneeID}, assigneeIDs...)
+	}
+	assigneeIDs, err = validAssigneeIDs(e, opts.Repo, assigneeIDs)
+	if err != nil {
+		return fmt.Errorf("validate assignees: %v", err)
+	}
+	opts.Issue.AssigneeIDs = assigneeIDs
+	opts.Issue.AssigneeID = 0
+	opts.Issue.Assignee = nil
+	opts.Issue.Assignees = nil
+	if len(assigneeIDs) > 0 {
+		opts.Issue.AssigneeID = assigneeIDs[0]
 	}
 
 	// Milestone and assignee validation should happen before insert actual object.
@@ -726,6 +699,12 @@ func newIssue(e *xorm.Session, opts NewIssueOptions) (err error) {
 		}
 	}
 
+	for _, id := range assigneeIDs {
+		if _, err = e.Insert(&IssueAssignee{IssueID: opts.Issue.ID, AssigneeID: id}); err != nil {
+			return fmt.Errorf("insert issue assignee: %v", err)
+		}
+	}
+
 	if err = newIssueUsers(e, opts.Repo, opts.Issue); err != nil {
 		return err
 	}
@@ -767,6 +746,7 @@ func NewIssue(repo *Repository, issue *Issue, labelIDs []int64, uuids []string)
 	if err = sess.Commit(); err != nil {
 		return fmt.Errorf("Commit: %v", err)
 	}
+	updateIssueIndexer(issue.ID)
 
 	if err = NotifyWatchers(&Action{
 		ActUserID:    issue.Poster.ID,
@@ -784,6 +764,16 @@ func NewIssue(repo *Repository, issue *Issue, labelIDs []int64, uuids []string)
 		log.Error("MailParticipants: %v", err)
 	}
 
+	assigneeIDs := make([]int64, 0, len(issue.AssigneeIDs))
+	for _, id := range issue.AssigneeIDs {
+		if id != issue.PosterID {
+			assigneeIDs = append(assigneeIDs, id)
+		}
+	}
+	if err = notifyIssueUsers(x, issue, assigneeIDs, NOTIFICATION_REASON_ASSIGN); err != nil {
+		log.Error("Failed to notify assignees [issue_id: %d]: %v", issue.ID, err)
+	}
+
 	if err = PrepareWebhooks(repo, HOOK_EVENT_ISSUES, &api.IssuesPayload{
 		Action:     api.HOOK_ISSUE_OPENED,
 		Index:      issue.Index,
@@ -901,8 +891,12 @@ type IssuesOptions struct {
 	IsClosed    bool
 	IsMention   bool
 	IsPull      bool
+	Draft       DraftFilter
 	Labels      string
 	SortType    string
+	// IssueIDs limits results to issues in the list if it is not nil, the list is
+	// expected to be ordered by relevance for the "relevance" sort type.
+	IssueIDs []int64
 }
 
 // buildIssuesQuery returns nil if it foresees there won't be any value returned.
@@ -925,8 +919,15 @@ func buildIssuesQuery(opts *IssuesOptions) *xorm.Session {
 		sess.Where("issue.is_closed=?", opts.IsClosed)
 	}
 
+	if opts.IssueIDs != nil {
+		if len(opts.IssueIDs) == 0 {
+			return nil
+		}
+		sess.In("issue.id", opts.IssueIDs)
+	}
+
 	if opts.AssigneeID > 0 {
-		sess.And("issue.assignee_id=?", opts.AssigneeID)
+		sess.And(issueAssigneeCond, opts.AssigneeID)
 	} else if opts.PosterID > 0 {
 		sess.And("issue.poster_id=?", opts.PosterID)
 	}
@@ -936,6 +937,9 @@ func buildIssuesQuery(opts *IssuesOptions) *xorm.Session {
 	}
 
 	sess.And("issue.is_pull=?", opts.IsPull)
+	if opts.IsPull {
+		opts.Draft.apply(sess)
+	}
 
 	switch opts.SortType {
 	case "oldest":
@@ -982,6 +986,47 @@ func IssuesCount(opts *IssuesOptions) (int64, error) {
 	return sess.Count(&Issue{})
 }
 
+// findIssuesByRelevance returns issues of the page that match the query in the order
+// of opts.IssueIDs.
+func findIssuesByRelevance(sess *xorm.Session, opts *IssuesOptions) ([]*Issue, error) {
+	ids := make([]int64, 0, len(opts.IssueIDs))
+	if err := sess.Table("issue").Cols("issue.id").Find(&ids); err != nil {
+		return nil, fmt.Errorf("find issue IDs: %v", err)
+	}
+	matched := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		matched[id] = true
+	}
+
+	ranks := make(map[int64]int, len(ids))
+	ids = ids[:0]
+	for _, id := range opts.IssueIDs {
+		if matched[id] {
+			ranks[id] = len(ids)
+			ids = append(ids, id)
+		}
+	}
+
+	start := (opts.Page - 1) * conf.UI.IssuePagingNum
+	if start >= len(ids) {
+		return make([]*Issue, 0), nil
+	}
+	end := start + conf.UI.IssuePagingNum
+	if end > len(ids) {
+		end = len(ids)
+	}
+	ids = ids[start:end]
+
+	issues := make([]*Issue, 0, len(ids))
+	if err := x.In("id", ids).Find(&issues); err != nil {
+		return nil, fmt.Errorf("find issues: %v", err)
+	}
+	sort.Slice(issues, func(i, j int) bool {
+		return ranks[issues[i].ID] < ranks[issues[j].ID]
+	})
+	return issues, nil
+}
+
 // Issues returns a list of issues by given conditions.
 func Issues(opts *IssuesOptions) ([]*Issue, error) {
 	sess := buildIssuesQuery(opts)
@@ -989,11 +1034,20 @@ func Issues(opts *IssuesOptions) ([]*Issue, error) {
 		return make([]*Issue, 0), nil
 	}
 
-	sess.Limit(conf.UI.IssuePagingNum, (opts.Page-1)*conf.UI.IssuePagingNum)
+	var issues []*Issue
+	if opts.SortType == "relevance" && opts.IssueIDs != nil {
+		var err error
+		issues, err = findIssuesByRelevance(sess, opts)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		sess.Limit(conf.UI.IssuePagingNum, (opts.Page-1)*conf.UI.IssuePagingNum)
 
-	issues := make([]*Issue, 0, conf.UI.IssuePagingNum)
-	if err := sess.Find(&issues); err != nil {
-		return nil, fmt.Errorf("Find: %v", err)
+		issues = make([]*Issue, 0, conf.UI.IssuePagingNum)
+		if err := sess.Find(&issues); err != nil {
+			return nil, fmt.Errorf("Find: %v", err)
+		}
 	}
 
 	// FIXME: use IssueList to improve performance.
@@ -1053,6 +1107,11 @@ func newIssueUsers(e *xorm.Session, repo *Repository, issue *Issue) error {
 	// Poster can be anyone, append later if not one of assignees.
 	isPosterAssignee := false
 
+	isAssigned := make(map[int64]bool, len(issue.AssigneeIDs))
+	for _, id := range issue.AssigneeIDs {
+		isAssigned[id] = true
+	}
+
 	// Leave a seat for poster itself to append later, but if poster is one of assignee
 	// and just waste 1 unit is cheaper than re-allocate memory once.
 	issueUsers := make([]*IssueUser, 0, len(assignees)+1)
@@ -1063,7 +1122,7 @@ func newIssueUsers(e *xorm.Session, repo *Repository, issue *Issue) error {
 			RepoID:     repo.ID,
 			UID:        assignee.ID,
 			IsPoster:   isPoster,
-			IsAssigned: assignee.ID == issue.AssigneeID,
+			IsAssigned: isAssigned[assignee.ID],
 		})
 		if !isPosterAssignee && isPoster {
 			isPosterAssignee = true
@@ -1071,10 +1130,11 @@ func newIssueUsers(e *xorm.Session, repo *Repository, issue *Issue) error {
 	}
 	if !isPosterAssignee {
 		issueUsers = append(issueUsers, &IssueUser{
-			IssueID:  issue.ID,
-			RepoID:   repo.ID,
-			UID:      issue.PosterID,
-			IsPoster: true,
+			IssueID:    issue.ID,
+			RepoID:     repo.ID,
+			UID:        issue.PosterID,
+			IsPoster:   true,
+			IsAssigned: isAssigned[issue.PosterID],
 		})
 	}
 
@@ -1150,8 +1210,8 @@ func GetIssueUserPairsByMode(userID, repoID int64, filterMode FilterMode, isClos
 }
 
 // updateIssueMentions extracts mentioned people from content and
-// updates issue-user relations for them.
-func updateIssueMentions(e Engine, issueID int64, mentions []string) error {
+// updates issue-user relations and notifications for them.
+func updateIssueMentions(e Engine, issue *Issue, doerID int64, mentions []string) error {
 	if len(mentions) == 0 {
 		return nil
 	}
@@ -1166,9 +1226,15 @@ func updateIssueMentions(e Engine, issueID int64, mentions []string) error {
 	}
 
 	ids := make([]int64, 0, len(mentions))
+	notifyIDs := make([]int64, 0, len(mentions))
 	for _, user := range users {
 		ids = append(ids, user.ID)
-		if !user.IsOrganization() || user.NumMembers == 0 {
+		if !user.IsOrganization() {
+			if user.ID != doerID {
+				notifyIDs = append(notifyIDs, user.ID)
+			}
+			continue
+		} else if user.NumMembers == 0 {
 			continue
 		}
 
@@ -1180,15 +1246,22 @@ func updateIssueMentions(e Engine, issueID int64, mentions []string) error {
 
 		for _, orgUser := range orgUsers {
 			memberIDs = append(memberIDs, orgUser.ID)
+			if orgUser.ID != doerID {
+				notifyIDs = append(notifyIDs, orgUser.ID)
+			}
 		}
 
 		ids = append(ids, memberIDs...)
 	}
 
-	if err := updateIssueUsersByMentions(e, issueID, ids); err != nil {
+	if err := updateIssueUsersByMentions(e, issue.ID, ids); err != nil {
 		return fmt.Errorf("UpdateIssueUsersByMentions: %v", err)
 	}
 
+	if err := notifyIssueUsers(e, issue, notifyIDs, NOTIFICATION_REASON_MENTION); err != nil {
+		return fmt.Errorf("notify mentioned users: %v", err)
+	}
+
 	return nil
 }
 
@@ -1228,6 +1301,8 @@ type IssueStatsOptions struct {
 	AssigneeID  int64
 	FilterMode  FilterMode
 	IsPull      bool
+	Draft       DraftFilter
+	IssueIDs    []int64 // Only counts issues in the list if it is not nil.
 }
 
 // GetIssueStats returns issue statistic information by given conditions.
@@ -1249,7 +1324,16 @@ func GetIssueStats(opts *IssueStatsOptions) *IssueStats {
 		}
 
 		if opts.AssigneeID > 0 {
-			sess.And("assignee_id = ?", opts.AssigneeID)
+			sess.And(issueAssigneeCond, opts.AssigneeID)
+		}
+
+		if opts.IsPull {
+			opts.Draft.apply(sess)
+		}
+
+		if opts.IssueIDs != nil {
+			// Make sure nothing is counted when no issue matches.
+			sess.In("issue.id", append([]int64{0}, opts.IssueIDs...))
 		}
 
 		return sess
@@ -1309,7 +1393,7 @@ func GetUserIssueStats(repoID, userID int64, repoIDs []int64, filterMode FilterM
 	}
 
 	stats.AssignCount, _ = countSession(false, isPull, repoID, nil).
-		And("assignee_id = ?", userID).
+		And(issueAssigneeCond, userID).
 		Count(new(Issue))
 
 	stats.CreateCount, _ = countSession(false, isPull, repoID, nil).
@@ -1333,10 +1417,10 @@ func GetUserIssueStats(repoID, userID int64, repoIDs []int64, filterMode FilterM
 			Count(new(Issue))
 	case FILTER_MODE_ASSIGN:
 		stats.OpenCount, _ = countSession(false, isPull, repoID, nil).
-			And("assignee_id = ?", userID).
+			And(issueAssigneeCond, userID).
 			Count(new(Issue))
 		stats.ClosedCount, _ = countSession(true, isPull, repoID, nil).
-			And("assignee_id = ?", userID).
+			And(issueAssigneeCond, userID).
 			Count(new(Issue))
 	case FILTER_MODE_CREATE:
 		stats.OpenCount, _ = countSession(false, isPull, repoID, nil).
@@ -1365,8 +1449,8 @@ func GetRepoIssueStats(repoID, userID int64, filterMode FilterMode, isPull bool)
 
 	switch filterMode {
 	case FILTER_MODE_ASSIGN:
-		openCountSession.And("assignee_id = ?", userID)
-		closedCountSession.And("assignee_id = ?", userID)
+		openCountSession.And(issueAssigneeCond, userID)
+		closedCountSession.And(issueAssigneeCond, userID)
 	case FILTER_MODE_CREATE:
 		openCountSession.And("poster_id = ?", userID)
 		closedCountSession.And("poster_id = ?", userID)
@@ -1385,7 +1469,11 @@ func updateIssue(e Engine, issue *Issue) error {
 
 // UpdateIssue updates all fields of given issue.
 func UpdateIssue(issue *Issue) error {
-	return updateIssue(x, issue)
+	if err := updateIssue(x, issue); err != nil {
+		return err
+	}
+	updateIssueIndexer(issue.ID)
+	return nil
 }
 
 func updateIssueUsersByStatus(e Engine, issueID int64, isClosed bool) error {
@@ -1398,36 +1486,6 @@ func UpdateIssueUsersByStatus(issueID int64, isClosed bool) error {
 	return updateIssueUsersByStatus(x, issueID, isClosed)
 }
 
-func updateIssueUserByAssignee(e *xorm.Session, issue *Issue) (err error) {
-	if _, err = e.Exec("UPDATE `issue_user` SET is_assigned = ? WHERE issue_id = ?", false, issue.ID); err != nil {
-		return err
-	}
-
-	// Assignee ID equals to 0 means clear assignee.
-	if issue.AssigneeID > 0 {
-		if _, err = e.Exec("UPDATE `issue_user` SET is_assigned = ? WHERE uid = ? AND issue_id = ?", true, issue.AssigneeID, issue.ID); err != nil {
-			return err
-		}
-	}
-
-	return updateIssue(e, issue)
-}
-
-// UpdateIssueUserByAssignee updates issue-user relation for assignee.
-func UpdateIssueUserByAssignee(issue *Issue) (err error) {
-	sess := x.NewSession()
-	defer sess.Close()
-	if err = sess.Begin(); err != nil {
-		return err
-	}
-
-	if err = updateIssueUserByAssignee(sess, issue); err != nil {
-		return err
-	}
-
-	return sess.Commit()
-}
-
 // UpdateIssueUserByRead updates issue-user relation for reading.
 func UpdateIssueUserByRead(uid, issueID int64) error {
 	_, err := x.Exec("UPDATE `issue_user` SET is_read=? WHERE uid=? AND issue_id=?", true, uid, issueID)
@@ -0,0 +1,633 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	gouuid "github.com/satori/go.uuid"
+	log "unknwon.dev/clog/v2"
+)
+
+// IssueArchiveVersion is the version of the format of issue archives produced by
+// ExportIssues, archives of other versions are refused to be imported.
+const IssueArchiveVersion = 1
+
+const (
+	issueArchiveJSON           = "issues.json"
+	issueArchiveCSV            = "issues.csv"
+	issueArchiveAttachmentsDir = "attachments"
+)
+
+// IssueArchive is the serialized form of issues of a repository.
+type IssueArchive struct {
+	Version    int                      `json:"version"`
+	Repository string                   `json:"repository"`
+	ExportedAt time.Time                `json:"exported_at"`
+	Labels     []*IssueArchiveLabel     `json:"labels"`
+	Milestones []*IssueArchiveMilestone `json:"milestones"`
+	Issues     []*IssueArchiveIssue     `json:"issues"`
+}
+
+type IssueArchiveLabel struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type IssueArchiveMilestone struct {
+	Name     string     `json:"name"`
+	Content  string     `json:"content"`
+	IsClosed bool       `json:"is_closed"`
+	Deadline *time.Time `json:"deadline,omitempty"`
+}
+
+// IssueArchiveAttachment is an attachment whose content is stored in the archive
+// under the File path.
+type IssueArchiveAttachment struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+}
+
+type IssueArchiveComment struct {
+	// Poster is the username of the author, or empty if the author has been deleted.
+	Poster      string                    `json:"poster"`
+	Content     string                    `json:"content"`
+	Created     time.Time                 `json:"created_at"`
+	Updated     time.Time                 `json:"updated_at"`
+	Attachments []*IssueArchiveAttachment `json:"attachments"`
+}
+
+type IssueArchiveIssue struct {
+	Index       int64                     `json:"index"`
+	Title       string                    `json:"title"`
+	Content     string                    `json:"content"`
+	Poster      string                    `json:"poster"`
+	IsClosed    bool                      `json:"is_closed"`
+	Labels      []string                  `json:"labels"`
+	Milestone   string                    `json:"milestone"`
+	Assignees   []string                  `json:"assignees"`
+	Created     time.Time                 `json:"created_at"`
+	Updated     time.Time                 `json:"updated_at"`
+	Comments    []*IssueArchiveComment    `json:"comments"`
+	Attachments []*IssueArchiveAttachment `json:"attachments"`
+}
+
+type ErrIssueArchiveInvalid struct {
+	args map[string]interface{}
+}
+
+func IsErrIssueArchiveInvalid(err error) bool {
+	_, ok := err.(ErrIssueArchiveInvalid)
+	return ok
+}
+
+func (err ErrIssueArchiveInvalid) Error() string {
+	return fmt.Sprintf("issue archive is invalid: %v", err.args)
+}
+
+// archiveUserName returns the username to be recorded in an archive for the user,
+// the ghost user is recorded as an empty name.
+func archiveUserName(u *User) string {
+	if u == nil || u.ID <= 0 {
+		return ""
+	}
+	return u.Name
+}
+
+// issueArchiveWriter writes attachments to the archive and records them.
+type issueArchiveWriter struct {
+	zw *zip.Writer
+}
+
+func (w *issueArchiveWriter) addAttachments(attachments []*Attachment) ([]*IssueArchiveAttachment, error) {
+	archived := make([]*IssueArchiveAttachment, 0, len(attachments))
+	for _, attach := range attachments {
+		f, err := os.Open(attach.LocalPath())
+		if err != nil {
+			// The file could be removed by hand, it should not prevent exporting everything else.
+			log.Warn("Failed to open attachment [uuid: %s]: %v", attach.UUID, err)
+			continue
+		}
+
+		file := path.Join(issueArchiveAttachmentsDir, attach.UUID)
+		fw, err := w.zw.Create(file)
+		if err == nil {
+			_, err = io.Copy(fw, f)
+		}
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("write attachment [uuid: %s]: %v", attach.UUID, err)
+		}
+
+		archived = append(archived, &IssueArchiveAttachment{
+			Name: attach.Name,
+			File: file,
+		})
+	}
+	return archived, nil
+}
+
+// ExportIssues writes issues of the repository along with their comments, labels,
+// milestones and attachments to w as a ZIP archive. Besides the issues.json that
+// can be imported by ImportIssues, the archive contains a flat issues.csv report.
+// Pull requests and comments other than plain ones are not exported.
+func ExportIssues(repo *Repository, w io.Writer) (err error) {
+	issues := make([]*Issue, 0, repo.NumIssues)
+	if err = x.Where("repo_id = ? AND is_pull = ?", repo.ID, false).Asc("index").Find(&issues); err != nil {
+		return fmt.Errorf("find issues: %v", err)
+	}
+
+	labels, err := GetLabelsByRepoID(repo.ID)
+	if err != nil {
+		return fmt.Errorf("get labels: %v", err)
+	}
+	milestones, err := GetMilestonesByRepoID(repo.ID)
+	if err != nil {
+		return fmt.Errorf("get milestones: %v", err)
+	}
+
+	archive := &IssueArchive{
+		Version:    IssueArchiveVersion,
+		Repository: repo.FullName(),
+		ExportedAt: time.Now(),
+		Labels:     make([]*IssueArchiveLabel, len(labels)),
+		Milestones: make([]*IssueArchiveMilestone, len(milestones)),
+		Issues:     make([]*IssueArchiveIssue, 0, len(issues)),
+	}
+	for i, l := range labels {
+		archive.Labels[i] = &IssueArchiveLabel{
+			Name:  l.Name,
+			Color: l.Color,
+		}
+	}
+	for i, m := range milestones {
+		archive.Milestones[i] = &IssueArchiveMilestone{
+			Name:     m.Name,
+			Content:  m.Content,
+			IsClosed: m.IsClosed,
+		}
+		if m.DeadlineUnix > 0 && m.Deadline.Year() < 9999 {
+			deadline := m.Deadline
+			archive.Milestones[i].Deadline = &deadline
+		}
+	}
+
+	zw := zip.NewWriter(w)
+	aw := &issueArchiveWriter{zw: zw}
+	for _, issue := range issues {
+		issue.Repo = repo
+		if err = issue.loadAttributes(x); err != nil {
+			return fmt.Errorf("load attributes [issue_id: %d]: %v", issue.ID, err)
+		}
+
+		archived := &IssueArchiveIssue{
+			Index:     issue.Index,
+			Title:     issue.Title,
+			Content:   issue.Content,
+			Poster:    archiveUserName(issue.Poster),
+			IsClosed:  issue.IsClosed,
+			Labels:    make([]string, len(issue.Labels)),
+			Assignees: make([]string, 0, len(issue.Assignees)),
+			Created:   issue.Created,
+			Updated:   issue.Updated,
+			Comments:  make([]*IssueArchiveComment, 0, len(issue.Comments)),
+		}
+		for i := range issue.Labels {
+			archived.Labels[i] = issue.Labels[i].Name
+		}
+		if issue.Milestone != nil {
+			archived.Milestone = issue.Milestone.Name
+		}
+		for _, assignee := range issue.Assignees {
+			archived.Assignees = append(archived.Assignees, assignee.Name)
+		}
+		archived.Attachments, err = aw.addAttachments(issue.Attachments)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range issue.Comments {
+			if c.Type != COMMENT_TYPE_COMMENT {
+				continue
+			}
+
+			comment := &IssueArchiveComment{
+				Poster:  archiveUserName(c.Poster),
+				Content: c.Content,
+				Created: c.Created,
+				Updated: c.Updated,
+			}
+			comment.Attachments, err = aw.addAttachments(c.Attachments)
+			if err != nil {
+				return err
+			}
+			archived.Comments = append(archived.Comments, comment)
+		}
+
+		archive.Issues = append(archive.Issues, archived)
+	}
+
+	fw, err := zw.Create(issueArchiveJSON)
+	if err != nil {
+		return fmt.Errorf("create %s: %v", issueArchiveJSON, err)
+	}
+	enc := json.NewEncoder(fw)
+	enc.SetIndent("", "  ")
+	if err = enc.Encode(archive); err != nil {
+		return fmt.Errorf("encode %s: %v", issueArchiveJSON, err)
+	}
+
+	fw, err = zw.Create(issueArchiveCSV)
+	if err != nil {
+		return fmt.Errorf("create %s: %v", issueArchiveCSV, err)
+	}
+	if err = writeIssueArchiveCSV(fw, archive); err != nil {
+		return fmt.Errorf("write %s: %v", issueArchiveCSV, err)
+	}
+
+	return zw.Close()
+}
+
+// writeIssueArchiveCSV writes a flat report of issues in the archive with one issue
+// per line.
+func writeIssueArchiveCSV(w io.Writer, archive *IssueArchive) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"index", "title", "state", "author", "assignees", "labels", "milestone", "comments", "created_at", "updated_at"}); err != nil {
+		return err
+	}
+	for _, issue := range archive.Issues {
+		state := "open"
+		if issue.IsClosed {
+			state = "closed"
+		}
+		if err := cw.Write([]string{
+			strconv.FormatInt(issue.Index, 10),
+			issue.Title,
+			state,
+			issue.Poster,
+			strings.Join(issue.Assignees, ","),
+			strings.Join(issue.Labels, ","),
+			issue.Milestone,
+			strconv.Itoa(len(issue.Comments)),
+			issue.Created.Format(time.RFC3339),
+			issue.Updated.Format(time.RFC3339),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// readIssueArchive reads and validates issues.json of the archive, files in the
+// archive are returned by their names.
+func readIssueArchive(r io.ReaderAt, size int64) (*IssueArchive, map[string]*zip.File, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, nil, ErrIssueArchiveInvalid{args: map[string]interface{}{"reason": err.Error()}}
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	f, ok := files[issueArchiveJSON]
+	if !ok {
+		return nil, nil, ErrIssueArchiveInvalid{args: map[string]interface{}{"reason": "missing " + issueArchiveJSON}}
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, nil, fmt.Errorf("open %s: %v", issueArchiveJSON, err)
+	}
+	defer rc.Close()
+
+	archive := new(IssueArchive)
+	if err = json.NewDecoder(rc).Decode(archive); err != nil {
+		return nil, nil, ErrIssueArchiveInvalid{args: map[string]interface{}{"reason": err.Error()}}
+	} else if archive.Version != IssueArchiveVersion {
+		return nil, nil, ErrIssueArchiveInvalid{args: map[string]interface{}{"version": archive.Version}}
+	}
+
+	for _, issue := range archive.Issues {
+		if strings.TrimSpace(issue.Title) == "" {
+			return nil, nil, ErrIssueArchiveInvalid{args: map[string]interface{}{"index": issue.Index, "reason": "empty title"}}
+		}
+		attachments := issue.Attachments
+		for _, c := range issue.Comments {
+			attachments = append(attachments, c.Attachments...)
+		}
+		for _, attach := range attachments {
+			if _, ok := files[attach.File]; !ok {
+				return nil, nil, ErrIssueArchiveInvalid{args: map[string]interface{}{"index": issue.Index, "reason": "missing " + attach.File}}
+			}
+		}
+	}
+	return archive, files, nil
+}
+
+// issueArchiveImporter resolves names in an archive to objects of the repository
+// that the archive is imported into.
+type issueArchiveImporter struct {
+	repo       *Repository
+	files      map[string]*zip.File
+	userIDs    map[string]int64
+	labelIDs   map[string]int64
+	milestones map[string]int64
+	// Local paths of saved attachment files, they are removed if the import fails.
+	attachmentPaths []string
+}
+
+// userID returns ID of the user with given name, or ID of the ghost user if no such
+// user exists.
+func (im *issueArchiveImporter) userID(name string) (int64, error) {
+	if name == "" {
+		return NewGhostUser().ID, nil
+	}
+	if id, ok := im.userIDs[name]; ok {
+		return id, nil
+	}
+
+	u, err := GetUserByName(name)
+	if err != nil {
+		if !IsErrUserNotExist(err) {
+			return 0, err
+		}
+		u = NewGhostUser()
+	} else if u.IsOrganization() {
+		u = NewGhostUser()
+	}
+	im.userIDs[name] = u.ID
+	return u.ID, nil
+}
+
+// newAttachments saves contents of archived attachments as files of new attachments,
+// the attachments are inserted along with issues or comments they belong to.
+func (im *issueArchiveImporter) newAttachments(archived []*IssueArchiveAttachment) ([]*Attachment, error) {
+	attachments := make([]*Attachment, 0, len(archived))
+	for _, a := range archived {
+		rc, err := im.files[a.File].Open()
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %v", a.File, err)
+		}
+
+		attach := &Attachment{
+			UUID: gouuid.NewV4().String(),
+			Name: a.Name,
+		}
+		im.attachmentPaths = append(im.attachmentPaths, attach.LocalPath())
+		err = attach.saveFile(nil, rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("save attachment %q: %v", a.Name, err)
+		}
+		attachments = append(attachments, attach)
+	}
+	return attachments, nil
+}
+
+// removeAttachments removes all saved attachment files.
+func (im *issueArchiveImporter) removeAttachments() {
+	for _, p := range im.attachmentPaths {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			log.Error("Failed to remove attachment %q: %v", p, err)
+		}
+	}
+}
+
+// prepare creates labels and milestones in the archive that do not exist in the
+// repository by their names.
+func (im *issueArchiveImporter) prepare(archive *IssueArchive) error {
+	labels, err := GetLabelsByRepoID(im.repo.ID)
+	if err != nil {
+		return fmt.Errorf("get labels: %v", err)
+	}
+	for _, l := range labels {
+		im.labelIDs[l.Name] = l.ID
+	}
+	for _, archived := range archive.Labels {
+		if _, ok := im.labelIDs[archived.Name]; ok {
+			continue
+		}
+
+		color := archived.Color
+		if !labelColorPattern.MatchString(color) {
+			color = "#e6e6e6"
+		}
+		l := &Label{
+			RepoID: im.repo.ID,
+			Name:   archived.Name,
+			Color:  color,
+		}
+		if err = NewLabels(l); err != nil {
+			return fmt.Errorf("new label %q: %v", archived.Name, err)
+		}
+		im.labelIDs[l.Name] = l.ID
+	}
+
+	milestones, err := GetMilestonesByRepoID(im.repo.ID)
+	if err != nil {
+		return fmt.Errorf("get milestones: %v", err)
+	}
+	for _, m := range milestones {
+		im.milestones[m.Name] = m.ID
+	}
+	for _, archived := range archive.Milestones {
+		if _, ok := im.milestones[archived.Name]; ok {
+			continue
+		}
+
+		m := &Milestone{
+			RepoID:  im.repo.ID,
+			Name:    archived.Name,
+			Content: archived.Content,
+		}
+		if archived.Deadline != nil {
+			m.Deadline = *archived.Deadline
+		} else {
+			m.Deadline, _ = time.ParseInLocation("2006-01-02", "9999-12-31", time.Local)
+		}
+		if err = NewMilestone(m); err != nil {
+			return fmt.Errorf("new milestone %q: %v", archived.Name, err)
+		}
+		if archived.IsClosed {
+			if err = ChangeMilestoneStatus(m, true); err != nil {
+				return fmt.Errorf("close milestone %q: %v", archived.Name, err)
+			}
+		}
+		im.milestones[m.Name] = m.ID
+	}
+	return nil
+}
+
+// ImportIssues imports issues from an archive produced by ExportIssues into the
+// repository as new issues, and returns the number of imported issues. Authorship
+// of issues and comments is preserved for users that exist by the same names, and
+// falls back to the ghost user otherwise. Labels and milestones are matched by
+// names and created if missing.
+func ImportIssues(repo *Repository, r io.ReaderAt, size int64) (_ int, err error) {
+	archive, files, err := readIssueArchive(r, size)
+	if err != nil {
+		return 0, err
+	}
+
+	im := &issueArchiveImporter{
+		repo:       repo,
+		files:      files,
+		userIDs:    make(map[string]int64),
+		labelIDs:   make(map[string]int64),
+		milestones: make(map[string]int64),
+	}
+	if err = im.prepare(archive); err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			im.removeAttachments()
+		}
+	}()
+
+	// During the session, SQLite3 driver cannot handle retrieve objects after update something.
+	// So we have to resolve all users and save all attachment files first.
+	type importedComment struct {
+		*Comment
+		attachments []*Attachment
+	}
+	type importedIssue struct {
+		*Issue
+		labelIDs    []int64
+		attachments []*Attachment
+		comments    []*importedComment
+	}
+	imported := make([]*importedIssue, len(archive.Issues))
+	for i, archived := range archive.Issues {
+		issue := &importedIssue{
+			Issue: &Issue{
+				RepoID:      repo.ID,
+				Repo:        repo,
+				Title:       archived.Title,
+				Content:     archived.Content,
+				MilestoneID: im.milestones[archived.Milestone],
+				IsClosed:    archived.IsClosed,
+				NumComments: len(archived.Comments),
+				CreatedUnix: archived.Created.Unix(),
+				UpdatedUnix: archived.Updated.Unix(),
+			},
+			comments: make([]*importedComment, len(archived.Comments)),
+		}
+		if issue.PosterID, err = im.userID(archived.Poster); err != nil {
+			return 0, fmt.Errorf("get poster %q: %v", archived.Poster, err)
+		}
+		for _, name := range archived.Assignees {
+			id, err := im.userID(name)
+			if err != nil {
+				return 0, fmt.Errorf("get assignee %q: %v", name, err)
+			} else if id > 0 {
+				issue.AssigneeIDs = append(issue.AssigneeIDs, id)
+			}
+		}
+		for _, name := range archived.Labels {
+			if id, ok := im.labelIDs[name]; ok {
+				issue.labelIDs = append(issue.labelIDs, id)
+			}
+		}
+		if issue.attachments, err = im.newAttachments(archived.Attachments); err != nil {
+			return 0, err
+		}
+
+		for j, c := range archived.Comments {
+			comment := &importedComment{
+				Comment: &Comment{
+					Type:        COMMENT_TYPE_COMMENT,
+					Content:     c.Content,
+					CreatedUnix: c.Created.Unix(),
+					UpdatedUnix: c.Updated.Unix(),
+				},
+			}
+			if comment.PosterID, err = im.userID(c.Poster); err != nil {
+				return 0, fmt.Errorf("get comment poster %q: %v", c.Poster, err)
+			}
+			if comment.attachments, err = im.newAttachments(c.Attachments); err != nil {
+				return 0, err
+			}
+			issue.comments[j] = comment
+		}
+		imported[i] = issue
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return 0, err
+	}
+
+	for _, issue := range imported {
+		// Timestamps are overwritten on insert, they have to be restored afterwards.
+		createdUnix, updatedUnix := issue.CreatedUnix, issue.UpdatedUnix
+		if err = newIssue(sess, NewIssueOptions{
+			Repo:     repo,
+			Issue:    issue.Issue,
+			LableIDs: issue.labelIDs,
+		}); err != nil {
+			return 0, fmt.Errorf("new issue %q: %v", issue.Title, err)
+		}
+		repo.NumIssues++
+
+		for _, attach := range issue.attachments {
+			attach.IssueID = issue.ID
+			if _, err = sess.Insert(attach); err != nil {
+				return 0, fmt.Errorf("insert attachment: %v", err)
+			}
+		}
+
+		if _, err = sess.Exec("UPDATE `issue` SET created_unix = ?, updated_unix = ? WHERE id = ?", createdUnix, updatedUnix, issue.ID); err != nil {
+			return 0, fmt.Errorf("restore timestamps of issue: %v", err)
+		}
+		if issue.IsClosed {
+			if _, err = sess.Exec("UPDATE `repository` SET num_closed_issues = num_closed_issues + 1 WHERE id = ?", repo.ID); err != nil {
+				return 0, fmt.Errorf("increase closed issues: %v", err)
+			} else if err = updateIssueUsersByStatus(sess, issue.ID, true); err != nil {
+				return 0, fmt.Errorf("update issue users by status: %v", err)
+			}
+			repo.NumClosedIssues++
+		}
+
+		for _, c := range issue.comments {
+			createdUnix, updatedUnix := c.CreatedUnix, c.UpdatedUnix
+			c.IssueID = issue.ID
+			if _, err = sess.Insert(c.Comment); err != nil {
+				return 0, fmt.Errorf("insert comment: %v", err)
+			}
+			if _, err = sess.Exec("UPDATE `comment` SET created_unix = ?, updated_unix = ? WHERE id = ?", createdUnix, updatedUnix, c.ID); err != nil {
+				return 0, fmt.Errorf("restore timestamps of comment: %v", err)
+			}
+			for _, attach := range c.attachments {
+				attach.IssueID = issue.ID
+				attach.CommentID = c.ID
+				if _, err = sess.Insert(attach); err != nil {
+					return 0, fmt.Errorf("insert attachment: %v", err)
+				}
+			}
+		}
+	}
+
+	if err = sess.Commit(); err != nil {
+		return 0, err
+	}
+
+	for _, issue := range imported {
+		updateIssueIndexer(issue.ID)
+	}
+	return len(imported), nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestIssueArchive(t *testing.T, archive *IssueArchive) *bytes.Reader {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	fw, err := zw.Create(issueArchiveJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = json.NewEncoder(fw).Encode(archive); err != nil {
+		t.Fatal(err)
+	}
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func Test_readIssueArchive(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		r := newTestIssueArchive(t, &IssueArchive{
+			Version: IssueArchiveVersion,
+			Issues:  []*IssueArchiveIssue{{Index: 1, Title: "Bug"}},
+		})
+		archive, _, err := readIssueArchive(r, r.Size())
+		assert.Nil(t, err)
+		assert.Len(t, archive.Issues, 1)
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		r := newTestIssueArchive(t, &IssueArchive{Version: IssueArchiveVersion + 1})
+		_, _, err := readIssueArchive(r, r.Size())
+		assert.True(t, IsErrIssueArchiveInvalid(err))
+	})
+
+	t.Run("missing attachment", func(t *testing.T) {
+		r := newTestIssueArchive(t, &IssueArchive{
+			Version: IssueArchiveVersion,
+			Issues: []*IssueArchiveIssue{{
+				Index:       1,
+				Title:       "Bug",
+				Attachments: []*IssueArchiveAttachment{{Name: "log.txt", File: "attachments/missing"}},
+			}},
+		})
+		_, _, err := readIssueArchive(r, r.Size())
+		assert.True(t, IsErrIssueArchiveInvalid(err))
+	})
+
+	t.Run("not a zip", func(t *testing.T) {
+		r := bytes.NewReader([]byte("{}"))
+		_, _, err := readIssueArchive(r, r.Size())
+		assert.True(t, IsErrIssueArchiveInvalid(err))
+	})
+}
+
+func Test_writeIssueArchiveCSV(t *testing.T) {
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	var buf bytes.Buffer
+	err := writeIssueArchiveCSV(&buf, &IssueArchive{
+		Issues: []*IssueArchiveIssue{{
+			Index:     3,
+			Title:     "Crash, on start",
+			Poster:    "alice",
+			IsClosed:  true,
+			Labels:    []string{"bug", "p1"},
+			Assignees: []string{"bob"},
+			Created:   created,
+			Updated:   created,
+			Comments:  []*IssueArchiveComment{{}},
+		}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, `index,title,state,author,assignees,labels,milestone,comments,created_at,updated_at
+3,"Crash, on start",closed,alice,bob,"bug,p1",,1,2020-01-02T03:04:05Z,2020-01-02T03:04:05Z
+`, buf.String())
+}
@@ -0,0 +1,309 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"fmt"
+
+	"github.com/json-iterator/go"
+	log "unknwon.dev/clog/v2"
+	"xorm.io/xorm"
+
+	api "github.com/gogs/go-gogs-client"
+)
+
+// IssueAssignee represents an issue-assignee relation.
+type IssueAssignee struct {
+	ID         int64
+	IssueID    int64 `xorm:"INDEX UNIQUE(s)"`
+	AssigneeID int64 `xorm:"INDEX UNIQUE(s)"`
+}
+
+// issueAssigneeCond is the condition of querying issues assigned to a user.
+const issueAssigneeCond = "issue.id IN (SELECT issue_id FROM issue_assignee WHERE assignee_id = ?)"
+
+func getAssigneeIDsByIssueID(e Engine, issueID int64) ([]int64, error) {
+	ids := make([]int64, 0, 3)
+	return ids, e.Table("issue_assignee").Where("issue_id = ?", issueID).Asc("id").Cols("assignee_id").Find(&ids)
+}
+
+// getAssigneesByIssueID returns assignees of the issue in the order they were assigned,
+// users that no longer exist are omitted.
+func getAssigneesByIssueID(e Engine, issueID int64) ([]*User, error) {
+	ids, err := getAssigneeIDsByIssueID(e, issueID)
+	if err != nil {
+		return nil, fmt.Errorf("get assignee IDs: %v", err)
+	}
+
+	assignees := make([]*User, 0, len(ids))
+	for _, id := range ids {
+		u, err := getUserByID(e, id)
+		if err != nil {
+			if IsErrUserNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("getUserByID [%d]: %v", id, err)
+		}
+		assignees = append(assignees, u)
+	}
+	return assignees, nil
+}
+
+// GetAssigneesByIssueID returns assignees of the issue by given ID.
+func GetAssigneesByIssueID(issueID int64) ([]*User, error) {
+	return getAssigneesByIssueID(x, issueID)
+}
+
+// IsAssignee returns true if given user is an assignee of the issue.
+// It requires Assignees to be loaded.
+func (issue *Issue) IsAssignee(userID int64) bool {
+	for _, u := range issue.Assignees {
+		if u.ID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// validAssigneeIDs returns IDs of users who exist and have read access to the repository
+// in given list, duplicates are removed.
+func validAssigneeIDs(e Engine, repo *Repository, ids []int64) ([]int64, error) {
+	valid := make([]int64, 0, len(ids))
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if id <= 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		u, err := getUserByID(e, id)
+		if err != nil {
+			if IsErrUserNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("getUserByID [%d]: %v", id, err)
+		}
+
+		has, err := hasAccess(e, u.ID, repo, AccessModeRead)
+		if err != nil {
+			return nil, fmt.Errorf("hasAccess [user_id: %d, repo_id: %d]: %v", u.ID, repo.ID, err)
+		} else if has {
+			valid = append(valid, u.ID)
+		}
+	}
+	return valid, nil
+}
+
+// syncIssueAssignees updates the deprecated assignee column, which is the earliest
+// assignee, and the issue-user relations by current assignees of the issue.
+func syncIssueAssignees(e *xorm.Session, issue *Issue) (err error) {
+	ids, err := getAssigneeIDsByIssueID(e, issue.ID)
+	if err != nil {
+		return fmt.Errorf("get assignee IDs: %v", err)
+	}
+
+	issue.AssigneeID = 0
+	if len(ids) > 0 {
+		issue.AssigneeID = ids[0]
+	}
+	if _, err = e.ID(issue.ID).Cols("assignee_id").Update(issue); err != nil {
+		return fmt.Errorf("update issue: %v", err)
+	}
+
+	if _, err = e.Exec("UPDATE `issue_user` SET is_assigned = ? WHERE issue_id = ?", false, issue.ID); err != nil {
+		return err
+	} else if len(ids) == 0 {
+		return nil
+	}
+	_, err = e.In("uid", ids).And("issue_id = ?", issue.ID).Cols("is_assigned").Update(&IssueUser{IsAssigned: true})
+	return err
+}
+
+// IssueAssigneePayload represents the payload of issues events for assigned and
+// unassigned actions, which carries the user who is assigned or unassigned.
+type IssueAssigneePayload struct {
+	*api.IssuesPayload
+	Assignee *api.User `json:"assignee"`
+}
+
+func (p *IssueAssigneePayload) JSONPayload() ([]byte, error) {
+	data, err := jsoniter.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return []byte{}, err
+	}
+	return data, nil
+}
+
+// PullRequestAssigneePayload represents the payload of pull request events for assigned
+// and unassigned actions, which carries the user who is assigned or unassigned.
+type PullRequestAssigneePayload struct {
+	*api.PullRequestPayload
+	Assignee *api.User `json:"assignee"`
+}
+
+func (p *PullRequestAssigneePayload) JSONPayload() ([]byte, error) {
+	data, err := jsoniter.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return []byte{}, err
+	}
+	return data, nil
+}
+
+// toIssuesPayload returns the issues payload and the user who is assigned or
+// unassigned if the payload is for such actions.
+func toIssuesPayload(p api.Payloader) (*api.IssuesPayload, *api.User) {
+	if ap, ok := p.(*IssueAssigneePayload); ok {
+		return ap.IssuesPayload, ap.Assignee
+	}
+	return p.(*api.IssuesPayload), nil
+}
+
+// toPullRequestPayload returns the pull request payload and the user who is assigned
+// or unassigned if the payload is for such actions.
+func toPullRequestPayload(p api.Payloader) (*api.PullRequestPayload, *api.User) {
+	if ap, ok := p.(*PullRequestAssigneePayload); ok {
+		return ap.PullRequestPayload, ap.Assignee
+	}
+	return p.(*api.PullRequestPayload), nil
+}
+
+// assigneePayload returns the webhook payload of the issue or the pull request for
+// the user who is assigned or unassigned.
+func (issue *Issue) assigneePayload(doer, assignee *User, isRemove bool) api.Payloader {
+	action := api.HOOK_ISSUE_ASSIGNED
+	if isRemove {
+		action = api.HOOK_ISSUE_UNASSIGNED
+	}
+
+	if issue.IsPull {
+		return &PullRequestAssigneePayload{
+			PullRequestPayload: issue.issuePayload(doer, action).(*api.PullRequestPayload),
+			Assignee:           assignee.APIFormat(),
+		}
+	}
+	return &IssueAssigneePayload{
+		IssuesPayload: issue.issuePayload(doer, action).(*api.IssuesPayload),
+		Assignee:      assignee.APIFormat(),
+	}
+}
+
+func (issue *Issue) sendAssigneeWebhook(doer, assignee *User, isRemove bool) {
+	if err := PrepareWebhooks(issue.Repo, issue.webhookEvent(), issue.assigneePayload(doer, assignee, isRemove)); err != nil {
+		log.Error("PrepareWebhooks [is_pull: %v, remove_assignee: %v]: %v", issue.IsPull, isRemove, err)
+	}
+}
+
+// ChangeAssignees replaces assignees of the issue with given users, invalid users are
+// dropped silently. A webhook is sent for every user who is assigned or unassigned.
+func (issue *Issue) ChangeAssignees(doer *User, assigneeIDs []int64) (err error) {
+	if err = issue.loadAttributes(x); err != nil {
+		return fmt.Errorf("load attributes: %v", err)
+	}
+	oldAssignees := issue.Assignees
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	assigneeIDs, err = validAssigneeIDs(sess, issue.Repo, assigneeIDs)
+	if err != nil {
+		return fmt.Errorf("validate assignees: %v", err)
+	}
+	isNew := make(map[int64]bool, len(assigneeIDs))
+	for _, id := range assigneeIDs {
+		isNew[id] = true
+	}
+
+	removed := make([]*User, 0, len(oldAssignees))
+	for _, u := range oldAssignees {
+		if isNew[u.ID] {
+			delete(isNew, u.ID)
+			continue
+		}
+
+		if _, err = sess.Delete(&IssueAssignee{IssueID: issue.ID, AssigneeID: u.ID}); err != nil {
+			return fmt.Errorf("delete issue assignee: %v", err)
+		}
+		removed = append(removed, u)
+	}
+
+	for _, id := range assigneeIDs {
+		if !isNew[id] {
+			continue
+		}
+
+		if _, err = sess.Insert(&IssueAssignee{IssueID: issue.ID, AssigneeID: id}); err != nil {
+			return fmt.Errorf("insert issue assignee: %v", err)
+		}
+	}
+
+	if err = syncIssueAssignees(sess, issue); err != nil {
+		return fmt.Errorf("sync issue assignees: %v", err)
+	}
+
+	if err = sess.Commit(); err != nil {
+		return fmt.Errorf("commit: %v", err)
+	}
+
+	issue.Assignee = nil
+	issue.Assignees, err = GetAssigneesByIssueID(issue.ID)
+	if err != nil {
+		return fmt.Errorf("get assignees: %v", err)
+	}
+	if len(issue.Assignees) > 0 {
+		issue.Assignee = issue.Assignees[0]
+	}
+
+	for _, u := range removed {
+		issue.sendAssigneeWebhook(doer, u, true)
+	}
+	notifyIDs := make([]int64, 0, len(isNew))
+	for _, u := range issue.Assignees {
+		if isNew[u.ID] {
+			issue.sendAssigneeWebhook(doer, u, false)
+			if u.ID != doer.ID {
+				notifyIDs = append(notifyIDs, u.ID)
+			}
+		}
+	}
+	if err = notifyIssueUsers(x, issue, notifyIDs, NOTIFICATION_REASON_ASSIGN); err != nil {
+		log.Error("Failed to notify assignees [issue_id: %d]: %v", issue.ID, err)
+	}
+	return nil
+}
+
+// AddAssignee assigns given user to the issue.
+func (issue *Issue) AddAssignee(doer *User, assigneeID int64) error {
+	if err := issue.loadAttributes(x); err != nil {
+		return fmt.Errorf("load attributes: %v", err)
+	} else if issue.IsAssignee(assigneeID) {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(issue.Assignees)+1)
+	for _, u := range issue.Assignees {
+		ids = append(ids, u.ID)
+	}
+	return issue.ChangeAssignees(doer, append(ids, assigneeID))
+}
+
+// RemoveAssignee unassigns given user from the issue.
+func (issue *Issue) RemoveAssignee(doer *User, assigneeID int64) error {
+	if err := issue.loadAttributes(x); err != nil {
+		return fmt.Errorf("load attributes: %v", err)
+	} else if !issue.IsAssignee(assigneeID) {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(issue.Assignees))
+	for _, u := range issue.Assignees {
+		if u.ID != assigneeID {
+			ids = append(ids, u.ID)
+		}
+	}
+	return issue.ChangeAssignees(doer, ids)
+}
@@ -0,0 +1,341 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"fmt"
+
+	log "unknwon.dev/clog/v2"
+
+	api "github.com/gogs/go-gogs-client"
+)
+
+// BulkEditIssuesOptions contains changes to be applied to many issues at once, zero
+// values leave corresponding attributes of issues unchanged.
+type BulkEditIssuesOptions struct {
+	MilestoneID    int64 // -1 to remove the milestone.
+	AddLabelIDs    []int64
+	RemoveLabelIDs []int64
+	AssigneeID     int64 // -1 to remove all assignees, otherwise replaces all assignees.
+	State          api.StateType
+	// Comment is posted to every issue before its status is changed, it is ignored
+	// if State is empty.
+	Comment string
+}
+
+// bulkIssueChanges records which attributes of an issue have been changed by a bulk edit.
+type bulkIssueChanges struct {
+	milestone bool
+	labels    bool
+	assignees bool
+	status    bool
+
+	// addedAssignee is the user who is newly assigned, if any.
+	addedAssignee *User
+	// removedAssignees is the list of users who are no longer assigned.
+	removedAssignees []*User
+}
+
+// kinds returns the number of kinds of attributes that have been changed.
+func (c bulkIssueChanges) kinds() int {
+	n := 0
+	for _, changed := range []bool{c.milestone, c.labels, c.assignees, c.status} {
+		if changed {
+			n++
+		}
+	}
+	return n
+}
+
+func (c bulkIssueChanges) any() bool {
+	return c.kinds() > 0
+}
+
+// webhookEvent returns the webhook event type of the issue or the pull request.
+func (issue *Issue) webhookEvent() HookEventType {
+	if issue.IsPull {
+		return HOOK_EVENT_PULL_REQUEST
+	}
+	return HOOK_EVENT_ISSUES
+}
+
+// issuePayload returns the webhook payload of the issue or the pull request with
+// given action.
+func (issue *Issue) issuePayload(doer *User, action api.HookIssueAction) api.Payloader {
+	if issue.IsPull {
+		issue.PullRequest.Issue = issue
+		return &api.PullRequestPayload{
+			Action:      action,
+			Index:       issue.Index,
+			PullRequest: issue.PullRequest.APIFormat(),
+			Repository:  issue.Repo.APIFormat(nil),
+			Sender:      doer.APIFormat(),
+		}
+	}
+	return &api.IssuesPayload{
+		Action:     action,
+		Index:      issue.Index,
+		Issue:      issue.APIFormat(),
+		Repository: issue.Repo.APIFormat(nil),
+		Sender:     doer.APIFormat(),
+	}
+}
+
+// sendIssueWebhook sends a webhook of the issue or the pull request with given action.
+func (issue *Issue) sendIssueWebhook(doer *User, action api.HookIssueAction) {
+	if err := PrepareWebhooks(issue.Repo, issue.webhookEvent(), issue.issuePayload(doer, action)); err != nil {
+		log.Error("PrepareWebhooks [is_pull: %v, action: %s]: %v", issue.IsPull, action, err)
+	}
+}
+
+// bulkEditPayload returns the webhook payload that describes changes made by a bulk
+// edit. A specific action is used when only one kind of attributes has been changed
+// and the change can be described by the action, otherwise the issue is considered
+// as edited.
+func (issue *Issue) bulkEditPayload(doer *User, changes bulkIssueChanges) api.Payloader {
+	if changes.kinds() > 1 {
+		return issue.issuePayload(doer, api.HOOK_ISSUE_EDITED)
+	}
+
+	switch {
+	case changes.status:
+		if issue.IsClosed {
+			return issue.issuePayload(doer, api.HOOK_ISSUE_CLOSED)
+		}
+		return issue.issuePayload(doer, api.HOOK_ISSUE_REOPENED)
+	case changes.labels:
+		return issue.issuePayload(doer, api.HOOK_ISSUE_LABEL_UPDATED)
+	case changes.milestone:
+		if issue.MilestoneID > 0 {
+			return issue.issuePayload(doer, api.HOOK_ISSUE_MILESTONED)
+		}
+		return issue.issuePayload(doer, api.HOOK_ISSUE_DEMILESTONED)
+	case changes.assignees:
+		if changes.addedAssignee != nil && len(changes.removedAssignees) == 0 {
+			return issue.assigneePayload(doer, changes.addedAssignee, false)
+		} else if changes.addedAssignee == nil && len(changes.removedAssignees) == 1 {
+			return issue.assigneePayload(doer, changes.removedAssignees[0], true)
+		}
+	}
+	return issue.issuePayload(doer, api.HOOK_ISSUE_EDITED)
+}
+
+// sendBulkEditWebhook sends the webhook of the issue that describes changes made
+// by a bulk edit.
+func (issue *Issue) sendBulkEditWebhook(doer *User, changes bulkIssueChanges) {
+	if err := PrepareWebhooks(issue.Repo, issue.webhookEvent(), issue.bulkEditPayload(doer, changes)); err != nil {
+		log.Error("PrepareWebhooks [issue_id: %d, is_pull: %v]: %v", issue.ID, issue.IsPull, err)
+	}
+}
+
+// BulkEditIssues applies changes to issues and pull requests of given IDs in the
+// repository within a single transaction, IDs that do not belong to the repository
+// are ignored. Exactly one webhook is sent for every issue that is actually changed:
+// the specific action (e.g. closed, label_updated, assigned) when only one kind of
+// attributes has been changed, or "edited" when more than one kind has been changed
+// or changes of assignees involve more than one user. Changed issues are returned.
+func BulkEditIssues(doer *User, repo *Repository, issueIDs []int64, opts BulkEditIssuesOptions) (_ []*Issue, err error) {
+	if len(issueIDs) == 0 {
+		return nil, nil
+	}
+	switch opts.State {
+	case "", api.STATE_OPEN, api.STATE_CLOSED:
+	default:
+		return nil, fmt.Errorf("invalid state: %s", opts.State)
+	}
+
+	// During the session, SQLite3 driver cannot handle retrieve objects after update something.
+	// So we have to get all needed issues, labels, milestone and assignee first.
+	issues := make([]*Issue, 0, len(issueIDs))
+	if err = x.Where("repo_id = ?", repo.ID).In("id", issueIDs).Asc("id").Find(&issues); err != nil {
+		return nil, fmt.Errorf("find issues: %v", err)
+	}
+	for _, issue := range issues {
+		if err = issue.loadAttributes(x); err != nil {
+			return nil, fmt.Errorf("load attributes [issue_id: %d]: %v", issue.ID, err)
+		}
+	}
+
+	// Label counters are updated through label objects, all issues must share the same
+	// object of a label to not overwrite changes made by each other.
+	repoLabels, err := GetLabelsByRepoID(repo.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get labels of repository: %v", err)
+	}
+	labelsByID := make(map[int64]*Label, len(repoLabels))
+	for _, l := range repoLabels {
+		labelsByID[l.ID] = l
+	}
+	for _, issue := range issues {
+		for i := range issue.Labels {
+			if l, ok := labelsByID[issue.Labels[i].ID]; ok {
+				issue.Labels[i] = l
+			}
+		}
+	}
+
+	if opts.MilestoneID > 0 {
+		if _, err = GetMilestoneByRepoID(repo.ID, opts.MilestoneID); err != nil {
+			return nil, err
+		}
+	}
+
+	var assignee *User
+	if opts.AssigneeID > 0 {
+		ids, err := validAssigneeIDs(x, repo, []int64{opts.AssigneeID})
+		if err != nil {
+			return nil, fmt.Errorf("validate assignee: %v", err)
+		} else if len(ids) == 0 {
+			return nil, ErrUserNotExist{args: map[string]interface{}{"userID": opts.AssigneeID}}
+		}
+		assignee, err = GetUserByID(opts.AssigneeID)
+		if err != nil {
+			return nil, fmt.Errorf("get assignee: %v", err)
+		}
+	}
+
+	isClosed := opts.State == api.STATE_CLOSED
+	if isClosed && repo.IssuesBlockCloseByDependencies {
+		for _, issue := range issues {
+			if issue.IsClosed {
+				continue
+			}
+			count, err := issue.countOpenBlockers(x)
+			if err != nil {
+				return nil, fmt.Errorf("count open blockers [issue_id: %d]: %v", issue.ID, err)
+			} else if count > 0 {
+				return nil, ErrIssueHasOpenBlockers{args: map[string]interface{}{"issueID": issue.ID, "count": count}}
+			}
+		}
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	changes := make([]bulkIssueChanges, len(issues))
+	for i, issue := range issues {
+		if opts.MilestoneID != 0 {
+			milestoneID := opts.MilestoneID
+			if milestoneID < 0 {
+				milestoneID = 0
+			}
+			if issue.MilestoneID != milestoneID {
+				oldMilestoneID := issue.MilestoneID
+				issue.MilestoneID = milestoneID
+				if err = changeMilestoneAssign(sess, issue, oldMilestoneID); err != nil {
+					return nil, fmt.Errorf("change milestone [issue_id: %d]: %v", issue.ID, err)
+				}
+				changes[i].milestone = true
+			}
+		}
+
+		for _, id := range opts.RemoveLabelIDs {
+			label, ok := labelsByID[id]
+			if !ok || !issue.hasLoadedLabel(id) {
+				continue
+			}
+			if err = deleteIssueLabel(sess, issue, label); err != nil {
+				return nil, fmt.Errorf("delete issue label [issue_id: %d, label_id: %d]: %v", issue.ID, id, err)
+			}
+			changes[i].labels = true
+		}
+		for _, id := range opts.AddLabelIDs {
+			label, ok := labelsByID[id]
+			if !ok || issue.hasLoadedLabel(id) {
+				continue
+			}
+			if err = newIssueLabel(sess, issue, label); err != nil {
+				return nil, fmt.Errorf("new issue label [issue_id: %d, label_id: %d]: %v", issue.ID, id, err)
+			}
+			changes[i].labels = true
+		}
+
+		if opts.AssigneeID != 0 {
+			isAssigned := len(issue.Assignees) == 1 && assignee != nil && issue.Assignees[0].ID == assignee.ID
+			if !isAssigned && (assignee != nil || len(issue.Assignees) > 0) {
+				if _, err = sess.Where("issue_id = ?", issue.ID).Delete(new(IssueAssignee)); err != nil {
+					return nil, fmt.Errorf("delete issue assignees [issue_id: %d]: %v", issue.ID, err)
+				}
+				changes[i].addedAssignee = assignee
+				for _, u := range issue.Assignees {
+					if assignee != nil && u.ID == assignee.ID {
+						changes[i].addedAssignee = nil
+						continue
+					}
+					changes[i].removedAssignees = append(changes[i].removedAssignees, u)
+				}
+				issue.Assignees = nil
+				if assignee != nil {
+					if _, err = sess.Insert(&IssueAssignee{IssueID: issue.ID, AssigneeID: assignee.ID}); err != nil {
+						return nil, fmt.Errorf("insert issue assignee [issue_id: %d]: %v", issue.ID, err)
+					}
+					issue.Assignees = []*User{assignee}
+				}
+				if err = syncIssueAssignees(sess, issue); err != nil {
+					return nil, fmt.Errorf("sync issue assignees [issue_id: %d]: %v", issue.ID, err)
+				}
+				issue.Assignee = assignee
+				changes[i].assignees = true
+			}
+		}
+
+		if opts.State == "" || issue.IsClosed == isClosed ||
+			(issue.IsPull && issue.PullRequest.HasMerged) {
+			continue
+		}
+
+		if opts.Comment != "" {
+			if _, err = createComment(sess, &CreateCommentOptions{
+				Type:    COMMENT_TYPE_COMMENT,
+				Doer:    doer,
+				Repo:    repo,
+				Issue:   issue,
+				Content: opts.Comment,
+			}); err != nil {
+				return nil, fmt.Errorf("create comment [issue_id: %d]: %v", issue.ID, err)
+			}
+		}
+		if err = issue.changeStatus(sess, doer, repo, isClosed); err != nil {
+			return nil, fmt.Errorf("change status [issue_id: %d]: %v", issue.ID, err)
+		}
+		changes[i].status = true
+	}
+
+	if err = sess.Commit(); err != nil {
+		return nil, err
+	}
+
+	changed := make([]*Issue, 0, len(issues))
+	for i, issue := range issues {
+		if !changes[i].any() {
+			continue
+		}
+		changed = append(changed, issue)
+
+		issue.sendBulkEditWebhook(doer, changes[i])
+		if changes[i].status && opts.Comment != "" {
+			updateIssueIndexer(issue.ID)
+		}
+		if added := changes[i].addedAssignee; added != nil && added.ID != doer.ID {
+			if err = notifyIssueUsers(x, issue, []int64{added.ID}, NOTIFICATION_REASON_ASSIGN); err != nil {
+				log.Error("Failed to notify assignee [issue_id: %d]: %v", issue.ID, err)
+			}
+		}
+	}
+	return changed, nil
+}
+
+// hasLoadedLabel returns true if the label is one of loaded labels of the issue.
+func (issue *Issue) hasLoadedLabel(labelID int64) bool {
+	for i := range issue.Labels {
+		if issue.Labels[i].ID == labelID {
+			return true
+		}
+	}
+	return false
+}
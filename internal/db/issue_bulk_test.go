@@ -0,0 +1,117 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	api "github.com/gogs/go-gogs-client"
+)
+
+func TestIssue_bulkEditPayload(t *testing.T) {
+	owner := &User{ID: 1, Name: "owner"}
+	alice := &User{ID: 2, Name: "alice"}
+	bob := &User{ID: 3, Name: "bob"}
+	newIssue := func(isPull bool) *Issue {
+		repo := &Repository{ID: 1, Name: "repo", Owner: owner}
+		issue := &Issue{
+			ID:       1,
+			Index:    1,
+			Title:    "Bug",
+			Poster:   owner,
+			Repo:     repo,
+			IsPull:   isPull,
+			IsClosed: true,
+		}
+		if isPull {
+			issue.PullRequest = &PullRequest{ID: 1, Index: 1, BaseRepo: repo}
+		}
+		return issue
+	}
+
+	tests := []struct {
+		name        string
+		changes     bulkIssueChanges
+		expAction   api.HookIssueAction
+		expAssignee string
+	}{
+		{
+			name:      "status",
+			changes:   bulkIssueChanges{status: true},
+			expAction: api.HOOK_ISSUE_CLOSED,
+		},
+		{
+			name:      "labels",
+			changes:   bulkIssueChanges{labels: true},
+			expAction: api.HOOK_ISSUE_LABEL_UPDATED,
+		},
+		{
+			name:      "status and labels",
+			changes:   bulkIssueChanges{status: true, labels: true},
+			expAction: api.HOOK_ISSUE_EDITED,
+		},
+		{
+			name:      "milestone and assignees",
+			changes:   bulkIssueChanges{milestone: true, assignees: true, addedAssignee: alice},
+			expAction: api.HOOK_ISSUE_EDITED,
+		},
+		{
+			name:        "assign",
+			changes:     bulkIssueChanges{assignees: true, addedAssignee: alice},
+			expAction:   api.HOOK_ISSUE_ASSIGNED,
+			expAssignee: "alice",
+		},
+		{
+			name:        "unassign",
+			changes:     bulkIssueChanges{assignees: true, removedAssignees: []*User{bob}},
+			expAction:   api.HOOK_ISSUE_UNASSIGNED,
+			expAssignee: "bob",
+		},
+		{
+			name:      "unassign many",
+			changes:   bulkIssueChanges{assignees: true, removedAssignees: []*User{alice, bob}},
+			expAction: api.HOOK_ISSUE_EDITED,
+		},
+		{
+			name:      "replace assignee",
+			changes:   bulkIssueChanges{assignees: true, addedAssignee: alice, removedAssignees: []*User{bob}},
+			expAction: api.HOOK_ISSUE_EDITED,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, isPull := range []bool{false, true} {
+				issue := newIssue(isPull)
+				p := issue.bulkEditPayload(owner, test.changes)
+
+				var action api.HookIssueAction
+				var assignee *api.User
+				if isPull {
+					var pp *api.PullRequestPayload
+					pp, assignee = toPullRequestPayload(p)
+					action = pp.Action
+				} else {
+					var ip *api.IssuesPayload
+					ip, assignee = toIssuesPayload(p)
+					action = ip.Action
+				}
+				assert.Equal(t, test.expAction, action)
+				if test.expAssignee == "" {
+					assert.Nil(t, assignee)
+				} else if assert.NotNil(t, assignee) {
+					assert.Equal(t, test.expAssignee, assignee.UserName)
+				}
+
+				// Every payload must be deliverable to chat services.
+				slack, err := GetSlackPayload(p, issue.webhookEvent(), `{"channel":"#gogs"}`)
+				if assert.Nil(t, err) && test.expAssignee != "" {
+					assert.Contains(t, slack.Text, test.expAssignee)
+				}
+			}
+		})
+	}
+}
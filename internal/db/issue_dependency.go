@@ -0,0 +1,241 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"xorm.io/xorm"
+
+	api "github.com/gogs/go-gogs-client"
+)
+
+// IssueDependency represents a relation that an issue is blocked by another issue,
+// the two issues may belong to different repositories.
+type IssueDependency struct {
+	ID           int64
+	IssueID      int64 `xorm:"INDEX UNIQUE(s)"` // The issue that is blocked.
+	DependencyID int64 `xorm:"INDEX UNIQUE(s)"` // The issue that blocks.
+
+	Created     time.Time `xorm:"-" json:"-"`
+	CreatedUnix int64
+}
+
+func (d *IssueDependency) BeforeInsert() {
+	d.CreatedUnix = time.Now().Unix()
+}
+
+func (d *IssueDependency) AfterSet(colName string, _ xorm.Cell) {
+	switch colName {
+	case "created_unix":
+		d.Created = time.Unix(d.CreatedUnix, 0).Local()
+	}
+}
+
+type ErrDependencyExists struct {
+	args map[string]interface{}
+}
+
+func IsErrDependencyExists(err error) bool {
+	_, ok := err.(ErrDependencyExists)
+	return ok
+}
+
+func (err ErrDependencyExists) Error() string {
+	return fmt.Sprintf("dependency already exists: %v", err.args)
+}
+
+type ErrCircularDependency struct {
+	args map[string]interface{}
+}
+
+func IsErrCircularDependency(err error) bool {
+	_, ok := err.(ErrCircularDependency)
+	return ok
+}
+
+func (err ErrCircularDependency) Error() string {
+	return fmt.Sprintf("dependency is circular: %v", err.args)
+}
+
+type ErrIssueHasOpenBlockers struct {
+	args map[string]interface{}
+}
+
+func IsErrIssueHasOpenBlockers(err error) bool {
+	_, ok := err.(ErrIssueHasOpenBlockers)
+	return ok
+}
+
+func (err ErrIssueHasOpenBlockers) Error() string {
+	return fmt.Sprintf("issue is blocked by open issues: %v", err.args)
+}
+
+// isBlockedBy returns true if the issue is blocked by the other issue, either directly
+// or through a chain of dependencies.
+func isBlockedBy(e Engine, issueID, blockerID int64) (bool, error) {
+	visited := map[int64]bool{issueID: true}
+	queue := []int64{issueID}
+	for len(queue) > 0 {
+		ids := make([]int64, 0, len(queue))
+		if err := e.Table("issue_dependency").In("issue_id", queue).Cols("dependency_id").Find(&ids); err != nil {
+			return false, err
+		}
+
+		queue = make([]int64, 0, len(ids))
+		for _, id := range ids {
+			if id == blockerID {
+				return true, nil
+			} else if visited[id] {
+				continue
+			}
+			visited[id] = true
+			queue = append(queue, id)
+		}
+	}
+	return false, nil
+}
+
+// AddDependency marks the issue as blocked by given issue.
+func (issue *Issue) AddDependency(dependency *Issue) error {
+	if issue.ID == dependency.ID {
+		return ErrCircularDependency{args: map[string]interface{}{"issueID": issue.ID, "dependencyID": dependency.ID}}
+	}
+
+	has, err := x.Where("issue_id = ? AND dependency_id = ?", issue.ID, dependency.ID).Get(new(IssueDependency))
+	if err != nil {
+		return fmt.Errorf("get dependency: %v", err)
+	} else if has {
+		return ErrDependencyExists{args: map[string]interface{}{"issueID": issue.ID, "dependencyID": dependency.ID}}
+	}
+
+	circular, err := isBlockedBy(x, dependency.ID, issue.ID)
+	if err != nil {
+		return fmt.Errorf("check circular dependency: %v", err)
+	} else if circular {
+		return ErrCircularDependency{args: map[string]interface{}{"issueID": issue.ID, "dependencyID": dependency.ID}}
+	}
+
+	_, err = x.Insert(&IssueDependency{
+		IssueID:      issue.ID,
+		DependencyID: dependency.ID,
+	})
+	return err
+}
+
+// RemoveDependency removes the dependency of the issue on given issue.
+func (issue *Issue) RemoveDependency(dependencyID int64) error {
+	_, err := x.Delete(&IssueDependency{
+		IssueID:      issue.ID,
+		DependencyID: dependencyID,
+	})
+	return err
+}
+
+// filterReadableIssues loads repositories of issues and returns issues that given
+// user has read access to.
+func filterReadableIssues(e Engine, issues []*Issue, userID int64) ([]*Issue, error) {
+	readable := issues[:0]
+	for _, issue := range issues {
+		if issue.Repo == nil {
+			repo, err := getRepositoryByID(e, issue.RepoID)
+			if err != nil {
+				if IsErrRepoNotExist(err) {
+					continue
+				}
+				return nil, fmt.Errorf("getRepositoryByID [%d]: %v", issue.RepoID, err)
+			}
+			issue.Repo = repo
+		}
+
+		has, err := hasAccess(e, userID, issue.Repo, AccessModeRead)
+		if err != nil {
+			return nil, fmt.Errorf("hasAccess [user_id: %d, repo_id: %d]: %v", userID, issue.RepoID, err)
+		} else if has {
+			readable = append(readable, issue)
+		}
+	}
+	return readable, nil
+}
+
+func getIssuesByDependency(e Engine, cond string, issueID, userID int64) ([]*Issue, error) {
+	issues := make([]*Issue, 0, 5)
+	if err := e.Where(cond, issueID).Asc("id").Find(&issues); err != nil {
+		return nil, err
+	}
+	return filterReadableIssues(e, issues, userID)
+}
+
+// BlockedBy returns issues that block the issue and given user has read access to.
+func (issue *Issue) BlockedBy(userID int64) ([]*Issue, error) {
+	return getIssuesByDependency(x, "id IN (SELECT dependency_id FROM issue_dependency WHERE issue_id = ?)", issue.ID, userID)
+}
+
+// Blocks returns issues that are blocked by the issue and given user has read access to.
+func (issue *Issue) Blocks(userID int64) ([]*Issue, error) {
+	return getIssuesByDependency(x, "id IN (SELECT issue_id FROM issue_dependency WHERE dependency_id = ?)", issue.ID, userID)
+}
+
+func (issue *Issue) countOpenBlockers(e Engine) (int64, error) {
+	return e.Where("id IN (SELECT dependency_id FROM issue_dependency WHERE issue_id = ?)", issue.ID).
+		And("is_closed = ?", false).
+		Count(new(Issue))
+}
+
+// APIIssueDependency represents an issue in dependency lists of the API format.
+type APIIssueDependency struct {
+	ID         int64         `json:"id"`
+	Repository string        `json:"repository"`
+	Index      int64         `json:"number"`
+	Title      string        `json:"title"`
+	State      api.StateType `json:"state"`
+}
+
+// APIIssue represents the API format of an issue with its dependencies and lock state.
+type APIIssue struct {
+	*api.Issue
+	Locked     bool                  `json:"locked"`
+	LockReason string                `json:"active_lock_reason,omitempty"`
+	Pinned     bool                  `json:"pinned"`
+	BlockedBy  []*APIIssueDependency `json:"blocked_by"`
+	Blocks     []*APIIssueDependency `json:"blocks"`
+}
+
+func toAPIIssueDependencies(issues []*Issue) []*APIIssueDependency {
+	deps := make([]*APIIssueDependency, len(issues))
+	for i := range issues {
+		deps[i] = &APIIssueDependency{
+			ID:         issues[i].ID,
+			Repository: issues[i].Repo.FullName(),
+			Index:      issues[i].Index,
+			Title:      issues[i].Title,
+			State:      issues[i].State(),
+		}
+	}
+	return deps
+}
+
+// APIFormatWithDependencies returns the API format of the issue with issues that
+// block or are blocked by the issue and given user has read access to.
+func (issue *Issue) APIFormatWithDependencies(userID int64) (*APIIssue, error) {
+	blockedBy, err := issue.BlockedBy(userID)
+	if err != nil {
+		return nil, fmt.Errorf("get blocking issues: %v", err)
+	}
+	blocks, err := issue.Blocks(userID)
+	if err != nil {
+		return nil, fmt.Errorf("get blocked issues: %v", err)
+	}
+
+	return &APIIssue{
+		Issue:      issue.APIFormat(),
+		Locked:     issue.IsLocked,
+		LockReason: issue.LockReason,
+		Pinned:     issue.IsPinned(),
+		BlockedBy:  toAPIIssueDependencies(blockedBy),
+		Blocks:     toAPIIssueDependencies(blocks),
+	}, nil
+}
@@ -0,0 +1,276 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/mapping"
+	"github.com/blevesearch/bleve/search/query"
+	"github.com/unknwon/com"
+	log "unknwon.dev/clog/v2"
+
+	"gogs.io/gogs/internal/conf"
+	"gogs.io/gogs/internal/sync"
+)
+
+// issueIndexerMaxResults is the maximum number of issues returned by a search.
+const issueIndexerMaxResults = 1000
+
+var (
+	issueIndexer      bleve.Index
+	issueIndexerQueue = sync.NewUniqueQueue(1000)
+)
+
+// issueIndexerData is the document of an issue or a pull request in the index.
+type issueIndexerData struct {
+	RepoID   int64
+	Title    string
+	Content  string
+	Comments []string
+}
+
+func issueIndexerPath() string {
+	return filepath.Join(conf.Server.AppDataPath, "indexers", "issues.bleve")
+}
+
+func newIssueIndexerMapping() mapping.IndexMapping {
+	textField := bleve.NewTextFieldMapping()
+	textField.Store = false
+	textField.IncludeInAll = false
+
+	numericField := bleve.NewNumericFieldMapping()
+	numericField.Store = false
+	numericField.IncludeInAll = false
+
+	docMapping := bleve.NewDocumentMapping()
+	docMapping.AddFieldMappingsAt("RepoID", numericField)
+	docMapping.AddFieldMappingsAt("Title", textField)
+	docMapping.AddFieldMappingsAt("Content", textField)
+	docMapping.AddFieldMappingsAt("Comments", textField)
+
+	indexMapping := bleve.NewIndexMapping()
+	indexMapping.DefaultMapping = docMapping
+	return indexMapping
+}
+
+// openIssueIndexer opens the issue index and creates a new one if it does not
+// exist yet. It returns true if the index is newly created.
+func openIssueIndexer() (created bool, err error) {
+	if issueIndexer != nil {
+		return false, nil
+	}
+
+	path := issueIndexerPath()
+	issueIndexer, err = bleve.Open(path)
+	if err == nil {
+		return false, nil
+	} else if err != bleve.ErrorIndexPathDoesNotExist {
+		return false, fmt.Errorf("open: %v", err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return false, fmt.Errorf("create directory: %v", err)
+	}
+	issueIndexer, err = bleve.New(path, newIssueIndexerMapping())
+	if err != nil {
+		return false, fmt.Errorf("new: %v", err)
+	}
+	return true, nil
+}
+
+// InitIssueIndexer opens the full-text index of issues and pull requests, and starts
+// to process index updates in background. A newly created index is populated with
+// all existing issues and pull requests.
+func InitIssueIndexer() error {
+	created, err := openIssueIndexer()
+	if err != nil {
+		return err
+	}
+
+	if created {
+		go func() {
+			log.Info("Populating issue index...")
+			if err := RebuildIssueIndex(); err != nil {
+				log.Error("Failed to populate issue index: %v", err)
+				return
+			}
+			log.Info("Issue index has been populated")
+		}()
+	}
+	go processIssueIndexerQueue()
+	return nil
+}
+
+func processIssueIndexerQueue() {
+	for issueID := range issueIndexerQueue.Queue() {
+		issueIndexerQueue.Remove(issueID)
+
+		if err := indexIssue(com.StrTo(issueID).MustInt64()); err != nil {
+			log.Error("Failed to update issue index [issue_id: %s]: %v", issueID, err)
+		}
+	}
+}
+
+// updateIssueIndexer queues the issue to be re-indexed, the issue is removed from the
+// index if it no longer exists.
+func updateIssueIndexer(issueID int64) {
+	if issueIndexer == nil {
+		return
+	}
+	go issueIndexerQueue.Add(issueID)
+}
+
+func newIssueIndexerData(e Engine, issue *Issue) (*issueIndexerData, error) {
+	comments := make([]string, 0, issue.NumComments)
+	if err := e.Table("comment").
+		Where("issue_id = ?", issue.ID).
+		In("type", COMMENT_TYPE_COMMENT, COMMENT_TYPE_REVIEW, COMMENT_TYPE_CODE).
+		// Code comments of pending reviews are only visible to their posters.
+		And("review_id = 0 OR review_id NOT IN (SELECT id FROM review WHERE state = ?)", REVIEW_STATE_PENDING).
+		Asc("created_unix").
+		Cols("content").
+		Find(&comments); err != nil {
+		return nil, fmt.Errorf("get comments: %v", err)
+	}
+
+	return &issueIndexerData{
+		RepoID:   issue.RepoID,
+		Title:    issue.Title,
+		Content:  issue.Content,
+		Comments: comments,
+	}, nil
+}
+
+func indexIssue(issueID int64) error {
+	id := strconv.FormatInt(issueID, 10)
+	issue, err := getRawIssueByID(x, issueID)
+	if err != nil {
+		if IsErrIssueNotExist(err) {
+			return issueIndexer.Delete(id)
+		}
+		return fmt.Errorf("getRawIssueByID: %v", err)
+	}
+
+	data, err := newIssueIndexerData(x, issue)
+	if err != nil {
+		return err
+	}
+	return issueIndexer.Index(id, data)
+}
+
+// RebuildIssueIndex re-indexes all issues and pull requests, and removes documents
+// of issues that no longer exist from the index.
+func RebuildIssueIndex() error {
+	if _, err := openIssueIndexer(); err != nil {
+		return fmt.Errorf("open issue indexer: %v", err)
+	}
+
+	const batchSize = 100
+	exists := make(map[string]bool)
+	var lastID int64
+	for {
+		issues := make([]*Issue, 0, batchSize)
+		if err := x.Where("id > ?", lastID).Asc("id").Limit(batchSize).Find(&issues); err != nil {
+			return fmt.Errorf("find issues: %v", err)
+		} else if len(issues) == 0 {
+			break
+		}
+
+		batch := issueIndexer.NewBatch()
+		for _, issue := range issues {
+			data, err := newIssueIndexerData(x, issue)
+			if err != nil {
+				return fmt.Errorf("newIssueIndexerData [%d]: %v", issue.ID, err)
+			}
+
+			id := strconv.FormatInt(issue.ID, 10)
+			if err = batch.Index(id, data); err != nil {
+				return fmt.Errorf("index [%d]: %v", issue.ID, err)
+			}
+			exists[id] = true
+		}
+		if err := issueIndexer.Batch(batch); err != nil {
+			return fmt.Errorf("batch: %v", err)
+		}
+		lastID = issues[len(issues)-1].ID
+	}
+
+	count, err := issueIndexer.DocCount()
+	if err != nil {
+		return fmt.Errorf("count documents: %v", err)
+	} else if count <= uint64(len(exists)) {
+		return nil
+	}
+
+	result, err := issueIndexer.Search(bleve.NewSearchRequestOptions(bleve.NewMatchAllQuery(), int(count), 0, false))
+	if err != nil {
+		return fmt.Errorf("search all: %v", err)
+	}
+	batch := issueIndexer.NewBatch()
+	for _, hit := range result.Hits {
+		if !exists[hit.ID] {
+			batch.Delete(hit.ID)
+		}
+	}
+	return issueIndexer.Batch(batch)
+}
+
+// SearchIssueIndex returns IDs of issues and pull requests in given repositories that
+// match the keyword in their titles, contents or comments, ordered by relevance. It
+// searches in all repositories if no repository is given.
+func SearchIssueIndex(keyword string, repoIDs []int64) ([]int64, error) {
+	if issueIndexer == nil {
+		return nil, fmt.Errorf("issue indexer is not initialized")
+	}
+
+	fields := []struct {
+		name  string
+		boost float64
+	}{
+		{"Title", 2},
+		{"Content", 1},
+		{"Comments", 0.5},
+	}
+	matches := make([]query.Query, len(fields))
+	for i, f := range fields {
+		q := bleve.NewMatchQuery(keyword)
+		q.SetField(f.name)
+		q.SetBoost(f.boost)
+		matches[i] = q
+	}
+	var q query.Query = bleve.NewDisjunctionQuery(matches...)
+
+	if len(repoIDs) > 0 {
+		inclusive := true
+		repos := make([]query.Query, len(repoIDs))
+		for i := range repoIDs {
+			repoID := float64(repoIDs[i])
+			rq := bleve.NewNumericRangeInclusiveQuery(&repoID, &repoID, &inclusive, &inclusive)
+			rq.SetField("RepoID")
+			repos[i] = rq
+		}
+		q = bleve.NewConjunctionQuery(bleve.NewDisjunctionQuery(repos...), q)
+	}
+
+	result, err := issueIndexer.Search(bleve.NewSearchRequestOptions(q, issueIndexerMaxResults, 0, false))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		id, err := strconv.ParseInt(hit.ID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse document ID %q: %v", hit.ID, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"fmt"
+)
+
+// LockReasons is the list of reasons that can be given when locking the conversation
+// of an issue or a pull request, the reason is optional.
+var LockReasons = []string{"off-topic", "too heated", "resolved", "spam"}
+
+// IsValidLockReason returns true if given reason is empty or one of lock reasons.
+func IsValidLockReason(reason string) bool {
+	if reason == "" {
+		return true
+	}
+	for i := range LockReasons {
+		if LockReasons[i] == reason {
+			return true
+		}
+	}
+	return false
+}
+
+type ErrInvalidLockReason struct {
+	args map[string]interface{}
+}
+
+func IsErrInvalidLockReason(err error) bool {
+	_, ok := err.(ErrInvalidLockReason)
+	return ok
+}
+
+func (err ErrInvalidLockReason) Error() string {
+	return fmt.Sprintf("lock reason is not valid: %v", err.args)
+}
+
+type ErrIssueLocked struct {
+	args map[string]interface{}
+}
+
+func IsErrIssueLocked(err error) bool {
+	_, ok := err.(ErrIssueLocked)
+	return ok
+}
+
+func (err ErrIssueLocked) Error() string {
+	return fmt.Sprintf("conversation is locked and limited to collaborators with write access: %v", err.args)
+}
+
+// CanComment returns nil if a user with or without write access to the repository
+// is allowed to comment on the issue, and ErrIssueLocked otherwise.
+func (issue *Issue) CanComment(isWriter bool) error {
+	if issue.IsLocked && !isWriter {
+		return ErrIssueLocked{args: map[string]interface{}{"issueID": issue.ID}}
+	}
+	return nil
+}
+
+func (issue *Issue) changeLock(doer *User, isLocked bool, reason string) (err error) {
+	if issue.IsLocked == isLocked {
+		return nil
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	issue.IsLocked = isLocked
+	issue.LockReason = reason
+	if err = updateIssueCols(sess, issue, "is_locked", "lock_reason"); err != nil {
+		return fmt.Errorf("update issue: %v", err)
+	}
+
+	cmtType := COMMENT_TYPE_LOCK
+	if !isLocked {
+		cmtType = COMMENT_TYPE_UNLOCK
+	}
+	if _, err = createComment(sess, &CreateCommentOptions{
+		Type:    cmtType,
+		Doer:    doer,
+		Repo:    issue.Repo,
+		Issue:   issue,
+		Content: reason,
+	}); err != nil {
+		return fmt.Errorf("create comment: %v", err)
+	}
+
+	return sess.Commit()
+}
+
+// Lock locks the conversation of the issue with an optional reason, so that only
+// collaborators with write access are able to comment on it. It requires Repo to
+// be loaded.
+func (issue *Issue) Lock(doer *User, reason string) error {
+	if !IsValidLockReason(reason) {
+		return ErrInvalidLockReason{args: map[string]interface{}{"reason": reason}}
+	}
+	return issue.changeLock(doer, true, reason)
+}
+
+// Unlock unlocks the conversation of the issue. It requires Repo to be loaded.
+func (issue *Issue) Unlock(doer *User) error {
+	return issue.changeLock(doer, false, "")
+}
@@ -0,0 +1,29 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidLockReason(t *testing.T) {
+	assert.True(t, IsValidLockReason(""))
+	for _, reason := range LockReasons {
+		assert.True(t, IsValidLockReason(reason), reason)
+	}
+	assert.False(t, IsValidLockReason("off topic"))
+}
+
+func TestIssue_CanComment(t *testing.T) {
+	issue := &Issue{ID: 1}
+	assert.Nil(t, issue.CanComment(false))
+	assert.Nil(t, issue.CanComment(true))
+
+	issue.IsLocked = true
+	assert.True(t, IsErrIssueLocked(issue.CanComment(false)))
+	assert.Nil(t, issue.CanComment(true))
+}
@@ -74,6 +74,10 @@ type mailerIssue struct {
 	issue *Issue
 }
 
+func (this mailerIssue) ID() int64 {
+	return this.issue.ID
+}
+
 func (this mailerIssue) MailSubject() string {
 	return this.issue.MailSubject()
 }
@@ -114,7 +118,7 @@ func mailIssueCommentToParticipants(issue *Issue, doer *User, mentions []string)
 		participants = append(participants, issue.Poster)
 	}
 
-	tos := make([]string, 0, len(watchers)) // List of email addresses
+	tos := make([]email.User, 0, len(watchers))
 	names := make([]string, 0, len(watchers))
 	for i := range watchers {
 		if watchers[i].UserID == doer.ID {
@@ -129,7 +133,7 @@ func mailIssueCommentToParticipants(issue *Issue, doer *User, mentions []string)
 			continue
 		}
 
-		tos = append(tos, to.Email)
+		tos = append(tos, NewMailerUser(to))
 		names = append(names, to.Name)
 	}
 	for i := range participants {
@@ -139,28 +143,36 @@ func mailIssueCommentToParticipants(issue *Issue, doer *User, mentions []string)
 			continue
 		}
 
-		tos = append(tos, participants[i].Email)
+		tos = append(tos, NewMailerUser(participants[i]))
 		names = append(names, participants[i].Name)
 	}
-	if issue.Assignee != nil && issue.Assignee.ID != doer.ID {
-		if !com.IsSliceContainsStr(names, issue.Assignee.Name) {
-			tos = append(tos, issue.Assignee.Email)
-			names = append(names, issue.Assignee.Name)
+	for _, assignee := range issue.Assignees {
+		if assignee.ID == doer.ID || com.IsSliceContainsStr(names, assignee.Name) {
+			continue
 		}
+
+		tos = append(tos, NewMailerUser(assignee))
+		names = append(names, assignee.Name)
 	}
 	email.SendIssueCommentMail(NewMailerIssue(issue), NewMailerRepo(issue.Repo), NewMailerUser(doer), tos)
 
 	// Mail mentioned people and exclude watchers.
 	names = append(names, doer.Name)
-	tos = make([]string, 0, len(mentions)) // list of user names.
+	tos = make([]email.User, 0, len(mentions))
 	for i := range mentions {
 		if com.IsSliceContainsStr(names, mentions[i]) {
 			continue
 		}
 
-		tos = append(tos, mentions[i])
+		u, err := GetUserByName(mentions[i])
+		if err != nil {
+			continue
+		}
+		if u.IsMailable() {
+			tos = append(tos, NewMailerUser(u))
+		}
 	}
-	email.SendIssueMentionMail(NewMailerIssue(issue), NewMailerRepo(issue.Repo), NewMailerUser(doer), GetUserEmailsByNames(tos))
+	email.SendIssueMentionMail(NewMailerIssue(issue), NewMailerRepo(issue.Repo), NewMailerUser(doer), tos)
 	return nil
 }
 
@@ -168,7 +180,7 @@ func mailIssueCommentToParticipants(issue *Issue, doer *User, mentions []string)
 // and mentioned people.
 func (issue *Issue) MailParticipants() (err error) {
 	mentions := markup.FindAllMentions(issue.Content)
-	if err = updateIssueMentions(x, issue.ID, mentions); err != nil {
+	if err = updateIssueMentions(x, issue, issue.PosterID, mentions); err != nil {
 		return fmt.Errorf("UpdateIssueMentions [%d]: %v", issue.ID, err)
 	}
 
@@ -0,0 +1,153 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"fmt"
+
+	"gogs.io/gogs/internal/conf"
+)
+
+type ErrPinnedIssuesLimitReached struct {
+	args map[string]interface{}
+}
+
+func IsErrPinnedIssuesLimitReached(err error) bool {
+	_, ok := err.(ErrPinnedIssuesLimitReached)
+	return ok
+}
+
+func (err ErrPinnedIssuesLimitReached) Error() string {
+	return fmt.Sprintf("maximum number of pinned issues has been reached: %v", err.args)
+}
+
+// IsPinned returns true if the issue is pinned above the issue list.
+func (issue *Issue) IsPinned() bool {
+	return issue.PinOrder > 0
+}
+
+// getPinnedIssues returns pinned issues or pull requests of the repository in the
+// pinned order.
+func getPinnedIssues(e Engine, repoID int64, isPull bool) ([]*Issue, error) {
+	issues := make([]*Issue, 0, conf.Repository.MaxPinnedIssues)
+	return issues, e.Where("repo_id = ? AND is_pull = ? AND pin_order > 0", repoID, isPull).Asc("pin_order").Find(&issues)
+}
+
+// GetPinnedIssues returns pinned issues or pull requests of the repository in the
+// pinned order with their attributes loaded.
+func GetPinnedIssues(repoID int64, isPull bool) ([]*Issue, error) {
+	issues, err := getPinnedIssues(x, repoID, isPull)
+	if err != nil {
+		return nil, err
+	}
+	for _, issue := range issues {
+		if err = issue.loadAttributes(x); err != nil {
+			return nil, fmt.Errorf("load attributes [issue_id: %d]: %v", issue.ID, err)
+		}
+	}
+	return issues, nil
+}
+
+// Pin pins the issue after all other pinned issues of the same kind in the
+// repository, it returns ErrPinnedIssuesLimitReached if the maximum number of pinned
+// issues is reached.
+func (issue *Issue) Pin() (err error) {
+	if issue.IsPinned() {
+		return nil
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	count, err := sess.Where("repo_id = ? AND is_pull = ? AND pin_order > 0", issue.RepoID, issue.IsPull).Count(new(Issue))
+	if err != nil {
+		return fmt.Errorf("count pinned issues: %v", err)
+	} else if count >= int64(conf.Repository.MaxPinnedIssues) {
+		return ErrPinnedIssuesLimitReached{args: map[string]interface{}{"repoID": issue.RepoID, "max": conf.Repository.MaxPinnedIssues}}
+	}
+
+	// Pin orders are kept consecutive from 1.
+	issue.PinOrder = int(count) + 1
+	if err = updateIssueCols(sess, issue, "pin_order"); err != nil {
+		return fmt.Errorf("update issue: %v", err)
+	}
+	return sess.Commit()
+}
+
+func (issue *Issue) unpin(e Engine) error {
+	if !issue.IsPinned() {
+		return nil
+	}
+
+	if _, err := e.Exec("UPDATE `issue` SET pin_order = pin_order - 1 WHERE repo_id = ? AND is_pull = ? AND pin_order > ?",
+		issue.RepoID, issue.IsPull, issue.PinOrder); err != nil {
+		return fmt.Errorf("move following pinned issues: %v", err)
+	}
+	issue.PinOrder = 0
+	return updateIssueCols(e, issue, "pin_order")
+}
+
+// Unpin removes the issue from pinned issues of the repository.
+func (issue *Issue) Unpin() (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if err = issue.unpin(sess); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// MovePin moves the pinned issue to the given position (starting from 1) among
+// pinned issues of the same kind in the repository. Positions out of range move the
+// issue to the first or the last place.
+func (issue *Issue) MovePin(position int) (err error) {
+	if !issue.IsPinned() {
+		return nil
+	}
+
+	// During the session, SQLite3 driver cannot handle retrieve objects after update something.
+	// So we have to get all pinned issues first.
+	pinned, err := getPinnedIssues(x, issue.RepoID, issue.IsPull)
+	if err != nil {
+		return fmt.Errorf("get pinned issues: %v", err)
+	}
+
+	ordered := make([]*Issue, 0, len(pinned))
+	for _, p := range pinned {
+		if p.ID != issue.ID {
+			ordered = append(ordered, p)
+		}
+	}
+	if position < 1 {
+		position = 1
+	} else if position > len(ordered)+1 {
+		position = len(ordered) + 1
+	}
+	ordered = append(ordered[:position-1], append([]*Issue{issue}, ordered[position-1:]...)...)
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	for i, p := range ordered {
+		if p.ID != issue.ID && p.PinOrder == i+1 {
+			continue
+		}
+		p.PinOrder = i + 1
+		if err = updateIssueCols(sess, p, "pin_order"); err != nil {
+			return fmt.Errorf("update issue [id: %d]: %v", p.ID, err)
+		}
+	}
+	return sess.Commit()
+}
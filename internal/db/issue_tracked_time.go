@@ -0,0 +1,298 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"xorm.io/xorm"
+
+	"gogs.io/gogs/internal/errutil"
+)
+
+// TrackedTime represents time spent by a user on an issue or a pull request.
+type TrackedTime struct {
+	ID      int64
+	IssueID int64  `xorm:"INDEX"`
+	Issue   *Issue `xorm:"-" json:"-"`
+	UserID  int64  `xorm:"INDEX"`
+	User    *User  `xorm:"-" json:"-"`
+	Seconds int64  `xorm:"NOT NULL DEFAULT 0"`
+
+	Created     time.Time `xorm:"-" json:"-"`
+	CreatedUnix int64     `xorm:"INDEX"`
+}
+
+func (t *TrackedTime) BeforeInsert() {
+	if t.CreatedUnix == 0 {
+		t.CreatedUnix = time.Now().Unix()
+	}
+}
+
+func (t *TrackedTime) AfterSet(colName string, _ xorm.Cell) {
+	switch colName {
+	case "created_unix":
+		t.Created = time.Unix(t.CreatedUnix, 0).Local()
+	}
+}
+
+func (t *TrackedTime) loadAttributes(e Engine) (err error) {
+	if t.User == nil {
+		t.User, err = getUserByID(e, t.UserID)
+		if err != nil {
+			if IsErrUserNotExist(err) {
+				t.UserID = -1
+				t.User = NewGhostUser()
+			} else {
+				return fmt.Errorf("getUserByID [%d]: %v", t.UserID, err)
+			}
+		}
+	}
+
+	if t.Issue == nil {
+		t.Issue, err = getRawIssueByID(e, t.IssueID)
+		if err != nil {
+			return fmt.Errorf("getRawIssueByID [%d]: %v", t.IssueID, err)
+		}
+	}
+	return nil
+}
+
+// APITrackedTime represents the API format of a tracked time.
+type APITrackedTime struct {
+	ID         int64     `json:"id"`
+	Created    time.Time `json:"created"`
+	Time       int64     `json:"time"` // In seconds.
+	UserID     int64     `json:"user_id"`
+	UserName   string    `json:"user_name"`
+	IssueID    int64     `json:"issue_id"`
+	IssueIndex int64     `json:"issue_number"`
+}
+
+// APIFormat returns the API format of the tracked time, it requires attributes to be loaded.
+func (t *TrackedTime) APIFormat() *APITrackedTime {
+	return &APITrackedTime{
+		ID:         t.ID,
+		Created:    t.Created,
+		Time:       t.Seconds,
+		UserID:     t.UserID,
+		UserName:   t.User.Name,
+		IssueID:    t.IssueID,
+		IssueIndex: t.Issue.Index,
+	}
+}
+
+// AddTrackedTime records given seconds spent by the user on the issue.
+func AddTrackedTime(issue *Issue, user *User, seconds int64) (*TrackedTime, error) {
+	t := &TrackedTime{
+		IssueID: issue.ID,
+		Issue:   issue,
+		UserID:  user.ID,
+		User:    user,
+		Seconds: seconds,
+	}
+	if _, err := x.Insert(t); err != nil {
+		return nil, err
+	}
+	t.Created = time.Unix(t.CreatedUnix, 0).Local()
+	return t, nil
+}
+
+// TrackedTimeOptions contains options to filter tracked times, zero values are ignored.
+type TrackedTimeOptions struct {
+	RepoID  int64
+	IssueID int64
+	UserID  int64
+	// Unix timestamps of the time range of records.
+	Since  int64
+	Before int64
+}
+
+// GetTrackedTimes returns tracked times with attributes loaded by given options,
+// ordered by the time they were created.
+func GetTrackedTimes(opts TrackedTimeOptions) ([]*TrackedTime, error) {
+	sess := x.Asc("created_unix")
+	if opts.RepoID > 0 {
+		sess.And("issue_id IN (SELECT id FROM issue WHERE repo_id = ?)", opts.RepoID)
+	}
+	if opts.IssueID > 0 {
+		sess.And("issue_id = ?", opts.IssueID)
+	}
+	if opts.UserID > 0 {
+		sess.And("user_id = ?", opts.UserID)
+	}
+	if opts.Since > 0 {
+		sess.And("created_unix >= ?", opts.Since)
+	}
+	if opts.Before > 0 {
+		sess.And("created_unix < ?", opts.Before)
+	}
+
+	times := make([]*TrackedTime, 0, 10)
+	if err := sess.Find(&times); err != nil {
+		return nil, err
+	}
+	for _, t := range times {
+		if err := t.loadAttributes(x); err != nil {
+			return nil, err
+		}
+	}
+	return times, nil
+}
+
+// TrackedTimeSummary represents the total time spent by a user on an issue.
+type TrackedTimeSummary struct {
+	User    *User
+	Seconds int64
+}
+
+// GetTrackedTimeSummaries returns the total time spent by each user on the issue,
+// ordered by the time each user started to work on the issue.
+func GetTrackedTimeSummaries(issueID int64) ([]*TrackedTimeSummary, error) {
+	times, err := GetTrackedTimes(TrackedTimeOptions{IssueID: issueID})
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]*TrackedTimeSummary, 0, len(times))
+	userSummaries := make(map[int64]*TrackedTimeSummary, len(times))
+	for _, t := range times {
+		s, ok := userSummaries[t.UserID]
+		if !ok {
+			s = &TrackedTimeSummary{User: t.User}
+			userSummaries[t.UserID] = s
+			summaries = append(summaries, s)
+		}
+		s.Seconds += t.Seconds
+	}
+	return summaries, nil
+}
+
+// TotalTrackedTime returns the total time in seconds spent on the issue.
+func (issue *Issue) TotalTrackedTime() (int64, error) {
+	return x.Where("issue_id = ?", issue.ID).SumInt(new(TrackedTime), "seconds")
+}
+
+// Stopwatch represents a running timer of a user on an issue or a pull request.
+type Stopwatch struct {
+	ID      int64
+	IssueID int64 `xorm:"INDEX UNIQUE(s)"`
+	UserID  int64 `xorm:"INDEX UNIQUE(s)"`
+
+	Created     time.Time `xorm:"-" json:"-"`
+	CreatedUnix int64
+}
+
+func (s *Stopwatch) BeforeInsert() {
+	s.CreatedUnix = time.Now().Unix()
+}
+
+func (s *Stopwatch) AfterSet(colName string, _ xorm.Cell) {
+	switch colName {
+	case "created_unix":
+		s.Created = time.Unix(s.CreatedUnix, 0).Local()
+	}
+}
+
+// Seconds returns the number of seconds elapsed since the stopwatch was started.
+func (s *Stopwatch) Seconds() int64 {
+	return time.Now().Unix() - s.CreatedUnix
+}
+
+var _ errutil.NotFound = (*ErrStopwatchNotExist)(nil)
+
+type ErrStopwatchNotExist struct {
+	args map[string]interface{}
+}
+
+func IsErrStopwatchNotExist(err error) bool {
+	_, ok := err.(ErrStopwatchNotExist)
+	return ok
+}
+
+func (err ErrStopwatchNotExist) Error() string {
+	return fmt.Sprintf("stopwatch does not exist: %v", err.args)
+}
+
+func (ErrStopwatchNotExist) NotFound() bool {
+	return true
+}
+
+// GetStopwatch returns the running stopwatch of the user on the issue.
+func GetStopwatch(issueID, userID int64) (*Stopwatch, error) {
+	s := new(Stopwatch)
+	has, err := x.Where("issue_id = ? AND user_id = ?", issueID, userID).Get(s)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrStopwatchNotExist{args: map[string]interface{}{"issueID": issueID, "userID": userID}}
+	}
+	return s, nil
+}
+
+// StartStopwatch starts a stopwatch of the user on the issue, it does nothing if
+// there is already one running.
+func (issue *Issue) StartStopwatch(user *User) error {
+	_, err := GetStopwatch(issue.ID, user.ID)
+	if err == nil {
+		return nil
+	} else if !IsErrStopwatchNotExist(err) {
+		return err
+	}
+
+	_, err = x.Insert(&Stopwatch{
+		IssueID: issue.ID,
+		UserID:  user.ID,
+	})
+	return err
+}
+
+// StopStopwatch stops the running stopwatch of the user on the issue and records
+// the elapsed time.
+func (issue *Issue) StopStopwatch(user *User) (*TrackedTime, error) {
+	s, err := GetStopwatch(issue.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	// Make sure the stopwatch is not stopped concurrently.
+	affected, err := sess.ID(s.ID).Delete(new(Stopwatch))
+	if err != nil {
+		return nil, fmt.Errorf("delete stopwatch: %v", err)
+	} else if affected == 0 {
+		return nil, ErrStopwatchNotExist{args: map[string]interface{}{"issueID": issue.ID, "userID": user.ID}}
+	}
+
+	t := &TrackedTime{
+		IssueID: issue.ID,
+		Issue:   issue,
+		UserID:  user.ID,
+		User:    user,
+		Seconds: s.Seconds(),
+	}
+	if _, err = sess.Insert(t); err != nil {
+		return nil, fmt.Errorf("insert tracked time: %v", err)
+	}
+	t.Created = time.Unix(t.CreatedUnix, 0).Local()
+
+	return t, sess.Commit()
+}
+
+// CancelStopwatch discards the running stopwatch of the user on the issue without
+// recording the elapsed time.
+func (issue *Issue) CancelStopwatch(user *User) error {
+	_, err := x.Delete(&Stopwatch{
+		IssueID: issue.ID,
+		UserID:  user.ID,
+	})
+	return err
+}
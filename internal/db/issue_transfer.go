@@ -0,0 +1,227 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"fmt"
+
+	"gogs.io/gogs/internal/errutil"
+)
+
+// IssueRedirect represents the former index of an issue in the repository that the
+// issue has been transferred from.
+type IssueRedirect struct {
+	ID      int64
+	RepoID  int64 `xorm:"UNIQUE(s)"`
+	Index   int64 `xorm:"UNIQUE(s)"`
+	IssueID int64 `xorm:"INDEX"`
+}
+
+var _ errutil.NotFound = (*ErrIssueRedirectNotExist)(nil)
+
+type ErrIssueRedirectNotExist struct {
+	args map[string]interface{}
+}
+
+func IsErrIssueRedirectNotExist(err error) bool {
+	_, ok := err.(ErrIssueRedirectNotExist)
+	return ok
+}
+
+func (err ErrIssueRedirectNotExist) Error() string {
+	return fmt.Sprintf("issue redirect does not exist: %v", err.args)
+}
+
+func (ErrIssueRedirectNotExist) NotFound() bool {
+	return true
+}
+
+// GetRedirectedIssue returns the issue that was transferred from given index of the
+// repository, the issue may have been transferred more than once.
+func GetRedirectedIssue(repoID, index int64) (*Issue, error) {
+	redirect := &IssueRedirect{
+		RepoID: repoID,
+		Index:  index,
+	}
+	has, err := x.Get(redirect)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrIssueRedirectNotExist{args: map[string]interface{}{"repoID": repoID, "index": index}}
+	}
+	return GetIssueByID(redirect.IssueID)
+}
+
+type ErrIssueTransferNotAllowed struct {
+	args map[string]interface{}
+}
+
+func IsErrIssueTransferNotAllowed(err error) bool {
+	_, ok := err.(ErrIssueTransferNotAllowed)
+	return ok
+}
+
+func (err ErrIssueTransferNotAllowed) Error() string {
+	return fmt.Sprintf("issue cannot be transferred: %v", err.args)
+}
+
+// TransferTo moves the issue with its comments, attachments and mentions to another
+// repository of the same owner. The issue is given the next index in the target
+// repository, labels and the milestone are replaced by the ones with same names in
+// the target repository if any, and assignees who have no access to the target
+// repository are removed. The former index redirects to the issue afterwards. It
+// requires Repo, Labels and Milestone to be loaded.
+func (issue *Issue) TransferTo(doer *User, target *Repository) (err error) {
+	source := issue.Repo
+	switch {
+	case issue.IsPull:
+		return ErrIssueTransferNotAllowed{args: map[string]interface{}{"issueID": issue.ID, "reason": "pull request"}}
+	case target.ID == source.ID:
+		return ErrIssueTransferNotAllowed{args: map[string]interface{}{"issueID": issue.ID, "reason": "same repository"}}
+	case target.OwnerID != source.OwnerID:
+		return ErrIssueTransferNotAllowed{args: map[string]interface{}{"issueID": issue.ID, "reason": "different owner"}}
+	case !target.EnableIssues || target.EnableExternalTracker:
+		return ErrIssueTransferNotAllowed{args: map[string]interface{}{"issueID": issue.ID, "reason": "issues disabled"}}
+	}
+
+	oldRef := fmt.Sprintf("%s#%d", source.FullName(), issue.Index)
+	target.Owner = source.Owner
+
+	// During the session, SQLite3 driver cannot handle retrieve objects after update something.
+	// So we have to get all needed labels and milestones first.
+	targetLabels := make([]*Label, 0, 10)
+	if err = x.Where("repo_id = ?", target.ID).Find(&targetLabels); err != nil {
+		return fmt.Errorf("find labels of target repository: %v", err)
+	}
+	targetLabelsByName := make(map[string]*Label, len(targetLabels))
+	for _, l := range targetLabels {
+		targetLabelsByName[l.Name] = l
+	}
+
+	var targetMilestoneID int64
+	if issue.Milestone != nil {
+		m := new(Milestone)
+		has, err := x.Where("repo_id = ? AND name = ?", target.ID, issue.Milestone.Name).Get(m)
+		if err != nil {
+			return fmt.Errorf("get milestone of target repository: %v", err)
+		} else if has {
+			targetMilestoneID = m.ID
+		}
+	}
+
+	assigneeIDs, err := getAssigneeIDsByIssueID(x, issue.ID)
+	if err != nil {
+		return fmt.Errorf("get assignee IDs: %v", err)
+	}
+	validIDs, err := validAssigneeIDs(x, target, assigneeIDs)
+	if err != nil {
+		return fmt.Errorf("validate assignees: %v", err)
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	// Replace labels by the ones with same names in the target repository.
+	oldLabels := append([]*Label{}, issue.Labels...)
+	for _, label := range oldLabels {
+		if err = deleteIssueLabel(sess, issue, label); err != nil {
+			return fmt.Errorf("delete issue label [%d]: %v", label.ID, err)
+		}
+	}
+	for _, label := range oldLabels {
+		l, ok := targetLabelsByName[label.Name]
+		if !ok {
+			continue
+		}
+		if err = newIssueLabel(sess, issue, l); err != nil {
+			return fmt.Errorf("new issue label [%d]: %v", l.ID, err)
+		}
+	}
+
+	// Detach the milestone while the issue is still in the source repository.
+	if issue.MilestoneID > 0 {
+		oldMilestoneID := issue.MilestoneID
+		issue.MilestoneID = 0
+		if err = changeMilestoneAssign(sess, issue, oldMilestoneID); err != nil {
+			return fmt.Errorf("detach milestone: %v", err)
+		}
+	}
+
+	if _, err = sess.Insert(&IssueRedirect{
+		RepoID:  source.ID,
+		Index:   issue.Index,
+		IssueID: issue.ID,
+	}); err != nil {
+		return fmt.Errorf("insert issue redirect: %v", err)
+	}
+
+	if err = issue.unpin(sess); err != nil {
+		return fmt.Errorf("unpin: %v", err)
+	}
+
+	issue.RepoID = target.ID
+	issue.Repo = target
+	issue.Index = target.NextIssueIndex()
+	issue.MilestoneID = targetMilestoneID
+	if err = changeMilestoneAssign(sess, issue, 0); err != nil {
+		return fmt.Errorf("attach milestone: %v", err)
+	}
+
+	if _, err = createComment(sess, &CreateCommentOptions{
+		Type:    COMMENT_TYPE_TRANSFER,
+		Doer:    doer,
+		Repo:    target,
+		Issue:   issue,
+		Content: oldRef,
+	}); err != nil {
+		return fmt.Errorf("create comment: %v", err)
+	}
+
+	if _, err = sess.Exec("UPDATE `repository` SET num_issues = num_issues - 1, num_transferred_issues = num_transferred_issues + 1 WHERE id = ?", source.ID); err != nil {
+		return err
+	} else if _, err = sess.Exec("UPDATE `repository` SET num_issues = num_issues + 1 WHERE id = ?", target.ID); err != nil {
+		return err
+	}
+	if issue.IsClosed {
+		if _, err = sess.Exec("UPDATE `repository` SET num_closed_issues = num_closed_issues - 1 WHERE id = ?", source.ID); err != nil {
+			return err
+		} else if _, err = sess.Exec("UPDATE `repository` SET num_closed_issues = num_closed_issues + 1 WHERE id = ?", target.ID); err != nil {
+			return err
+		}
+	}
+
+	// Keep mentions, notifications and other issue-user relations with the issue.
+	if _, err = sess.Exec("UPDATE `issue_user` SET repo_id = ? WHERE issue_id = ?", target.ID, issue.ID); err != nil {
+		return fmt.Errorf("update issue users: %v", err)
+	} else if _, err = sess.Exec("UPDATE `notification` SET repo_id = ? WHERE issue_id = ?", target.ID, issue.ID); err != nil {
+		return fmt.Errorf("update notifications: %v", err)
+	}
+
+	if len(validIDs) < len(assigneeIDs) {
+		if len(validIDs) > 0 {
+			_, err = sess.Where("issue_id = ?", issue.ID).NotIn("assignee_id", validIDs).Delete(new(IssueAssignee))
+		} else {
+			_, err = sess.Where("issue_id = ?", issue.ID).Delete(new(IssueAssignee))
+		}
+		if err != nil {
+			return fmt.Errorf("delete issue assignees: %v", err)
+		} else if err = syncIssueAssignees(sess, issue); err != nil {
+			return fmt.Errorf("sync issue assignees: %v", err)
+		}
+	}
+
+	if err = sess.Commit(); err != nil {
+		return err
+	}
+
+	target.NumIssues++
+	source.NumIssues--
+	source.NumTransferredIssues++
+	updateIssueIndexer(issue.ID)
+	return nil
+}
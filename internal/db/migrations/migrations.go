@@ -68,6 +68,8 @@ var migrations = []Migration{
 	NewMigration("store long text in repository description field", updateRepositoryDescriptionField),
 	// v18 -> v19:v0.11.55
 	NewMigration("clean unlinked webhook and hook_tasks", cleanUnlinkedWebhookAndHookTasks),
+	// v19 -> v20:v0.12.0
+	NewMigration("migrate issue assignees to issue_assignee table", migrateIssueAssignees),
 }
 
 // Migrate database to current version
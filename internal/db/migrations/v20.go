@@ -0,0 +1,33 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"xorm.io/xorm"
+)
+
+func migrateIssueAssignees(x *xorm.Engine) error {
+	exist, err := x.IsTableExist("issue")
+	if err != nil {
+		return fmt.Errorf("IsTableExist: %v", err)
+	} else if !exist {
+		return nil
+	}
+
+	type IssueAssignee struct {
+		ID         int64
+		IssueID    int64 `xorm:"INDEX UNIQUE(s)"`
+		AssigneeID int64 `xorm:"INDEX UNIQUE(s)"`
+	}
+	if err = x.Sync2(new(IssueAssignee)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+
+	_, err = x.Exec(`INSERT INTO issue_assignee (issue_id, assignee_id)
+SELECT id, assignee_id FROM issue WHERE assignee_id > 0 AND id NOT IN (SELECT issue_id FROM issue_assignee)`)
+	return err
+}
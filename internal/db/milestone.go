@@ -30,6 +30,8 @@ type Milestone struct {
 	NumOpenIssues   int  `xorm:"-" json:"-"`
 	Completeness    int  // Percentage(1-100).
 	IsOverDue       bool `xorm:"-" json:"-"`
+	// Total time in seconds tracked on issues and pull requests of the milestone.
+	TotalTrackedTime int64 `xorm:"-" json:"-"`
 
 	DeadlineString string    `xorm:"-" json:"-"`
 	Deadline       time.Time `xorm:"-" json:"-"`
@@ -113,6 +115,14 @@ func (m *Milestone) CountIssues(isClosed, includePulls bool) int64 {
 	return count
 }
 
+// CountTrackedTime returns the total time in seconds tracked on issues and pull
+// requests of the milestone.
+func (m *Milestone) CountTrackedTime() int64 {
+	total, _ := x.Where("issue_id IN (SELECT id FROM issue WHERE milestone_id = ?)", m.ID).
+		SumInt(new(TrackedTime), "seconds")
+	return total
+}
+
 // NewMilestone creates new milestone of repository.
 func NewMilestone(m *Milestone) (err error) {
 	sess := x.NewSession()
@@ -50,11 +50,11 @@ func init() {
 	tables = append(tables,
 		new(User), new(PublicKey), new(AccessToken), new(TwoFactor), new(TwoFactorRecoveryCode),
 		new(Repository), new(DeployKey), new(Collaboration), new(Access), new(Upload),
-		new(Watch), new(Star), new(Follow), new(Action),
-		new(Issue), new(PullRequest), new(Comment), new(Attachment), new(IssueUser),
-		new(Label), new(IssueLabel), new(Milestone),
+		new(Watch), new(Star), new(Follow), new(Action), new(Notification),
+		new(Issue), new(PullRequest), new(Review), new(ReviewRequest), new(Comment), new(Attachment), new(IssueUser), new(IssueAssignee),
+		new(IssueDependency), new(TrackedTime), new(Stopwatch), new(Reaction), new(IssueRedirect), new(Label), new(IssueLabel), new(Milestone),
 		new(Mirror), new(Release), new(LoginSource), new(Webhook), new(HookTask),
-		new(ProtectBranch), new(ProtectBranchWhitelist),
+		new(ProtectBranch), new(ProtectBranchWhitelist), new(CommitStatus),
 		new(Team), new(OrgUser), new(TeamUser), new(TeamRepo),
 		new(Notice), new(EmailAddress))
 
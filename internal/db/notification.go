@@ -0,0 +1,398 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/unknwon/com"
+	"xorm.io/xorm"
+
+	api "github.com/gogs/go-gogs-client"
+
+	"gogs.io/gogs/internal/conf"
+	"gogs.io/gogs/internal/errutil"
+)
+
+type NotificationStatus int
+
+const (
+	NOTIFICATION_STATUS_UNREAD NotificationStatus = iota + 1
+	NOTIFICATION_STATUS_READ
+	NOTIFICATION_STATUS_PINNED
+)
+
+var notificationStatusNames = map[NotificationStatus]string{
+	NOTIFICATION_STATUS_UNREAD: "unread",
+	NOTIFICATION_STATUS_READ:   "read",
+	NOTIFICATION_STATUS_PINNED: "pinned",
+}
+
+func (s NotificationStatus) String() string {
+	return notificationStatusNames[s]
+}
+
+// ParseNotificationStatus returns the notification status of given name, it returns
+// zero if the name is not valid.
+func ParseNotificationStatus(name string) NotificationStatus {
+	for status := range notificationStatusNames {
+		if notificationStatusNames[status] == name {
+			return status
+		}
+	}
+	return 0
+}
+
+type NotificationReason int
+
+const (
+	NOTIFICATION_REASON_WATCHING NotificationReason = iota + 1
+	NOTIFICATION_REASON_MENTION
+	NOTIFICATION_REASON_ASSIGN
+)
+
+func (r NotificationReason) String() string {
+	switch r {
+	case NOTIFICATION_REASON_WATCHING:
+		return "watching"
+	case NOTIFICATION_REASON_MENTION:
+		return "mention"
+	case NOTIFICATION_REASON_ASSIGN:
+		return "assign"
+	}
+	return ""
+}
+
+// Notification represents a thread of an issue or a pull request in the inbox of a
+// user. There is at most one notification per user and issue, new activity on the
+// issue marks the notification as unread again unless it is pinned.
+type Notification struct {
+	ID      int64
+	UserID  int64              `xorm:"INDEX UNIQUE(s) NOT NULL"`
+	IssueID int64              `xorm:"UNIQUE(s) NOT NULL"`
+	Issue   *Issue             `xorm:"-" json:"-"`
+	RepoID  int64              `xorm:"INDEX NOT NULL"`
+	Repo    *Repository        `xorm:"-" json:"-"`
+	Status  NotificationStatus `xorm:"INDEX NOT NULL"`
+	Reason  NotificationReason `xorm:"NOT NULL"` // The reason of latest activity.
+
+	Created     time.Time `xorm:"-" json:"-"`
+	CreatedUnix int64
+	Updated     time.Time `xorm:"-" json:"-"`
+	UpdatedUnix int64     `xorm:"INDEX"`
+}
+
+func (n *Notification) BeforeInsert() {
+	n.CreatedUnix = time.Now().Unix()
+	n.UpdatedUnix = n.CreatedUnix
+}
+
+func (n *Notification) BeforeUpdate() {
+	n.UpdatedUnix = time.Now().Unix()
+}
+
+func (n *Notification) AfterSet(colName string, _ xorm.Cell) {
+	switch colName {
+	case "created_unix":
+		n.Created = time.Unix(n.CreatedUnix, 0).Local()
+	case "updated_unix":
+		n.Updated = time.Unix(n.UpdatedUnix, 0).Local()
+	}
+}
+
+func (n *Notification) loadAttributes(e Engine) (err error) {
+	if n.Issue == nil {
+		n.Issue, err = getRawIssueByID(e, n.IssueID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if n.Repo == nil {
+		n.Repo, err = getRepositoryByID(e, n.RepoID)
+		if err != nil {
+			return err
+		}
+	}
+	n.Issue.Repo = n.Repo
+	return nil
+}
+
+func (n *Notification) LoadAttributes() error {
+	return n.loadAttributes(x)
+}
+
+// IsUnread returns true if the notification has not been read.
+func (n *Notification) IsUnread() bool {
+	return n.Status == NOTIFICATION_STATUS_UNREAD
+}
+
+// IsPinned returns true if the notification is pinned.
+func (n *Notification) IsPinned() bool {
+	return n.Status == NOTIFICATION_STATUS_PINNED
+}
+
+// APINotificationSubject represents the issue or the pull request of a notification
+// in the API format.
+type APINotificationSubject struct {
+	Title   string `json:"title"`
+	Type    string `json:"type"`
+	State   string `json:"state"`
+	URL     string `json:"url"`
+	HTMLURL string `json:"html_url"`
+}
+
+// APINotification represents the API format of a notification.
+type APINotification struct {
+	ID         int64                   `json:"id"`
+	Repository *api.Repository         `json:"repository"`
+	Subject    *APINotificationSubject `json:"subject"`
+	Reason     string                  `json:"reason"`
+	Unread     bool                    `json:"unread"`
+	Pinned     bool                    `json:"pinned"`
+	Updated    time.Time               `json:"updated_at"`
+}
+
+// APIFormat returns the API format of the notification, it requires Issue and Repo
+// to be loaded.
+func (n *Notification) APIFormat() *APINotification {
+	subjectType := "Issue"
+	if n.Issue.IsPull {
+		subjectType = "PullRequest"
+	}
+	state := api.STATE_OPEN
+	if n.Issue.IsClosed {
+		state = api.STATE_CLOSED
+	}
+
+	return &APINotification{
+		ID:         n.ID,
+		Repository: n.Repo.APIFormat(nil),
+		Subject: &APINotificationSubject{
+			Title:   n.Issue.Title,
+			Type:    subjectType,
+			State:   string(state),
+			URL:     fmt.Sprintf("%sapi/v1/repos/%s/issues/%d", conf.Server.ExternalURL, n.Repo.FullName(), n.Issue.Index),
+			HTMLURL: n.Issue.HTMLURL(),
+		},
+		Reason:  n.Reason.String(),
+		Unread:  n.IsUnread(),
+		Pinned:  n.IsPinned(),
+		Updated: n.Updated,
+	}
+}
+
+var _ errutil.NotFound = (*ErrNotificationNotExist)(nil)
+
+type ErrNotificationNotExist struct {
+	args map[string]interface{}
+}
+
+func IsErrNotificationNotExist(err error) bool {
+	_, ok := err.(ErrNotificationNotExist)
+	return ok
+}
+
+func (err ErrNotificationNotExist) Error() string {
+	return fmt.Sprintf("notification does not exist: %v", err.args)
+}
+
+func (ErrNotificationNotExist) NotFound() bool {
+	return true
+}
+
+// GetNotificationByID returns the notification with given ID that belongs to the user.
+func GetNotificationByID(userID, id int64) (*Notification, error) {
+	n := new(Notification)
+	has, err := x.Where("id = ? AND user_id = ?", id, userID).Get(n)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrNotificationNotExist{args: map[string]interface{}{"userID": userID, "notificationID": id}}
+	}
+	return n, n.LoadAttributes()
+}
+
+// NotificationsOptions contains options to list notifications of a user.
+type NotificationsOptions struct {
+	UserID   int64
+	Statuses []NotificationStatus // All statuses if empty.
+	Page     int
+	PageSize int
+}
+
+func (opts *NotificationsOptions) buildSession() *xorm.Session {
+	sess := x.Where("user_id = ?", opts.UserID)
+	if len(opts.Statuses) > 0 {
+		sess.In("status", opts.Statuses)
+	}
+	return sess
+}
+
+// Notifications returns a page of notifications of the user ordered by the time of
+// latest activity, notifications of issues that no longer exist are skipped.
+func Notifications(opts *NotificationsOptions) ([]*Notification, error) {
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = conf.UI.IssuePagingNum
+	}
+
+	notifications := make([]*Notification, 0, opts.PageSize)
+	if err := opts.buildSession().
+		Desc("updated_unix").
+		Limit(opts.PageSize, (opts.Page-1)*opts.PageSize).
+		Find(&notifications); err != nil {
+		return nil, err
+	}
+
+	repos := make(map[int64]*Repository)
+	valid := notifications[:0]
+	for _, n := range notifications {
+		n.Repo = repos[n.RepoID]
+		if err := n.LoadAttributes(); err != nil {
+			if IsErrIssueNotExist(err) || IsErrRepoNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("load attributes [notification_id: %d]: %v", n.ID, err)
+		}
+		repos[n.RepoID] = n.Repo
+		valid = append(valid, n)
+	}
+	return valid, nil
+}
+
+// CountNotifications returns the number of notifications of the user in given statuses.
+func CountNotifications(userID int64, statuses ...NotificationStatus) int64 {
+	opts := &NotificationsOptions{
+		UserID:   userID,
+		Statuses: statuses,
+	}
+	count, _ := opts.buildSession().Count(new(Notification))
+	return count
+}
+
+// CountUnreadNotifications returns the number of unread notifications of the user.
+func CountUnreadNotifications(userID int64) int64 {
+	return CountNotifications(userID, NOTIFICATION_STATUS_UNREAD)
+}
+
+// SetStatus changes status of the notification.
+func (n *Notification) SetStatus(status NotificationStatus) error {
+	if _, ok := notificationStatusNames[status]; !ok {
+		return fmt.Errorf("invalid notification status: %d", status)
+	} else if n.Status == status {
+		return nil
+	}
+
+	n.Status = status
+	_, err := x.ID(n.ID).Cols("status").Update(n)
+	return err
+}
+
+// MarkNotificationsRead marks all unread notifications of the user that are updated
+// no later than given time as read, pinned notifications are not affected.
+func MarkNotificationsRead(userID int64, before time.Time) error {
+	_, err := x.Exec("UPDATE `notification` SET status = ? WHERE user_id = ? AND status = ? AND updated_unix <= ?",
+		NOTIFICATION_STATUS_READ, userID, NOTIFICATION_STATUS_UNREAD, before.Unix())
+	return err
+}
+
+// MarkIssueNotificationRead marks the notification of the issue for the user as read
+// if it is unread.
+func MarkIssueNotificationRead(userID, issueID int64) error {
+	_, err := x.Exec("UPDATE `notification` SET status = ? WHERE user_id = ? AND issue_id = ? AND status = ?",
+		NOTIFICATION_STATUS_READ, userID, issueID, NOTIFICATION_STATUS_UNREAD)
+	return err
+}
+
+// notifyIssueUsers creates or updates notifications of the issue for given users with
+// the reason, users who do not have read access to the repository are skipped.
+func notifyIssueUsers(e Engine, issue *Issue, userIDs []int64, reason NotificationReason) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	repo, err := getRepositoryByID(e, issue.RepoID)
+	if err != nil {
+		return fmt.Errorf("get repository by ID: %v", err)
+	}
+
+	for _, userID := range userIDs {
+		has, err := hasAccess(e, userID, repo, AccessModeRead)
+		if err != nil {
+			return fmt.Errorf("check access [user_id: %d]: %v", userID, err)
+		} else if !has {
+			continue
+		}
+
+		n := &Notification{
+			UserID:  userID,
+			IssueID: issue.ID,
+		}
+		has, err = e.Get(n)
+		if err != nil {
+			return fmt.Errorf("get notification [user_id: %d]: %v", userID, err)
+		}
+
+		n.RepoID = issue.RepoID
+		n.Reason = reason
+		if !has {
+			n.Status = NOTIFICATION_STATUS_UNREAD
+			if _, err = e.Insert(n); err != nil {
+				return fmt.Errorf("insert notification [user_id: %d]: %v", userID, err)
+			}
+			continue
+		}
+
+		if n.Status != NOTIFICATION_STATUS_PINNED {
+			n.Status = NOTIFICATION_STATUS_UNREAD
+		}
+		if _, err = e.ID(n.ID).Cols("repo_id", "status", "reason", "updated_unix").Update(n); err != nil {
+			return fmt.Errorf("update notification [id: %d]: %v", n.ID, err)
+		}
+	}
+	return nil
+}
+
+// isIssueAction returns true if the action is about an issue or a pull request, and
+// the content of the action starts with the index of the issue.
+func (a *Action) isIssueAction() bool {
+	switch a.OpType {
+	case ACTION_CREATE_ISSUE, ACTION_CREATE_PULL_REQUEST, ACTION_COMMENT_ISSUE, ACTION_MERGE_PULL_REQUEST,
+		ACTION_CLOSE_ISSUE, ACTION_REOPEN_ISSUE, ACTION_CLOSE_PULL_REQUEST, ACTION_REOPEN_PULL_REQUEST:
+		return true
+	}
+	return false
+}
+
+// notifyWatchersByAction creates or updates notifications for watchers of the
+// repository if the action is about an issue or a pull request.
+func notifyWatchersByAction(e Engine, act *Action, watchers []*Watch) error {
+	if !act.isIssueAction() {
+		return nil
+	}
+
+	issue := &Issue{
+		RepoID: act.RepoID,
+		Index:  com.StrTo(act.GetIssueInfos()[0]).MustInt64(),
+	}
+	has, err := e.Get(issue)
+	if err != nil {
+		return fmt.Errorf("get issue by index: %v", err)
+	} else if !has {
+		return nil
+	}
+
+	userIDs := make([]int64, 0, len(watchers))
+	for i := range watchers {
+		if watchers[i].UserID != act.ActUserID {
+			userIDs = append(userIDs, watchers[i].UserID)
+		}
+	}
+	return notifyIssueUsers(e, issue, userIDs, NOTIFICATION_REASON_WATCHING)
+}
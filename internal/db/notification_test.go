@@ -0,0 +1,30 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNotificationStatus(t *testing.T) {
+	for _, status := range []NotificationStatus{
+		NOTIFICATION_STATUS_UNREAD,
+		NOTIFICATION_STATUS_READ,
+		NOTIFICATION_STATUS_PINNED,
+	} {
+		assert.Equal(t, status, ParseNotificationStatus(status.String()))
+	}
+	assert.Zero(t, ParseNotificationStatus(""))
+	assert.Zero(t, ParseNotificationStatus("archived"))
+}
+
+func TestAction_isIssueAction(t *testing.T) {
+	assert.True(t, (&Action{OpType: ACTION_COMMENT_ISSUE}).isIssueAction())
+	assert.True(t, (&Action{OpType: ACTION_MERGE_PULL_REQUEST}).isIssueAction())
+	assert.False(t, (&Action{OpType: ACTION_COMMIT_REPO}).isIssueAction())
+	assert.False(t, (&Action{OpType: ACTION_STAR_REPO}).isIssueAction())
+}
@@ -10,6 +10,7 @@ import (
 	"os"
 	"strings"
 
+	log "unknwon.dev/clog/v2"
 	"xorm.io/builder"
 	"xorm.io/xorm"
 )
@@ -167,7 +168,14 @@ func CreateOrganization(org, owner *User) (err error) {
 		return fmt.Errorf("create directory: %v", err)
 	}
 
-	return sess.Commit()
+	if err = sess.Commit(); err != nil {
+		return err
+	}
+
+	if err = sendOrganizationWebhook(org, HOOK_ORGANIZATION_CREATED, owner); err != nil {
+		log.Error("sendOrganizationWebhook [org_id: %d]: %v", org.ID, err)
+	}
+	return nil
 }
 
 // GetOrgByName returns organization by given name.
@@ -67,6 +67,15 @@ type PullRequest struct {
 	Merger         *User     `xorm:"-" json:"-"`
 	Merged         time.Time `xorm:"-" json:"-"`
 	MergedUnix     int64
+
+	// Draft pull requests are work in progress and cannot be merged.
+	IsDraft bool `xorm:"NOT NULL DEFAULT false"`
+
+	// Merge style to be used when the pull request is merged automatically,
+	// empty means auto-merge is not scheduled.
+	AutoMergeStyle             MergeStyle `xorm:"VARCHAR(25)"`
+	AutoMergeCommitDescription string     `xorm:"TEXT"`
+	AutoMergerID               int64
 }
 
 func (pr *PullRequest) BeforeUpdate() {
@@ -184,17 +193,188 @@ func (pr *PullRequest) CanAutoMerge() bool {
 	return pr.Status == PULL_REQUEST_STATUS_MERGEABLE
 }
 
+// HeadCommitID returns the latest commit ID of the head branch.
+func (pr *PullRequest) HeadCommitID() (string, error) {
+	if err := pr.LoadAttributes(); err != nil {
+		return "", fmt.Errorf("load attributes: %v", err)
+	} else if pr.HeadRepo == nil {
+		return "", fmt.Errorf("head repository [%d] does not exist", pr.HeadRepoID)
+	}
+
+	headGitRepo, err := git.Open(pr.HeadRepo.RepoPath())
+	if err != nil {
+		return "", fmt.Errorf("open repository: %v", err)
+	}
+	return headGitRepo.BranchCommitID(pr.HeadBranch)
+}
+
+// MergeRequirements represents the state of requirements of branch protection
+// for merging a pull request into its base branch.
+type MergeRequirements struct {
+	RequiredApprovals int
+	Approvals         int
+	// Contexts of required status checks which have not succeeded yet.
+	PendingStatusChecks []string
+	// Changed files whose code owners have not approved the pull request yet.
+	UnapprovedCodeOwnerFiles []string
+	// Whether the doer is allowed to merge even if requirements are not met.
+	CanOverride bool
+}
+
+// IsSatisfied returns true if all requirements are met.
+func (r *MergeRequirements) IsSatisfied() bool {
+	return r.Approvals >= r.RequiredApprovals &&
+		len(r.PendingStatusChecks) == 0 &&
+		len(r.UnapprovedCodeOwnerFiles) == 0
+}
+
+// CanMerge returns true if all requirements are met or the doer is allowed to override.
+func (r *MergeRequirements) CanMerge() bool {
+	return r.IsSatisfied() || r.CanOverride
+}
+
+// GetMergeRequirements returns the state of requirements for the doer to merge the pull request.
+func (pr *PullRequest) GetMergeRequirements(doer *User) (*MergeRequirements, error) {
+	reqs := new(MergeRequirements)
+	protectBranch, err := GetProtectBranchOfRepoByName(pr.BaseRepoID, pr.BaseBranch)
+	if err != nil {
+		if IsErrBranchNotExist(err) {
+			return reqs, nil
+		}
+		return nil, fmt.Errorf("get protect branch of repository by name: %v", err)
+	} else if !protectBranch.Protected {
+		return reqs, nil
+	}
+
+	if err = pr.LoadAttributes(); err != nil {
+		return nil, fmt.Errorf("load attributes: %v", err)
+	}
+
+	reqs.RequiredApprovals = protectBranch.RequiredApprovals
+	if reqs.RequiredApprovals > 0 || protectBranch.RequireCodeOwnerApproval {
+		reviews, err := GetLatestReviewsByIssueID(pr.IssueID)
+		if err != nil {
+			return nil, fmt.Errorf("get latest reviews by issue ID: %v", err)
+		}
+
+		// Only approvals from users who have write access are counted.
+		approvers := make([]int64, 0, len(reviews))
+		for _, r := range reviews {
+			if r.IsApproved() && r.Reviewer.IsWriterOfRepo(pr.BaseRepo) {
+				reqs.Approvals++
+				approvers = append(approvers, r.ReviewerID)
+			}
+		}
+
+		if protectBranch.RequireCodeOwnerApproval {
+			owners, err := pr.codeOwnersOfChangedFiles()
+			if err != nil {
+				return nil, fmt.Errorf("get code owners of changed files: %v", err)
+			}
+			reqs.UnapprovedCodeOwnerFiles = unapprovedCodeOwnerFiles(owners, approvers)
+		}
+	}
+
+	contexts := protectBranch.StatusCheckContexts()
+	if len(contexts) > 0 {
+		headCommitID, err := pr.HeadCommitID()
+		if err != nil {
+			return nil, fmt.Errorf("get head commit ID: %v", err)
+		}
+		statuses, err := GetLatestCommitStatuses(headCommitID, pr.BaseRepoID, pr.HeadRepoID)
+		if err != nil {
+			return nil, fmt.Errorf("get latest commit statuses: %v", err)
+		}
+
+		succeeded := make(map[string]bool, len(statuses))
+		for _, s := range statuses {
+			succeeded[s.Context] = s.IsSuccess()
+		}
+		for _, context := range contexts {
+			if !succeeded[context] {
+				reqs.PendingStatusChecks = append(reqs.PendingStatusChecks, context)
+			}
+		}
+	}
+
+	reqs.CanOverride = doer != nil &&
+		protectBranch.EnableWhitelist && protectBranch.WhitelistCanOverride &&
+		IsUserInProtectBranchWhitelist(pr.BaseRepoID, doer.ID, pr.BaseBranch)
+	return reqs, nil
+}
+
+type ErrMergeRequirementsNotMet struct {
+	args map[string]interface{}
+}
+
+func IsErrMergeRequirementsNotMet(err error) bool {
+	_, ok := err.(ErrMergeRequirementsNotMet)
+	return ok
+}
+
+func (err ErrMergeRequirementsNotMet) Error() string {
+	return fmt.Sprintf("merge requirements of protected branch are not met: %v", err.args)
+}
+
 // MergeStyle represents the approach to merge commits into base branch.
 type MergeStyle string
 
 const (
-	MERGE_STYLE_REGULAR MergeStyle = "create_merge_commit"
-	MERGE_STYLE_REBASE  MergeStyle = "rebase_before_merging"
+	MERGE_STYLE_REGULAR      MergeStyle = "create_merge_commit"
+	MERGE_STYLE_REBASE       MergeStyle = "rebase_before_merging"
+	MERGE_STYLE_SQUASH       MergeStyle = "squash"
+	MERGE_STYLE_FAST_FORWARD MergeStyle = "fast_forward_only"
 )
 
+// AllowsMergeStyle returns true if the repository allows pull requests
+// to be merged with given style.
+func (repo *Repository) AllowsMergeStyle(style MergeStyle) bool {
+	switch style {
+	case MERGE_STYLE_REGULAR:
+		return true
+	case MERGE_STYLE_REBASE:
+		return repo.PullsAllowRebase
+	case MERGE_STYLE_SQUASH:
+		return repo.PullsAllowSquash
+	case MERGE_STYLE_FAST_FORWARD:
+		return repo.PullsAllowFastForward
+	}
+	return false
+}
+
+type ErrPullRequestNotFastForward struct {
+	args map[string]interface{}
+}
+
+func IsErrPullRequestNotFastForward(err error) bool {
+	_, ok := err.(ErrPullRequestNotFastForward)
+	return ok
+}
+
+func (err ErrPullRequestNotFastForward) Error() string {
+	return fmt.Sprintf("pull request cannot be fast-forwarded: %v", err.args)
+}
+
 // Merge merges pull request to base repository.
 // FIXME: add repoWorkingPull make sure two merges does not happen at same time.
 func (pr *PullRequest) Merge(doer *User, baseGitRepo *git.Repository, mergeStyle MergeStyle, commitDescription string) (err error) {
+	if pr.IsDraft {
+		return ErrPullRequestIsDraft{args: map[string]interface{}{"pullRequestID": pr.ID}}
+	}
+
+	reqs, err := pr.GetMergeRequirements(doer)
+	if err != nil {
+		return fmt.Errorf("get merge requirements: %v", err)
+	} else if !reqs.CanMerge() {
+		return ErrMergeRequirementsNotMet{args: map[string]interface{}{
+			"pullRequestID":            pr.ID,
+			"approvals":                reqs.Approvals,
+			"requiredApprovals":        reqs.RequiredApprovals,
+			"pendingStatusChecks":      reqs.PendingStatusChecks,
+			"unapprovedCodeOwnerFiles": reqs.UnapprovedCodeOwnerFiles,
+		}}
+	}
+
 	defer func() {
 		go HookQueue.Add(pr.BaseRepo.ID)
 		go AddTestPullRequestTask(doer, pr.BaseRepo.ID, pr.BaseBranch, false)
@@ -252,7 +432,7 @@ func (pr *PullRequest) Merge(doer *User, baseGitRepo *git.Repository, mergeStyle
 	remoteHeadBranch := "head_repo/" + pr.HeadBranch
 
 	// Check if merge style is allowed, reset to default style if not
-	if mergeStyle == MERGE_STYLE_REBASE && !pr.BaseRepo.PullsAllowRebase {
+	if !pr.BaseRepo.AllowsMergeStyle(mergeStyle) {
 		mergeStyle = MERGE_STYLE_REGULAR
 	}
 
@@ -307,6 +487,44 @@ func (pr *PullRequest) Merge(doer *User, baseGitRepo *git.Repository, mergeStyle
 			return fmt.Errorf("git merge [%s]: %v - %s", tmpBasePath, err, stderr)
 		}
 
+	case MERGE_STYLE_SQUASH: // Squash commits into a single commit
+
+		// Stage changes from head branch without creating any commit.
+		if _, stderr, err = process.ExecDir(-1, tmpBasePath,
+			fmt.Sprintf("PullRequest.Merge (git merge --squash): %s", tmpBasePath),
+			"git", "merge", "--squash", remoteHeadBranch); err != nil {
+			return fmt.Errorf("git merge --squash [%s]: %v - %s", tmpBasePath, err, stderr)
+		}
+
+		// Create a single commit for all changes of the head branch.
+		sig := doer.NewGitSig()
+		if _, stderr, err = process.ExecDir(-1, tmpBasePath,
+			fmt.Sprintf("PullRequest.Merge (git commit): %s", tmpBasePath),
+			"git", "commit", fmt.Sprintf("--author='%s <%s>'", sig.Name, sig.Email),
+			"-m", fmt.Sprintf("%s (#%d)", pr.Issue.Title, pr.Index),
+			"-m", commitDescription); err != nil {
+			return fmt.Errorf("git commit [%s]: %v - %s", tmpBasePath, err, stderr)
+		}
+
+	case MERGE_STYLE_FAST_FORWARD: // Fast-forward only
+
+		// Refuse to merge if base branch is not an ancestor of head branch.
+		if _, _, err = process.ExecDir(-1, tmpBasePath,
+			fmt.Sprintf("PullRequest.Merge (git merge-base --is-ancestor): %s", tmpBasePath),
+			"git", "merge-base", "--is-ancestor", pr.BaseBranch, remoteHeadBranch); err != nil {
+			return ErrPullRequestNotFastForward{args: map[string]interface{}{
+				"pullRequestID": pr.ID,
+				"baseBranch":    pr.BaseBranch,
+				"headBranch":    remoteHeadBranch,
+			}}
+		}
+
+		if _, stderr, err = process.ExecDir(-1, tmpBasePath,
+			fmt.Sprintf("PullRequest.Merge (git merge --ff-only): %s", tmpBasePath),
+			"git", "merge", "--ff-only", remoteHeadBranch); err != nil {
+			return fmt.Errorf("git merge --ff-only [%s]: %v - %s", tmpBasePath, err, stderr)
+		}
+
 	default:
 		return fmt.Errorf("unknown merge style: %s", mergeStyle)
 	}
@@ -318,9 +536,24 @@ func (pr *PullRequest) Merge(doer *User, baseGitRepo *git.Repository, mergeStyle
 		return fmt.Errorf("git push: %s", stderr)
 	}
 
-	pr.MergedCommitID, err = headGitRepo.BranchCommitID(pr.HeadBranch)
-	if err != nil {
-		return fmt.Errorf("get head branch %q commit ID: %v", pr.HeadBranch, err)
+	if mergeStyle == MERGE_STYLE_SQUASH {
+		// The squash commit only exists in the base branch, and its parent is used as
+		// the merge base to have exactly the squash commit as the merged changes.
+		squashCommit, err := baseGitRepo.BranchCommit(pr.BaseBranch)
+		if err != nil {
+			return fmt.Errorf("get base branch %q commit: %v", pr.BaseBranch, err)
+		}
+		parentID, err := squashCommit.ParentID(0)
+		if err != nil {
+			return fmt.Errorf("get parent of squash commit: %v", err)
+		}
+		pr.MergeBase = parentID.String()
+		pr.MergedCommitID = squashCommit.ID.String()
+	} else {
+		pr.MergedCommitID, err = headGitRepo.BranchCommitID(pr.HeadBranch)
+		if err != nil {
+			return fmt.Errorf("get head branch %q commit ID: %v", pr.HeadBranch, err)
+		}
 	}
 
 	pr.HasMerged = true
@@ -354,10 +587,13 @@ func (pr *PullRequest) Merge(doer *User, baseGitRepo *git.Repository, mergeStyle
 		return nil
 	}
 
-	commits, err := headGitRepo.RevList([]string{pr.MergeBase + "..." + pr.MergedCommitID})
-	if err != nil {
-		log.Error("Failed to list commits [merge_base: %s, merged_commit_id: %s]: %v", pr.MergeBase, pr.MergedCommitID, err)
-		return nil
+	var commits []*git.Commit
+	if mergeStyle != MERGE_STYLE_SQUASH {
+		commits, err = headGitRepo.RevList([]string{pr.MergeBase + "..." + pr.MergedCommitID})
+		if err != nil {
+			log.Error("Failed to list commits [merge_base: %s, merged_commit_id: %s]: %v", pr.MergeBase, pr.MergedCommitID, err)
+			return nil
+		}
 	}
 
 	// NOTE: It is possible that head branch is not fully sync with base branch
@@ -368,8 +604,11 @@ func (pr *PullRequest) Merge(doer *User, baseGitRepo *git.Repository, mergeStyle
 		log.Error("Failed to get base branch %q commit: %v", pr.BaseBranch, err)
 		return nil
 	}
-	if mergeStyle == MERGE_STYLE_REGULAR {
+	switch mergeStyle {
+	case MERGE_STYLE_REGULAR:
 		commits = append([]*git.Commit{mergeCommit}, commits...)
+	case MERGE_STYLE_SQUASH:
+		commits = []*git.Commit{mergeCommit}
 	}
 
 	pcs, err := CommitsToPushCommits(commits).ToApiPayloadCommits(pr.BaseRepo.RepoPath(), pr.BaseRepo.HTMLURL())
@@ -483,6 +722,7 @@ func NewPullRequest(repo *Repository, pull *Issue, labelIDs []int64, uuids []str
 	if err = sess.Commit(); err != nil {
 		return fmt.Errorf("Commit: %v", err)
 	}
+	updateIssueIndexer(pull.ID)
 
 	if err = NotifyWatchers(&Action{
 		ActUserID:    pull.Poster.ID,
@@ -502,6 +742,10 @@ func NewPullRequest(repo *Repository, pull *Issue, labelIDs []int64, uuids []str
 
 	pr.Issue = pull
 	pull.PullRequest = pr
+	if err = pr.requestCodeOwnerReviews(); err != nil {
+		log.Error("requestCodeOwnerReviews: %v", err)
+	}
+
 	if err = PrepareWebhooks(repo, HOOK_EVENT_PULL_REQUEST, &api.PullRequestPayload{
 		Action:      api.HOOK_ISSUE_OPENED,
 		Index:       pull.Index,
@@ -766,6 +1010,8 @@ func addHeadRepoTasks(prs []*PullRequest) {
 		} else if err := pr.PushToBaseRepo(); err != nil {
 			log.Error("PushToBaseRepo: %v", err)
 			continue
+		} else if err := pr.markOutdatedCodeComments(); err != nil {
+			log.Error("markOutdatedCodeComments: %v", err)
 		}
 
 		pr.AddToTaskQueue()
@@ -841,7 +1087,10 @@ func (pr *PullRequest) checkAndUpdateStatus() {
 	if !PullRequestQueue.Exist(pr.ID) {
 		if err := pr.UpdateCols("status"); err != nil {
 			log.Error("Update[%d]: %v", pr.ID, err)
+			return
 		}
+
+		pr.tryAutoMerge()
 	}
 }
 
@@ -0,0 +1,150 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"fmt"
+
+	log "unknwon.dev/clog/v2"
+
+	"github.com/gogs/git-module"
+)
+
+// IsAutoMergeScheduled returns true if the pull request is going to be merged
+// automatically once it becomes mergeable.
+func (pr *PullRequest) IsAutoMergeScheduled() bool {
+	return pr.AutoMergeStyle != ""
+}
+
+// ScheduleAutoMerge schedules the pull request to be merged by given user with given
+// merge style and commit description once it is mergeable and requirements of the
+// protected branch are met.
+func (pr *PullRequest) ScheduleAutoMerge(doer *User, style MergeStyle, commitDescription string) error {
+	if err := pr.LoadAttributes(); err != nil {
+		return fmt.Errorf("load attributes: %v", err)
+	} else if !pr.BaseRepo.AllowsMergeStyle(style) {
+		style = MERGE_STYLE_REGULAR
+	}
+
+	pr.AutoMergeStyle = style
+	pr.AutoMergeCommitDescription = commitDescription
+	pr.AutoMergerID = doer.ID
+	if err := pr.UpdateCols("auto_merge_style", "auto_merge_commit_description", "auto_merger_id"); err != nil {
+		return fmt.Errorf("update pull request: %v", err)
+	}
+
+	// Requirements may have already been met.
+	pr.AddToTaskQueue()
+	return nil
+}
+
+// CancelAutoMerge cancels the scheduled auto-merge of the pull request.
+func (pr *PullRequest) CancelAutoMerge() error {
+	pr.AutoMergeStyle = ""
+	pr.AutoMergeCommitDescription = ""
+	pr.AutoMergerID = 0
+	return pr.UpdateCols("auto_merge_style", "auto_merge_commit_description", "auto_merger_id")
+}
+
+// tryAutoMerge merges the pull request if auto-merge is scheduled and the pull request
+// is ready to be merged. It gives up and cancels the auto-merge if the pull request
+// is closed, the scheduler lost write access or the merge fails.
+func (pr *PullRequest) tryAutoMerge() {
+	if !pr.IsAutoMergeScheduled() || pr.HasMerged || pr.IsDraft || pr.Status != PULL_REQUEST_STATUS_MERGEABLE {
+		return
+	}
+
+	cancel := func(reason string) {
+		log.Trace("PullRequest[%d].tryAutoMerge: canceled: %s", pr.ID, reason)
+		if err := pr.CancelAutoMerge(); err != nil {
+			log.Error("CancelAutoMerge[%d]: %v", pr.ID, err)
+		}
+	}
+
+	if err := pr.LoadIssue(); err != nil {
+		log.Error("LoadIssue[%d]: %v", pr.ID, err)
+		return
+	} else if err = pr.LoadAttributes(); err != nil {
+		log.Error("LoadAttributes[%d]: %v", pr.ID, err)
+		return
+	}
+	if pr.Issue.IsClosed {
+		cancel("pull request is closed")
+		return
+	} else if pr.HeadRepo == nil {
+		cancel("head repository does not exist")
+		return
+	}
+
+	doer, err := GetUserByID(pr.AutoMergerID)
+	if err != nil {
+		if IsErrUserNotExist(err) {
+			cancel("user does not exist")
+		} else {
+			log.Error("GetUserByID[%d]: %v", pr.AutoMergerID, err)
+		}
+		return
+	} else if !doer.IsWriterOfRepo(pr.BaseRepo) {
+		cancel("user does not have write access")
+		return
+	}
+
+	// Auto-merge never overrides requirements of the protected branch,
+	// keep waiting for approvals and status checks.
+	reqs, err := pr.GetMergeRequirements(doer)
+	if err != nil {
+		log.Error("GetMergeRequirements[%d]: %v", pr.ID, err)
+		return
+	} else if !reqs.IsSatisfied() {
+		log.Trace("PullRequest[%d].tryAutoMerge: requirements are not met yet", pr.ID)
+		return
+	}
+
+	baseGitRepo, err := git.Open(pr.BaseRepo.RepoPath())
+	if err != nil {
+		log.Error("Open repository[%d]: %v", pr.BaseRepoID, err)
+		return
+	}
+
+	if err = pr.Merge(doer, baseGitRepo, pr.AutoMergeStyle, pr.AutoMergeCommitDescription); err != nil {
+		log.Error("Merge[%d]: %v", pr.ID, err)
+		cancel("merge failed")
+		return
+	}
+	log.Trace("PullRequest[%d].tryAutoMerge: merged", pr.ID)
+
+	if err = pr.CancelAutoMerge(); err != nil {
+		log.Error("CancelAutoMerge[%d]: %v", pr.ID, err)
+	}
+}
+
+// addAutoMergeTasksBySHA adds pull requests that have auto-merge scheduled and have
+// given head commit in given repository to the test task queue, so they are merged
+// once the new commit status satisfies requirements of the protected branch.
+func addAutoMergeTasksBySHA(repoID int64, sha string) error {
+	prs := make([]*PullRequest, 0, 2)
+	if err := x.Where("has_merged = ? AND auto_merge_style != ?", false, "").
+		And("head_repo_id = ? OR base_repo_id = ?", repoID, repoID).
+		Find(&prs); err != nil {
+		return err
+	}
+
+	for _, pr := range prs {
+		if err := pr.LoadAttributes(); err != nil {
+			return fmt.Errorf("load attributes: %v", err)
+		} else if pr.HeadRepo == nil {
+			continue
+		}
+
+		headCommitID, err := pr.HeadCommitID()
+		if err != nil {
+			log.Error("HeadCommitID[%d]: %v", pr.ID, err)
+			continue
+		} else if headCommitID == sha {
+			pr.AddToTaskQueue()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,366 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	log "unknwon.dev/clog/v2"
+	"xorm.io/xorm"
+
+	"github.com/gogs/git-module"
+
+	"gogs.io/gogs/internal/conf"
+	"gogs.io/gogs/internal/gitutil"
+)
+
+// DiffSide represents which side of a diff a code comment is anchored to.
+type DiffSide string
+
+const (
+	DIFF_SIDE_LEFT  DiffSide = "left"  // The line in the base version
+	DIFF_SIDE_RIGHT DiffSide = "right" // The line in the head version
+)
+
+// IsValidDiffSide returns true if given name is a valid side of diff.
+func IsValidDiffSide(name string) bool {
+	return name == string(DIFF_SIDE_LEFT) || name == string(DIFF_SIDE_RIGHT)
+}
+
+// codeCommentHunkLines is the maximum number of lines of the hunk (including
+// the anchored line) that are saved with a code comment to detect changes.
+const codeCommentHunkLines = 4
+
+// IsCode returns true if the comment is anchored to a line of pull request diff.
+func (c *Comment) IsCode() bool {
+	return c.Type == COMMENT_TYPE_CODE
+}
+
+// CodeCommentKey returns the key to group code comments by the anchored line of a file.
+func CodeCommentKey(treePath string, side DiffSide, line int64) string {
+	return fmt.Sprintf("%s:%s:%d", treePath, side, line)
+}
+
+// CodeKey returns the key of the anchored line of the code comment.
+func (c *Comment) CodeKey() string {
+	return CodeCommentKey(c.TreePath, c.Side, c.Line)
+}
+
+// DiffAnchor returns the anchor of the line in the diff view.
+func (c *Comment) DiffAnchor() string {
+	if c.Side == DIFF_SIDE_LEFT {
+		return fmt.Sprintf("L%d", c.Line)
+	}
+	return fmt.Sprintf("R%d", c.Line)
+}
+
+type ErrCodeCommentLineNotExist struct {
+	TreePath string
+	Side     DiffSide
+	Line     int64
+}
+
+func IsErrCodeCommentLineNotExist(err error) bool {
+	_, ok := err.(ErrCodeCommentLineNotExist)
+	return ok
+}
+
+func (err ErrCodeCommentLineNotExist) Error() string {
+	return fmt.Sprintf("line does not exist in diff [tree_path: %s, side: %s, line: %d]", err.TreePath, err.Side, err.Line)
+}
+
+type ErrCodeCommentCommitNotExist struct {
+	CommitSHA string
+}
+
+func IsErrCodeCommentCommitNotExist(err error) bool {
+	_, ok := err.(ErrCodeCommentCommitNotExist)
+	return ok
+}
+
+func (err ErrCodeCommentCommitNotExist) Error() string {
+	return fmt.Sprintf("commit does not belong to pull request [commit_sha: %s]", err.CommitSHA)
+}
+
+// hasCommit returns true if given commit is one of commits of the head branch
+// since the merge base.
+func (pr *PullRequest) hasCommit(commitID string) (bool, error) {
+	if pr.HeadRepo == nil {
+		return false, fmt.Errorf("head repository [%d] does not exist", pr.HeadRepoID)
+	}
+
+	headGitRepo, err := git.Open(pr.HeadRepo.RepoPath())
+	if err != nil {
+		return false, fmt.Errorf("open repository: %v", err)
+	}
+	headCommitID, err := headGitRepo.BranchCommitID(pr.HeadBranch)
+	if err != nil {
+		return false, fmt.Errorf("get head branch commit ID: %v", err)
+	} else if headCommitID == commitID {
+		return true, nil
+	}
+
+	commits, err := headGitRepo.RevList([]string{pr.MergeBase + ".." + headCommitID})
+	if err != nil {
+		return false, fmt.Errorf("list commits: %v", err)
+	}
+	for _, c := range commits {
+		if c.ID.String() == commitID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// diffHunkOf returns the lines of the hunk which contains the line on given side
+// of given file, up to and including the line itself.
+func diffHunkOf(diff *gitutil.Diff, treePath string, side DiffSide, line int64) (string, error) {
+	for _, file := range diff.Files {
+		if file.Name != treePath {
+			continue
+		}
+
+		for _, section := range file.Sections {
+			lines := make([]string, 0, codeCommentHunkLines)
+			for _, l := range section.Lines {
+				if l.Type == git.DiffLineSection {
+					continue
+				}
+
+				lines = append(lines, l.Content)
+				if len(lines) > codeCommentHunkLines {
+					lines = lines[1:]
+				}
+
+				if (side == DIFF_SIDE_LEFT && l.Type != git.DiffLineAdd && int64(l.LeftLine) == line) ||
+					(side == DIFF_SIDE_RIGHT && l.Type != git.DiffLineDelete && int64(l.RightLine) == line) {
+					return strings.Join(lines, "\n"), nil
+				}
+			}
+		}
+		break
+	}
+
+	return "", ErrCodeCommentLineNotExist{TreePath: treePath, Side: side, Line: line}
+}
+
+// headDiff returns the diff between merge base and given commit of the head branch.
+func (pr *PullRequest) headDiff(commitID string) (*gitutil.Diff, error) {
+	if pr.HeadRepo == nil {
+		return nil, fmt.Errorf("head repository [%d] does not exist", pr.HeadRepoID)
+	}
+
+	headGitRepo, err := git.Open(pr.HeadRepo.RepoPath())
+	if err != nil {
+		return nil, fmt.Errorf("open repository: %v", err)
+	}
+
+	return gitutil.RepoDiff(headGitRepo,
+		commitID, conf.Git.MaxDiffFiles, conf.Git.MaxDiffLines, conf.Git.MaxDiffLineChars,
+		git.DiffOptions{Base: pr.MergeBase},
+	)
+}
+
+type CreateCodeCommentOptions struct {
+	Doer      *User
+	Repo      *Repository
+	Issue     *Issue
+	CommitSHA string
+	TreePath  string
+	Side      DiffSide
+	Line      int64
+	Content   string
+}
+
+// CreateCodeComment creates a comment anchored to a line of the pull request diff.
+// The comment is attached to the pending review of the poster (if any) and only
+// becomes visible to others when the review is submitted.
+func CreateCodeComment(opts CreateCodeCommentOptions) (*Comment, error) {
+	pr := opts.Issue.PullRequest
+	if err := pr.LoadAttributes(); err != nil {
+		return nil, fmt.Errorf("load attributes: %v", err)
+	}
+
+	// The comment must be anchored to the diff of the pull request.
+	if has, err := pr.hasCommit(opts.CommitSHA); err != nil {
+		return nil, fmt.Errorf("check commit: %v", err)
+	} else if !has {
+		return nil, ErrCodeCommentCommitNotExist{CommitSHA: opts.CommitSHA}
+	}
+
+	diff, err := pr.headDiff(opts.CommitSHA)
+	if err != nil {
+		return nil, fmt.Errorf("get head diff: %v", err)
+	}
+	patch, err := diffHunkOf(diff, opts.TreePath, opts.Side, opts.Line)
+	if err != nil {
+		return nil, err
+	}
+
+	review, err := GetPendingReview(opts.Issue.ID, opts.Doer.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get pending review: %v", err)
+	}
+	var reviewID int64
+	if review != nil {
+		reviewID = review.ID
+	}
+
+	comment, err := CreateComment(&CreateCommentOptions{
+		Type:      COMMENT_TYPE_CODE,
+		Doer:      opts.Doer,
+		Repo:      opts.Repo,
+		Issue:     opts.Issue,
+		CommitSHA: opts.CommitSHA,
+		LineNum:   opts.Line,
+		Content:   opts.Content,
+		ReviewID:  reviewID,
+		TreePath:  opts.TreePath,
+		Side:      opts.Side,
+		Patch:     patch,
+		IsPending: review != nil,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("CreateComment: %v", err)
+	}
+
+	comment.Issue = opts.Issue
+	if review == nil {
+		comment.sendCreatedWebhook(opts.Doer, opts.Repo, opts.Issue)
+	}
+	return comment, nil
+}
+
+// publishCodeComments counts and announces code comments of the pending review
+// which is being submitted, the comments are returned to send webhooks for them
+// after the session is committed.
+func (r *Review) publishCodeComments(e *xorm.Session, doer *User, repo *Repository, issue *Issue) ([]*Comment, error) {
+	comments := make([]*Comment, 0, 5)
+	if err := e.Where("review_id = ? AND type = ?", r.ID, COMMENT_TYPE_CODE).Asc("created_unix").Find(&comments); err != nil {
+		return nil, fmt.Errorf("find code comments: %v", err)
+	} else if len(comments) == 0 {
+		return nil, nil
+	}
+
+	if _, err := e.Exec("UPDATE `issue` SET num_comments = num_comments + ? WHERE id = ?", len(comments), issue.ID); err != nil {
+		return nil, fmt.Errorf("update issue 'num_comments': %v", err)
+	}
+
+	for _, c := range comments {
+		c.Poster = doer
+		c.Issue = issue
+		if err := notifyWatchers(e, &Action{
+			ActUserID:    doer.ID,
+			ActUserName:  doer.Name,
+			OpType:       ACTION_COMMENT_ISSUE,
+			Content:      fmt.Sprintf("%d|%s", issue.Index, strings.Split(c.Content, "\n")[0]),
+			RepoID:       repo.ID,
+			RepoUserName: repo.Owner.Name,
+			RepoName:     repo.Name,
+			IsPrivate:    repo.IsPrivate,
+		}); err != nil {
+			log.Error("notifyWatchers: %v", err)
+		}
+
+		// The content of the issue is overwritten by the comment as the mail body.
+		mailIssue := *issue
+		if err := c.mailParticipants(e, ACTION_COMMENT_ISSUE, &mailIssue); err != nil {
+			log.Error("MailParticipants: %v", err)
+		}
+	}
+	return comments, nil
+}
+
+// IsPending returns true if the comment belongs to a pending review.
+func (c *Comment) IsPending() bool {
+	return c.Review != nil && c.Review.IsPending()
+}
+
+// IsVisibleTo returns true if the comment is visible to given user,
+// code comments of a pending review are only visible to the reviewer.
+func (c *Comment) IsVisibleTo(user *User) bool {
+	if !c.IsPending() {
+		return true
+	}
+	return user != nil && user.ID == c.PosterID
+}
+
+// VisibleComments returns comments which are visible to given user.
+func VisibleComments(comments []*Comment, user *User) []*Comment {
+	visible := comments[:0]
+	for _, c := range comments {
+		if c.IsVisibleTo(user) {
+			visible = append(visible, c)
+		}
+	}
+	return visible
+}
+
+// GetCodeCommentsByIssueID returns all code comments of the pull request
+// by given issue ID which are visible to given user, grouped by CodeKey.
+func GetCodeCommentsByIssueID(issueID int64, viewer *User) (map[string][]*Comment, error) {
+	comments := make([]*Comment, 0, 10)
+	if err := x.Where("issue_id = ? AND type = ? AND is_outdated = ?", issueID, COMMENT_TYPE_CODE, false).
+		Asc("created_unix").Find(&comments); err != nil {
+		return nil, err
+	} else if err = loadCommentsAttributes(x, comments); err != nil {
+		return nil, err
+	}
+
+	codeComments := make(map[string][]*Comment)
+	for _, c := range comments {
+		if !c.IsVisibleTo(viewer) {
+			continue
+		}
+		codeComments[c.CodeKey()] = append(codeComments[c.CodeKey()], c)
+	}
+	return codeComments, nil
+}
+
+// markOutdatedCodeComments marks code comments as outdated whose anchored
+// hunks have been changed by the latest commit of the head branch.
+func (pr *PullRequest) markOutdatedCodeComments() error {
+	comments := make([]*Comment, 0, 10)
+	if err := x.Where("issue_id = ? AND type = ? AND is_outdated = ?", pr.IssueID, COMMENT_TYPE_CODE, false).
+		Find(&comments); err != nil {
+		return fmt.Errorf("find code comments: %v", err)
+	} else if len(comments) == 0 {
+		return nil
+	}
+
+	headCommitID, err := pr.HeadCommitID()
+	if err != nil {
+		return fmt.Errorf("get head commit ID: %v", err)
+	}
+	diff, err := pr.headDiff(headCommitID)
+	if err != nil {
+		return fmt.Errorf("get head diff: %v", err)
+	}
+
+	for _, c := range comments {
+		if c.CommitSHA == headCommitID {
+			continue
+		}
+
+		patch, err := diffHunkOf(diff, c.TreePath, c.Side, c.Line)
+		if err != nil {
+			if !IsErrCodeCommentLineNotExist(err) {
+				return fmt.Errorf("get diff hunk: %v", err)
+			} else if diff.IsIncomplete() {
+				// The file may have been cut from the diff.
+				continue
+			}
+		} else if patch == c.Patch {
+			continue
+		}
+
+		c.IsOutdated = true
+		if _, err = x.ID(c.ID).Cols("is_outdated").Update(c); err != nil {
+			return fmt.Errorf("mark comment [%d] as outdated: %v", c.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisibleComments(t *testing.T) {
+	pending := &Review{ID: 1, State: REVIEW_STATE_PENDING}
+	approved := &Review{ID: 2, State: REVIEW_STATE_APPROVED}
+	newComments := func() []*Comment {
+		return []*Comment{
+			{ID: 1, Type: COMMENT_TYPE_COMMENT, PosterID: 1},
+			{ID: 2, Type: COMMENT_TYPE_CODE, PosterID: 1, ReviewID: pending.ID, Review: pending},
+			{ID: 3, Type: COMMENT_TYPE_CODE, PosterID: 2, ReviewID: approved.ID, Review: approved},
+		}
+	}
+	ids := func(comments []*Comment) []int64 {
+		ids := make([]int64, len(comments))
+		for i := range comments {
+			ids[i] = comments[i].ID
+		}
+		return ids
+	}
+
+	assert.Equal(t, []int64{1, 2, 3}, ids(VisibleComments(newComments(), &User{ID: 1})))
+	assert.Equal(t, []int64{1, 3}, ids(VisibleComments(newComments(), &User{ID: 2})))
+	assert.Equal(t, []int64{1, 3}, ids(VisibleComments(newComments(), nil)))
+}
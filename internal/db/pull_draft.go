@@ -0,0 +1,99 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"fmt"
+
+	log "unknwon.dev/clog/v2"
+	"xorm.io/xorm"
+
+	api "github.com/gogs/go-gogs-client"
+)
+
+const (
+	HOOK_PULL_REQUEST_READY_FOR_REVIEW   api.HookIssueAction = "ready_for_review"
+	HOOK_PULL_REQUEST_CONVERTED_TO_DRAFT api.HookIssueAction = "converted_to_draft"
+)
+
+// DraftFilter represents the filter of pull requests by draft state.
+type DraftFilter string
+
+const (
+	DRAFT_FILTER_NONE    DraftFilter = ""
+	DRAFT_FILTER_ONLY    DraftFilter = "only"
+	DRAFT_FILTER_EXCLUDE DraftFilter = "exclude"
+)
+
+// ToDraftFilter returns the draft filter by given name,
+// it returns DRAFT_FILTER_NONE for unknown names.
+func ToDraftFilter(name string) DraftFilter {
+	switch DraftFilter(name) {
+	case DRAFT_FILTER_ONLY, DRAFT_FILTER_EXCLUDE:
+		return DraftFilter(name)
+	}
+	return DRAFT_FILTER_NONE
+}
+
+// apply adds conditions of the filter to given session of querying issues.
+func (f DraftFilter) apply(sess *xorm.Session) {
+	if f == DRAFT_FILTER_NONE {
+		return
+	}
+	sess.And("issue.id IN (SELECT issue_id FROM pull_request WHERE is_draft = ?)", f == DRAFT_FILTER_ONLY)
+}
+
+type ErrPullRequestIsDraft struct {
+	args map[string]interface{}
+}
+
+func IsErrPullRequestIsDraft(err error) bool {
+	_, ok := err.(ErrPullRequestIsDraft)
+	return ok
+}
+
+func (err ErrPullRequestIsDraft) Error() string {
+	return fmt.Sprintf("pull request is still a draft: %v", err.args)
+}
+
+// SetDraft converts the pull request to a draft or marks it as ready for review,
+// and sends the corresponding webhook.
+func (pr *PullRequest) SetDraft(doer *User, isDraft bool) error {
+	if pr.IsDraft == isDraft {
+		return nil
+	}
+
+	pr.IsDraft = isDraft
+	if err := pr.UpdateCols("is_draft"); err != nil {
+		return fmt.Errorf("update pull request: %v", err)
+	}
+
+	if err := pr.LoadIssue(); err != nil {
+		return fmt.Errorf("load issue: %v", err)
+	} else if err = pr.LoadAttributes(); err != nil {
+		return fmt.Errorf("load attributes: %v", err)
+	}
+	pr.Issue.PullRequest = pr
+
+	action := HOOK_PULL_REQUEST_READY_FOR_REVIEW
+	if isDraft {
+		action = HOOK_PULL_REQUEST_CONVERTED_TO_DRAFT
+	}
+	if err := PrepareWebhooks(pr.Issue.Repo, HOOK_EVENT_PULL_REQUEST, &api.PullRequestPayload{
+		Action:      action,
+		Index:       pr.Index,
+		PullRequest: pr.APIFormat(),
+		Repository:  pr.Issue.Repo.APIFormat(nil),
+		Sender:      doer.APIFormat(),
+	}); err != nil {
+		log.Error("PrepareWebhooks [pull_id: %d]: %v", pr.ID, err)
+	}
+
+	// A scheduled auto-merge may be waiting for the pull request to be ready.
+	if !isDraft && pr.IsAutoMergeScheduled() {
+		pr.AddToTaskQueue()
+	}
+	return nil
+}
@@ -0,0 +1,440 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"fmt"
+	"time"
+
+	log "unknwon.dev/clog/v2"
+	"xorm.io/xorm"
+
+	"gogs.io/gogs/internal/errutil"
+)
+
+// ReviewState represents the state of a pull request review.
+type ReviewState int
+
+const (
+	REVIEW_STATE_PENDING ReviewState = iota
+	REVIEW_STATE_APPROVED
+	REVIEW_STATE_CHANGES_REQUESTED
+	REVIEW_STATE_DISMISSED
+)
+
+var reviewStates = map[string]ReviewState{
+	"pending":           REVIEW_STATE_PENDING,
+	"approved":          REVIEW_STATE_APPROVED,
+	"changes_requested": REVIEW_STATE_CHANGES_REQUESTED,
+	"dismissed":         REVIEW_STATE_DISMISSED,
+}
+
+// ToReviewState returns ReviewState by given name.
+func ToReviewState(name string) ReviewState {
+	return reviewStates[name]
+}
+
+// IsValidReviewState returns true if given name is a valid review state.
+func IsValidReviewState(name string) bool {
+	_, ok := reviewStates[name]
+	return ok
+}
+
+func (s ReviewState) Name() string {
+	switch s {
+	case REVIEW_STATE_PENDING:
+		return "pending"
+	case REVIEW_STATE_APPROVED:
+		return "approved"
+	case REVIEW_STATE_CHANGES_REQUESTED:
+		return "changes_requested"
+	case REVIEW_STATE_DISMISSED:
+		return "dismissed"
+	}
+	return ""
+}
+
+// Review represents a review of a pull request, which is attached to the issue of the pull request.
+type Review struct {
+	ID         int64
+	IssueID    int64  `xorm:"INDEX"`
+	Issue      *Issue `xorm:"-" json:"-"`
+	ReviewerID int64  `xorm:"INDEX"`
+	Reviewer   *User  `xorm:"-" json:"-"`
+	State      ReviewState
+	Content    string `xorm:"TEXT"`
+	// The head commit of the pull request at the time of the review.
+	CommitSHA string `xorm:"VARCHAR(40)"`
+
+	Created     time.Time `xorm:"-" json:"-"`
+	CreatedUnix int64
+	Updated     time.Time `xorm:"-" json:"-"`
+	UpdatedUnix int64
+}
+
+func (r *Review) BeforeInsert() {
+	r.CreatedUnix = time.Now().Unix()
+	r.UpdatedUnix = r.CreatedUnix
+}
+
+func (r *Review) BeforeUpdate() {
+	r.UpdatedUnix = time.Now().Unix()
+}
+
+func (r *Review) AfterSet(colName string, _ xorm.Cell) {
+	switch colName {
+	case "created_unix":
+		r.Created = time.Unix(r.CreatedUnix, 0).Local()
+	case "updated_unix":
+		r.Updated = time.Unix(r.UpdatedUnix, 0).Local()
+	}
+}
+
+func (r *Review) loadAttributes(e Engine) (err error) {
+	if r.Reviewer == nil {
+		r.Reviewer, err = getUserByID(e, r.ReviewerID)
+		if err != nil {
+			if IsErrUserNotExist(err) {
+				r.ReviewerID = -1
+				r.Reviewer = NewGhostUser()
+			} else {
+				return fmt.Errorf("getUserByID.(Reviewer) [%d]: %v", r.ReviewerID, err)
+			}
+		}
+	}
+
+	if r.Issue == nil {
+		r.Issue, err = getRawIssueByID(e, r.IssueID)
+		if err != nil {
+			return fmt.Errorf("getRawIssueByID [%d]: %v", r.IssueID, err)
+		}
+		if r.Issue.Repo == nil {
+			r.Issue.Repo, err = getRepositoryByID(e, r.Issue.RepoID)
+			if err != nil {
+				return fmt.Errorf("getRepositoryByID [%d]: %v", r.Issue.RepoID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *Review) LoadAttributes() error {
+	return r.loadAttributes(x)
+}
+
+// IsApproved returns true if the review approves the pull request.
+func (r *Review) IsApproved() bool {
+	return r.State == REVIEW_STATE_APPROVED
+}
+
+// IsChangesRequested returns true if the review requests changes to the pull request.
+func (r *Review) IsChangesRequested() bool {
+	return r.State == REVIEW_STATE_CHANGES_REQUESTED
+}
+
+// IsDismissed returns true if the review has been dismissed.
+func (r *Review) IsDismissed() bool {
+	return r.State == REVIEW_STATE_DISMISSED
+}
+
+// IsPending returns true if the review has not been submitted yet.
+func (r *Review) IsPending() bool {
+	return r.State == REVIEW_STATE_PENDING
+}
+
+func (r *Review) HTMLURL() string {
+	return fmt.Sprintf("%s#pullrequestreview-%d", r.Issue.HTMLURL(), r.ID)
+}
+
+// HashTag returns unique hash tag for review.
+func (r *Review) HashTag() string {
+	return fmt.Sprintf("pullrequestreview-%d", r.ID)
+}
+
+// This method assumes following fields have been assigned with valid values:
+// Required - Reviewer, Issue
+func (r *Review) APIFormat() *APIReview {
+	return &APIReview{
+		ID:        r.ID,
+		Reviewer:  r.Reviewer.APIFormat(),
+		Body:      r.Content,
+		State:     r.State.Name(),
+		CommitID:  r.CommitSHA,
+		HTMLURL:   r.HTMLURL(),
+		Submitted: r.Created,
+		Updated:   r.Updated,
+	}
+}
+
+func (r *Review) sendWebhook(doer *User, action HookReviewAction) {
+	issue := r.Issue
+	if err := issue.LoadAttributes(); err != nil {
+		log.Error("Issue.LoadAttributes [issue_id: %d]: %v", issue.ID, err)
+		return
+	}
+	issue.PullRequest.Issue = issue
+
+	if err := PrepareWebhooks(issue.Repo, HOOK_EVENT_PULL_REQUEST_REVIEW, &PullRequestReviewPayload{
+		Action:      action,
+		Index:       issue.Index,
+		PullRequest: issue.PullRequest.APIFormat(),
+		Review:      r.APIFormat(),
+		Repository:  issue.Repo.APIFormat(nil),
+		Sender:      doer.APIFormat(),
+	}); err != nil {
+		log.Error("PrepareWebhooks [review_id: %d]: %v", r.ID, err)
+	}
+}
+
+var _ errutil.NotFound = (*ErrReviewNotExist)(nil)
+
+type ErrReviewNotExist struct {
+	args map[string]interface{}
+}
+
+func IsErrReviewNotExist(err error) bool {
+	_, ok := err.(ErrReviewNotExist)
+	return ok
+}
+
+func (err ErrReviewNotExist) Error() string {
+	return fmt.Sprintf("review does not exist: %v", err.args)
+}
+
+func (ErrReviewNotExist) NotFound() bool {
+	return true
+}
+
+type ErrReviewOwnPullRequest struct {
+	args map[string]interface{}
+}
+
+func IsErrReviewOwnPullRequest(err error) bool {
+	_, ok := err.(ErrReviewOwnPullRequest)
+	return ok
+}
+
+func (err ErrReviewOwnPullRequest) Error() string {
+	return fmt.Sprintf("cannot approve or request changes on own pull request: %v", err.args)
+}
+
+func getPendingReview(e Engine, issueID, reviewerID int64) (*Review, error) {
+	r := new(Review)
+	has, err := e.Where("issue_id = ? AND reviewer_id = ? AND state = ?", issueID, reviewerID, REVIEW_STATE_PENDING).Get(r)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, nil
+	}
+	return r, nil
+}
+
+type CreateReviewOptions struct {
+	Doer      *User
+	Repo      *Repository
+	Issue     *Issue
+	State     ReviewState
+	Content   string
+	CommitSHA string
+}
+
+// CreateReview creates or submits a review of the pull request. A pending review
+// is only saved for the reviewer, and any other state submits the existing pending
+// review of the reviewer (if any) with a new timeline comment.
+func CreateReview(opts CreateReviewOptions) (_ *Review, err error) {
+	if opts.State != REVIEW_STATE_PENDING &&
+		opts.Issue.IsPoster(opts.Doer.ID) {
+		return nil, ErrReviewOwnPullRequest{args: map[string]interface{}{"issueID": opts.Issue.ID, "userID": opts.Doer.ID}}
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	r, err := getPendingReview(sess, opts.Issue.ID, opts.Doer.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get pending review: %v", err)
+	}
+
+	var codeComments []*Comment
+	if r == nil {
+		r = &Review{
+			IssueID:    opts.Issue.ID,
+			ReviewerID: opts.Doer.ID,
+			State:      opts.State,
+			Content:    opts.Content,
+			CommitSHA:  opts.CommitSHA,
+		}
+		if _, err = sess.Insert(r); err != nil {
+			return nil, fmt.Errorf("insert review: %v", err)
+		}
+	} else {
+		r.State = opts.State
+		r.Content = opts.Content
+		r.CommitSHA = opts.CommitSHA
+		if _, err = sess.ID(r.ID).AllCols().Update(r); err != nil {
+			return nil, fmt.Errorf("update review: %v", err)
+		}
+
+		if r.State != REVIEW_STATE_PENDING {
+			codeComments, err = r.publishCodeComments(sess, opts.Doer, opts.Repo, opts.Issue)
+			if err != nil {
+				return nil, fmt.Errorf("publish code comments: %v", err)
+			}
+		}
+	}
+
+	if r.State != REVIEW_STATE_PENDING {
+		if _, err = createComment(sess, &CreateCommentOptions{
+			Type:      COMMENT_TYPE_REVIEW,
+			Doer:      opts.Doer,
+			Repo:      opts.Repo,
+			Issue:     opts.Issue,
+			ReviewID:  r.ID,
+			CommitSHA: opts.CommitSHA,
+			Content:   opts.Content,
+		}); err != nil {
+			return nil, fmt.Errorf("create review comment: %v", err)
+		}
+	}
+
+	if err = sess.Commit(); err != nil {
+		return nil, fmt.Errorf("commit: %v", err)
+	}
+	if r.State != REVIEW_STATE_PENDING {
+		updateIssueIndexer(opts.Issue.ID)
+	}
+
+	r.Reviewer = opts.Doer
+	r.Issue = opts.Issue
+	if err = r.LoadAttributes(); err != nil {
+		return nil, fmt.Errorf("load attributes: %v", err)
+	}
+
+	if r.State != REVIEW_STATE_PENDING {
+		for _, c := range codeComments {
+			c.sendCreatedWebhook(opts.Doer, opts.Repo, opts.Issue)
+		}
+		r.sendWebhook(opts.Doer, HOOK_REVIEW_SUBMITTED)
+	}
+	if r.State == REVIEW_STATE_APPROVED && opts.Issue.PullRequest != nil && opts.Issue.PullRequest.IsAutoMergeScheduled() {
+		opts.Issue.PullRequest.AddToTaskQueue()
+	}
+	return r, nil
+}
+
+// Dismiss marks the review as dismissed so it no longer counts toward
+// the approval state of the pull request, and leaves a timeline comment.
+// This method assumes following fields have been assigned with valid values:
+// Required - Issue
+func (r *Review) Dismiss(doer *User) (err error) {
+	if r.State != REVIEW_STATE_APPROVED && r.State != REVIEW_STATE_CHANGES_REQUESTED {
+		return nil
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	r.State = REVIEW_STATE_DISMISSED
+	if _, err = sess.ID(r.ID).Cols("state").Update(r); err != nil {
+		return fmt.Errorf("update review: %v", err)
+	}
+
+	if _, err = createComment(sess, &CreateCommentOptions{
+		Type:     COMMENT_TYPE_REVIEW_DISMISSED,
+		Doer:     doer,
+		Repo:     r.Issue.Repo,
+		Issue:    r.Issue,
+		ReviewID: r.ID,
+	}); err != nil {
+		return fmt.Errorf("create dismissal comment: %v", err)
+	}
+
+	if err = sess.Commit(); err != nil {
+		return fmt.Errorf("commit: %v", err)
+	}
+
+	r.sendWebhook(doer, HOOK_REVIEW_DISMISSED)
+	return nil
+}
+
+func getReviewByID(e Engine, id int64) (*Review, error) {
+	r := new(Review)
+	has, err := e.ID(id).Get(r)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrReviewNotExist{args: map[string]interface{}{"reviewID": id}}
+	}
+	return r, r.loadAttributes(e)
+}
+
+// GetReviewByID returns the review by given ID.
+func GetReviewByID(id int64) (*Review, error) {
+	return getReviewByID(x, id)
+}
+
+// GetReviewOfIssueByID returns the review by given ID in given issue.
+func GetReviewOfIssueByID(issueID, id int64) (*Review, error) {
+	r, err := GetReviewByID(id)
+	if err != nil {
+		return nil, err
+	} else if r.IssueID != issueID {
+		return nil, ErrReviewNotExist{args: map[string]interface{}{"issueID": issueID, "reviewID": id}}
+	}
+	return r, nil
+}
+
+func getReviewsByIssueID(e Engine, issueID int64) ([]*Review, error) {
+	reviews := make([]*Review, 0, 5)
+	if err := e.Where("issue_id = ? AND state != ?", issueID, REVIEW_STATE_PENDING).Asc("created_unix").Find(&reviews); err != nil {
+		return nil, err
+	}
+
+	for i := range reviews {
+		if err := reviews[i].loadAttributes(e); err != nil {
+			return nil, fmt.Errorf("loadAttributes [%d]: %v", reviews[i].ID, err)
+		}
+	}
+	return reviews, nil
+}
+
+// GetReviewsByIssueID returns all submitted reviews of the pull request by given issue ID.
+func GetReviewsByIssueID(issueID int64) ([]*Review, error) {
+	return getReviewsByIssueID(x, issueID)
+}
+
+// GetLatestReviewsByIssueID returns the most recent submitted review of each reviewer
+// of the pull request by given issue ID, ordered by the time they were submitted.
+func GetLatestReviewsByIssueID(issueID int64) ([]*Review, error) {
+	reviews, err := getReviewsByIssueID(x, issueID)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make([]*Review, 0, len(reviews))
+	indexes := make(map[int64]int, len(reviews))
+	for _, r := range reviews {
+		if idx, ok := indexes[r.ReviewerID]; ok {
+			latest[idx] = r
+			continue
+		}
+		indexes[r.ReviewerID] = len(latest)
+		latest = append(latest, r)
+	}
+	return latest, nil
+}
+
+// GetPendingReview returns the pending review of the reviewer on the pull request,
+// it returns nil if the reviewer does not have one.
+func GetPendingReview(issueID, reviewerID int64) (*Review, error) {
+	return getPendingReview(x, issueID, reviewerID)
+}
@@ -0,0 +1,120 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"xorm.io/xorm"
+)
+
+// ReviewRequest represents a request for a user or a team to review a pull request,
+// exactly one of ReviewerID and TeamID is set.
+type ReviewRequest struct {
+	ID         int64
+	IssueID    int64 `xorm:"INDEX UNIQUE(s)"`
+	ReviewerID int64 `xorm:"UNIQUE(s)"`
+	Reviewer   *User `xorm:"-" json:"-"`
+	TeamID     int64 `xorm:"UNIQUE(s)"`
+	Team       *Team `xorm:"-" json:"-"`
+	// Whether the request was made because of the CODEOWNERS file.
+	IsCodeOwner bool `xorm:"NOT NULL DEFAULT false"`
+
+	Created     time.Time `xorm:"-" json:"-"`
+	CreatedUnix int64
+}
+
+func (r *ReviewRequest) BeforeInsert() {
+	r.CreatedUnix = time.Now().Unix()
+}
+
+func (r *ReviewRequest) AfterSet(colName string, _ xorm.Cell) {
+	switch colName {
+	case "created_unix":
+		r.Created = time.Unix(r.CreatedUnix, 0).Local()
+	}
+}
+
+func (r *ReviewRequest) loadAttributes(e Engine) (err error) {
+	if r.ReviewerID > 0 && r.Reviewer == nil {
+		r.Reviewer, err = getUserByID(e, r.ReviewerID)
+		if err != nil {
+			if IsErrUserNotExist(err) {
+				r.ReviewerID = -1
+				r.Reviewer = NewGhostUser()
+			} else {
+				return fmt.Errorf("getUserByID.(Reviewer) [%d]: %v", r.ReviewerID, err)
+			}
+		}
+	}
+
+	if r.TeamID > 0 && r.Team == nil {
+		r.Team, err = getTeamByID(e, r.TeamID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsTeam returns true if the review is requested from a team.
+func (r *ReviewRequest) IsTeam() bool {
+	return r.TeamID > 0
+}
+
+// requestReviews requests reviews of the pull request from given users and teams,
+// existing requests are left untouched.
+func requestReviews(issueID int64, userIDs, teamIDs []int64, isCodeOwner bool) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	requests := make([]*ReviewRequest, 0, len(userIDs)+len(teamIDs))
+	for _, id := range userIDs {
+		requests = append(requests, &ReviewRequest{IssueID: issueID, ReviewerID: id, IsCodeOwner: isCodeOwner})
+	}
+	for _, id := range teamIDs {
+		requests = append(requests, &ReviewRequest{IssueID: issueID, TeamID: id, IsCodeOwner: isCodeOwner})
+	}
+
+	for _, r := range requests {
+		has, err := sess.Where("issue_id = ? AND reviewer_id = ? AND team_id = ?", r.IssueID, r.ReviewerID, r.TeamID).Get(new(ReviewRequest))
+		if err != nil {
+			return fmt.Errorf("get review request: %v", err)
+		} else if has {
+			continue
+		}
+
+		if _, err = sess.Insert(r); err != nil {
+			return fmt.Errorf("insert review request: %v", err)
+		}
+	}
+
+	return sess.Commit()
+}
+
+// GetReviewRequestsByIssueID returns all review requests of the pull request by given issue ID.
+// Requests of teams that have been deleted are omitted.
+func GetReviewRequestsByIssueID(issueID int64) ([]*ReviewRequest, error) {
+	requests := make([]*ReviewRequest, 0, 5)
+	if err := x.Where("issue_id = ?", issueID).Asc("id").Find(&requests); err != nil {
+		return nil, err
+	}
+
+	valid := requests[:0]
+	for _, r := range requests {
+		if err := r.loadAttributes(x); err != nil {
+			if IsErrTeamNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		valid = append(valid, r)
+	}
+	return valid, nil
+}
@@ -0,0 +1,283 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"xorm.io/xorm"
+
+	api "github.com/gogs/go-gogs-client"
+)
+
+// AllowedReactions is the list of reactions that can be given to issues and comments,
+// in the order they are displayed.
+var AllowedReactions = []string{"+1", "-1", "laugh", "hooray", "confused", "heart", "rocket", "eyes"}
+
+var reactionEmojis = map[string]string{
+	"+1":       "\U0001F44D",
+	"-1":       "\U0001F44E",
+	"laugh":    "\U0001F604",
+	"hooray":   "\U0001F389",
+	"confused": "\U0001F615",
+	"heart":    "\u2764\uFE0F",
+	"rocket":   "\U0001F680",
+	"eyes":     "\U0001F440",
+}
+
+// IsAllowedReaction returns true if given content is one of allowed reactions.
+func IsAllowedReaction(content string) bool {
+	_, ok := reactionEmojis[content]
+	return ok
+}
+
+// ReactionEmoji returns the emoji of given reaction content.
+func ReactionEmoji(content string) string {
+	return reactionEmojis[content]
+}
+
+// Reaction represents a reaction of a user to an issue or a comment.
+type Reaction struct {
+	ID        int64
+	IssueID   int64  `xorm:"INDEX UNIQUE(s) NOT NULL"`
+	CommentID int64  `xorm:"INDEX UNIQUE(s)"` // Zero for reactions to the issue itself.
+	UserID    int64  `xorm:"INDEX UNIQUE(s) NOT NULL"`
+	User      *User  `xorm:"-" json:"-"`
+	Content   string `xorm:"VARCHAR(20) UNIQUE(s) NOT NULL"`
+
+	Created     time.Time `xorm:"-" json:"-"`
+	CreatedUnix int64
+}
+
+func (r *Reaction) BeforeInsert() {
+	r.CreatedUnix = time.Now().Unix()
+}
+
+func (r *Reaction) AfterSet(colName string, _ xorm.Cell) {
+	switch colName {
+	case "created_unix":
+		r.Created = time.Unix(r.CreatedUnix, 0).Local()
+	}
+}
+
+type ErrInvalidReaction struct {
+	args map[string]interface{}
+}
+
+func IsErrInvalidReaction(err error) bool {
+	_, ok := err.(ErrInvalidReaction)
+	return ok
+}
+
+func (err ErrInvalidReaction) Error() string {
+	return fmt.Sprintf("reaction is not allowed: %v", err.args)
+}
+
+// APIReaction represents the API format of a reaction.
+type APIReaction struct {
+	User    *api.User `json:"user"`
+	Content string    `json:"content"`
+	Created time.Time `json:"created_at"`
+}
+
+// APIFormat returns the API format of the reaction, it requires User to be loaded.
+func (r *Reaction) APIFormat() *APIReaction {
+	return &APIReaction{
+		User:    r.User.APIFormat(),
+		Content: r.Content,
+		Created: r.Created,
+	}
+}
+
+// ReactionList is a list of reactions.
+type ReactionList []*Reaction
+
+// APIFormat returns the API format of reactions in the list.
+func (rs ReactionList) APIFormat() []*APIReaction {
+	apiReactions := make([]*APIReaction, len(rs))
+	for i := range rs {
+		apiReactions[i] = rs[i].APIFormat()
+	}
+	return apiReactions
+}
+
+// ReactionGroup represents users who gave the same reaction.
+type ReactionGroup struct {
+	Content string
+	Users   []*User
+}
+
+// Emoji returns the emoji of the reaction.
+func (g *ReactionGroup) Emoji() string {
+	return ReactionEmoji(g.Content)
+}
+
+// HasUser returns true if given user is one of who gave the reaction.
+func (g *ReactionGroup) HasUser(userID int64) bool {
+	for _, u := range g.Users {
+		if u.ID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// UserNames returns names of users who gave the reaction joined by commas.
+func (g *ReactionGroup) UserNames() string {
+	names := make([]string, len(g.Users))
+	for i := range g.Users {
+		names[i] = g.Users[i].Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// Groups returns reactions grouped by content in the order of allowed reactions,
+// it requires users of reactions to be loaded.
+func (rs ReactionList) Groups() []*ReactionGroup {
+	contentGroups := make(map[string]*ReactionGroup, len(AllowedReactions))
+	for _, r := range rs {
+		g, ok := contentGroups[r.Content]
+		if !ok {
+			g = &ReactionGroup{Content: r.Content}
+			contentGroups[r.Content] = g
+		}
+		g.Users = append(g.Users, r.User)
+	}
+
+	groups := make([]*ReactionGroup, 0, len(contentGroups))
+	for _, content := range AllowedReactions {
+		if g, ok := contentGroups[content]; ok {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+func (rs ReactionList) loadUsers(e Engine) error {
+	users := make(map[int64]*User)
+	for _, r := range rs {
+		if u, ok := users[r.UserID]; ok {
+			r.User = u
+			continue
+		}
+
+		u, err := getUserByID(e, r.UserID)
+		if err != nil {
+			if !IsErrUserNotExist(err) {
+				return fmt.Errorf("getUserByID [%d]: %v", r.UserID, err)
+			}
+			u = NewGhostUser()
+		}
+		users[r.UserID] = u
+		r.User = u
+	}
+	return nil
+}
+
+// GetReactions returns reactions to the comment, or to the issue itself if the
+// comment ID is zero, in the order they were given.
+func GetReactions(issueID, commentID int64) (ReactionList, error) {
+	reactions := make(ReactionList, 0, 5)
+	if err := x.Where("issue_id = ? AND comment_id = ?", issueID, commentID).Asc("id").Find(&reactions); err != nil {
+		return nil, err
+	}
+	return reactions, reactions.loadUsers(x)
+}
+
+// LoadReactions loads reactions to the issue and its comments in the same query,
+// it requires Comments to be loaded.
+func (issue *Issue) LoadReactions() error {
+	reactions := make(ReactionList, 0, 10)
+	if err := x.Where("issue_id = ?", issue.ID).Asc("id").Find(&reactions); err != nil {
+		return err
+	} else if err = reactions.loadUsers(x); err != nil {
+		return fmt.Errorf("load users: %v", err)
+	}
+
+	issue.Reactions = nil
+	commentReactions := make(map[int64]ReactionList)
+	for _, r := range reactions {
+		if r.CommentID == 0 {
+			issue.Reactions = append(issue.Reactions, r)
+		} else {
+			commentReactions[r.CommentID] = append(commentReactions[r.CommentID], r)
+		}
+	}
+	for _, c := range issue.Comments {
+		c.Reactions = commentReactions[c.ID]
+	}
+	return nil
+}
+
+// LoadCommentsReactions loads reactions to given comments.
+func LoadCommentsReactions(comments []*Comment) error {
+	if len(comments) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(comments))
+	for i := range comments {
+		ids[i] = comments[i].ID
+	}
+	reactions := make(ReactionList, 0, len(comments))
+	if err := x.In("comment_id", ids).Asc("id").Find(&reactions); err != nil {
+		return err
+	} else if err = reactions.loadUsers(x); err != nil {
+		return fmt.Errorf("load users: %v", err)
+	}
+
+	commentReactions := make(map[int64]ReactionList, len(comments))
+	for _, r := range reactions {
+		commentReactions[r.CommentID] = append(commentReactions[r.CommentID], r)
+	}
+	for _, c := range comments {
+		c.Reactions = commentReactions[c.ID]
+	}
+	return nil
+}
+
+// CreateReaction gives the reaction of the user to the comment, or to the issue
+// itself if the comment ID is zero. It returns the existing one if the user has
+// already given the same reaction.
+func CreateReaction(doer *User, issueID, commentID int64, content string) (*Reaction, error) {
+	if !IsAllowedReaction(content) {
+		return nil, ErrInvalidReaction{args: map[string]interface{}{"content": content}}
+	}
+
+	r := new(Reaction)
+	has, err := x.Where("issue_id = ? AND comment_id = ? AND user_id = ? AND content = ?", issueID, commentID, doer.ID, content).Get(r)
+	if err != nil {
+		return nil, fmt.Errorf("get reaction: %v", err)
+	} else if has {
+		r.User = doer
+		return r, nil
+	}
+
+	r = &Reaction{
+		IssueID:   issueID,
+		CommentID: commentID,
+		UserID:    doer.ID,
+		User:      doer,
+		Content:   content,
+	}
+	if _, err = x.Insert(r); err != nil {
+		return nil, err
+	}
+	r.Created = time.Unix(r.CreatedUnix, 0).Local()
+	return r, nil
+}
+
+// DeleteReaction removes the reaction of the user to the comment, or to the issue
+// itself if the comment ID is zero.
+func DeleteReaction(doer *User, issueID, commentID int64, content string) error {
+	if !IsAllowedReaction(content) {
+		return ErrInvalidReaction{args: map[string]interface{}{"content": content}}
+	}
+
+	_, err := x.Where("issue_id = ? AND comment_id = ? AND user_id = ? AND content = ?", issueID, commentID, doer.ID, content).Delete(new(Reaction))
+	return err
+}
@@ -0,0 +1,43 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReactionList_Groups(t *testing.T) {
+	alice := &User{ID: 1, Name: "alice"}
+	bob := &User{ID: 2, Name: "bob"}
+	reactions := ReactionList{
+		{UserID: 1, User: alice, Content: "heart"},
+		{UserID: 2, User: bob, Content: "+1"},
+		{UserID: 1, User: alice, Content: "+1"},
+	}
+
+	groups := reactions.Groups()
+	if !assert.Len(t, groups, 2) {
+		return
+	}
+
+	assert.Equal(t, "+1", groups[0].Content)
+	assert.Equal(t, "bob, alice", groups[0].UserNames())
+	assert.True(t, groups[0].HasUser(1))
+
+	assert.Equal(t, "heart", groups[1].Content)
+	assert.Equal(t, "alice", groups[1].UserNames())
+	assert.False(t, groups[1].HasUser(2))
+}
+
+func TestIsAllowedReaction(t *testing.T) {
+	for _, content := range AllowedReactions {
+		assert.True(t, IsAllowedReaction(content), content)
+		assert.NotEmpty(t, ReactionEmoji(content), content)
+	}
+	assert.False(t, IsAllowedReaction("thumbsup"))
+	assert.False(t, IsAllowedReaction(""))
+}
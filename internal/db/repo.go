@@ -173,6 +173,9 @@ type Repository struct {
 	NumOpenMilestones   int `xorm:"-" json:"-"`
 	NumTags             int `xorm:"-" json:"-"`
 
+	// Issues transferred to other repositories, their indexes are not reused.
+	NumTransferredIssues int `xorm:"NOT NULL DEFAULT 0"`
+
 	IsPrivate bool
 	IsBare    bool
 
@@ -194,6 +197,11 @@ type Repository struct {
 	EnablePulls           bool              `xorm:"NOT NULL DEFAULT true"`
 	PullsIgnoreWhitespace bool              `xorm:"NOT NULL DEFAULT false"`
 	PullsAllowRebase      bool              `xorm:"NOT NULL DEFAULT false"`
+	PullsAllowSquash      bool              `xorm:"NOT NULL DEFAULT false"`
+	PullsAllowFastForward bool              `xorm:"NOT NULL DEFAULT false"`
+	// Whether to refuse closing issues that are blocked by open issues.
+	IssuesBlockCloseByDependencies bool `xorm:"NOT NULL DEFAULT false"`
+	EnableTimeTracker              bool `xorm:"NOT NULL DEFAULT false"`
 
 	IsFork   bool `xorm:"NOT NULL DEFAULT false"`
 	ForkID   int64
@@ -564,6 +572,12 @@ func (repo *Repository) CanBeForked() bool {
 	return !repo.IsBare
 }
 
+// IsTimeTrackerEnabled returns true if time tracking is enabled for the builtin
+// issue tracker of the repository.
+func (repo *Repository) IsTimeTrackerEnabled() bool {
+	return repo.EnableIssues && !repo.EnableExternalTracker && repo.EnableTimeTracker
+}
+
 // CanEnablePulls returns true if repository meets the requirements of accepting pulls.
 func (repo *Repository) CanEnablePulls() bool {
 	return !repo.IsMirror && !repo.IsBare
@@ -586,7 +600,7 @@ func (repo *Repository) CanEnableEditor() bool {
 // FIXME: should have a mutex to prevent producing same index for two issues that are created
 // closely enough.
 func (repo *Repository) NextIssueIndex() int64 {
-	return int64(repo.NumIssues+repo.NumPulls) + 1
+	return int64(repo.NumIssues+repo.NumPulls+repo.NumTransferredIssues) + 1
 }
 
 func (repo *Repository) LocalCopyPath() string {
@@ -1166,7 +1180,14 @@ func CreateRepository(doer, owner *User, opts CreateRepoOptions) (_ *Repository,
 		}
 	}
 
-	return repo, sess.Commit()
+	if err = sess.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err = sendRepositoryWebhook(repo.APIFormat(nil), HOOK_REPOSITORY_CREATED, doer); err != nil {
+		log.Error("sendRepositoryWebhook [repo_id: %d]: %v", repo.ID, err)
+	}
+	return repo, nil
 }
 
 func countRepositories(userID int64, private bool) int64 {
@@ -1531,6 +1552,13 @@ func DeleteRepository(ownerID, repoID int64) error {
 		}
 	}
 
+	// Payload of system webhooks must be formatted before the repository is gone.
+	repo.Owner = org
+	if err = repo.LoadAttributes(); err != nil {
+		return fmt.Errorf("load attributes: %v", err)
+	}
+	apiRepo := repo.APIFormat(nil)
+
 	sess := x.NewSession()
 	defer sess.Close()
 	if err = sess.Begin(); err != nil {
@@ -1555,6 +1583,8 @@ func DeleteRepository(ownerID, repoID int64) error {
 		&Star{RepoID: repoID},
 		&Mirror{RepoID: repoID},
 		&IssueUser{RepoID: repoID},
+		&IssueRedirect{RepoID: repoID},
+		&Notification{RepoID: repoID},
 		&Milestone{RepoID: repoID},
 		&Release{RepoID: repoID},
 		&Collaboration{RepoID: repoID},
@@ -1564,6 +1594,7 @@ func DeleteRepository(ownerID, repoID int64) error {
 		&Webhook{RepoID: repoID},
 		&HookTask{RepoID: repoID},
 		&LFSObject{RepoID: repoID},
+		&CommitStatus{RepoID: repoID},
 	); err != nil {
 		return fmt.Errorf("deleteBeans: %v", err)
 	}
@@ -1577,6 +1608,22 @@ func DeleteRepository(ownerID, repoID int64) error {
 	for i := range issues {
 		if _, err = sess.Delete(&Comment{IssueID: issues[i].ID}); err != nil {
 			return err
+		} else if _, err = sess.Delete(&IssueAssignee{IssueID: issues[i].ID}); err != nil {
+			return err
+		} else if _, err = sess.Where("issue_id = ? OR dependency_id = ?", issues[i].ID, issues[i].ID).Delete(new(IssueDependency)); err != nil {
+			return err
+		} else if _, err = sess.Delete(&TrackedTime{IssueID: issues[i].ID}); err != nil {
+			return err
+		} else if _, err = sess.Delete(&Stopwatch{IssueID: issues[i].ID}); err != nil {
+			return err
+		} else if _, err = sess.Delete(&Reaction{IssueID: issues[i].ID}); err != nil {
+			return err
+		} else if _, err = sess.Delete(&IssueRedirect{IssueID: issues[i].ID}); err != nil {
+			return err
+		} else if _, err = sess.Delete(&Review{IssueID: issues[i].ID}); err != nil {
+			return err
+		} else if _, err = sess.Delete(&ReviewRequest{IssueID: issues[i].ID}); err != nil {
+			return err
 		}
 
 		attachments := make([]*Attachment, 0, 5)
@@ -1610,6 +1657,11 @@ func DeleteRepository(ownerID, repoID int64) error {
 		return fmt.Errorf("Commit: %v", err)
 	}
 
+	// Remove issues from the search index.
+	for i := range issues {
+		updateIssueIndexer(issues[i].ID)
+	}
+
 	// Remove repository files.
 	repoPath := repo.RepoPath()
 	RemoveAllWithNotice("Delete repository files", repoPath)
@@ -1627,6 +1679,9 @@ func DeleteRepository(ownerID, repoID int64) error {
 		}
 	}
 
+	if err = sendRepositoryWebhook(apiRepo, HOOK_REPOSITORY_DELETED, nil); err != nil {
+		log.Error("sendRepositoryWebhook [repo_id: %d]: %v", repo.ID, err)
+	}
 	return nil
 }
 
@@ -2325,6 +2380,10 @@ func notifyWatchers(e Engine, act *Action) error {
 			return fmt.Errorf("insert new action: %v", err)
 		}
 	}
+
+	if err = notifyWatchersByAction(e, act, watchers); err != nil {
+		return fmt.Errorf("notify watchers by action: %v", err)
+	}
 	return nil
 }
 
@@ -2425,6 +2484,7 @@ func ForkRepository(doer, owner *User, baseRepo *Repository, name, desc string)
 		IsPrivate:     baseRepo.IsPrivate,
 		IsFork:        true,
 		ForkID:        baseRepo.ID,
+		BaseRepo:      baseRepo,
 	}
 
 	sess := x.NewSession()
@@ -2474,6 +2534,9 @@ func ForkRepository(doer, owner *User, baseRepo *Repository, name, desc string)
 	}); err != nil {
 		log.Error("PrepareWebhooks [repo_id: %d]: %v", baseRepo.ID, err)
 	}
+	if err = sendRepositoryWebhook(repo.APIFormat(nil), HOOK_REPOSITORY_CREATED, doer); err != nil {
+		log.Error("sendRepositoryWebhook [repo_id: %d]: %v", repo.ID, err)
+	}
 	return repo, nil
 }
 
@@ -109,6 +109,26 @@ type ProtectBranch struct {
 	EnableWhitelist    bool
 	WhitelistUserIDs   string `xorm:"TEXT"`
 	WhitelistTeamIDs   string `xorm:"TEXT"`
+
+	// Requirements for merging pull requests into the branch.
+	RequiredApprovals    int
+	RequiredStatusChecks string `xorm:"TEXT"` // Contexts of status checks, one per line
+	// Whether whitelisted users are allowed to merge when requirements are not met.
+	WhitelistCanOverride bool
+	// Whether an approval from code owners of changed files is required.
+	RequireCodeOwnerApproval bool `xorm:"NOT NULL DEFAULT false"`
+}
+
+// StatusCheckContexts returns the list of contexts of required status checks.
+func (protectBranch *ProtectBranch) StatusCheckContexts() []string {
+	contexts := make([]string, 0, 3)
+	for _, context := range strings.Split(protectBranch.RequiredStatusChecks, "\n") {
+		context = strings.TrimSpace(context)
+		if len(context) > 0 {
+			contexts = append(contexts, context)
+		}
+	}
+	return contexts
 }
 
 // GetProtectBranchOfRepoByName returns *ProtectBranch by branch name in given repostiory.
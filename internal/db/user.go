@@ -576,7 +576,14 @@ func CreateUser(u *User) (err error) {
 		return err
 	}
 
-	return sess.Commit()
+	if err = sess.Commit(); err != nil {
+		return err
+	}
+
+	if err = sendUserWebhook(u, HOOK_USER_CREATED); err != nil {
+		log.Error("sendUserWebhook [user_id: %d]: %v", u.ID, err)
+	}
+	return nil
 }
 
 func countUsers(e Engine) int64 {
@@ -794,6 +801,10 @@ func deleteUser(e *xorm.Session, u *User) error {
 		&Follow{FollowID: u.ID},
 		&Action{UserID: u.ID},
 		&IssueUser{UID: u.ID},
+		&IssueAssignee{AssigneeID: u.ID},
+		&Stopwatch{UserID: u.ID},
+		&Reaction{UserID: u.ID},
+		&Notification{UserID: u.ID},
 		&EmailAddress{UID: u.ID},
 	); err != nil {
 		return fmt.Errorf("deleteBeans: %v", err)
@@ -11,6 +11,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io/ioutil"
+	"math/rand"
 	"strings"
 	"time"
 
@@ -63,14 +64,20 @@ func IsValidHookContentType(name string) bool {
 }
 
 type HookEvents struct {
-	Create       bool `json:"create"`
-	Delete       bool `json:"delete"`
-	Fork         bool `json:"fork"`
-	Push         bool `json:"push"`
-	Issues       bool `json:"issues"`
-	PullRequest  bool `json:"pull_request"`
-	IssueComment bool `json:"issue_comment"`
-	Release      bool `json:"release"`
+	Create            bool `json:"create"`
+	Delete            bool `json:"delete"`
+	Fork              bool `json:"fork"`
+	Push              bool `json:"push"`
+	Issues            bool `json:"issues"`
+	PullRequest       bool `json:"pull_request"`
+	PullRequestReview bool `json:"pull_request_review"`
+	IssueComment      bool `json:"issue_comment"`
+	Release           bool `json:"release"`
+
+	// Events below are only delivered to system webhooks.
+	Repository   bool `json:"repository"`
+	User         bool `json:"user"`
+	Organization bool `json:"organization"`
 }
 
 // HookEvent represents events that will delivery hook.
@@ -92,19 +99,20 @@ const (
 
 // Webhook represents a web hook object.
 type Webhook struct {
-	ID           int64
-	RepoID       int64
-	OrgID        int64
-	URL          string `xorm:"url TEXT"`
-	ContentType  HookContentType
-	Secret       string     `xorm:"TEXT"`
-	Events       string     `xorm:"TEXT"`
-	*HookEvent   `xorm:"-"` // LEGACY [1.0]: Cannot ignore JSON (i.e. json:"-") here, it breaks old backup archive
-	IsSSL        bool       `xorm:"is_ssl"`
-	IsActive     bool
-	HookTaskType HookTaskType
-	Meta         string     `xorm:"TEXT"` // store hook-specific attributes
-	LastStatus   HookStatus // Last delivery status
+	ID              int64
+	RepoID          int64
+	OrgID           int64
+	IsSystemWebhook bool   `xorm:"NOT NULL DEFAULT false"` // Managed by site admins and receives events of all repositories
+	URL             string `xorm:"url TEXT"`
+	ContentType     HookContentType
+	Secret          string     `xorm:"TEXT"`
+	Events          string     `xorm:"TEXT"`
+	*HookEvent      `xorm:"-"` // LEGACY [1.0]: Cannot ignore JSON (i.e. json:"-") here, it breaks old backup archive
+	IsSSL           bool       `xorm:"is_ssl"`
+	IsActive        bool
+	HookTaskType    HookTaskType
+	Meta            string     `xorm:"TEXT"` // store hook-specific attributes
+	LastStatus      HookStatus // Last delivery status
 
 	Created     time.Time `xorm:"-" json:"-"`
 	CreatedUnix int64
@@ -192,6 +200,12 @@ func (w *Webhook) HasPullRequestEvent() bool {
 		(w.ChooseEvents && w.HookEvents.PullRequest)
 }
 
+// HasPullRequestReviewEvent returns true if hook enabled pull request review event.
+func (w *Webhook) HasPullRequestReviewEvent() bool {
+	return w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.PullRequestReview)
+}
+
 // HasIssueCommentEvent returns true if hook enabled issue comment event.
 func (w *Webhook) HasIssueCommentEvent() bool {
 	return w.SendEverything ||
@@ -204,6 +218,24 @@ func (w *Webhook) HasReleaseEvent() bool {
 		(w.ChooseEvents && w.HookEvents.Release)
 }
 
+// HasRepositoryEvent returns true if hook enabled repository event.
+func (w *Webhook) HasRepositoryEvent() bool {
+	return w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.Repository)
+}
+
+// HasUserEvent returns true if hook enabled user event.
+func (w *Webhook) HasUserEvent() bool {
+	return w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.User)
+}
+
+// HasOrganizationEvent returns true if hook enabled organization event.
+func (w *Webhook) HasOrganizationEvent() bool {
+	return w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.Organization)
+}
+
 type eventChecker struct {
 	checker func() bool
 	typ     HookEventType
@@ -218,9 +250,17 @@ func (w *Webhook) EventsArray() []string {
 		{w.HasPushEvent, HOOK_EVENT_PUSH},
 		{w.HasIssuesEvent, HOOK_EVENT_ISSUES},
 		{w.HasPullRequestEvent, HOOK_EVENT_PULL_REQUEST},
+		{w.HasPullRequestReviewEvent, HOOK_EVENT_PULL_REQUEST_REVIEW},
 		{w.HasIssueCommentEvent, HOOK_EVENT_ISSUE_COMMENT},
 		{w.HasReleaseEvent, HOOK_EVENT_RELEASE},
 	}
+	if w.IsSystemWebhook {
+		eventCheckers = append(eventCheckers,
+			eventChecker{w.HasRepositoryEvent, HOOK_EVENT_REPOSITORY},
+			eventChecker{w.HasUserEvent, HOOK_EVENT_USER},
+			eventChecker{w.HasOrganizationEvent, HOOK_EVENT_ORGANIZATION},
+		)
+	}
 	for _, c := range eventCheckers {
 		if c.checker() {
 			events = append(events, string(c.typ))
@@ -309,6 +349,13 @@ func UpdateWebhook(w *Webhook) error {
 	return err
 }
 
+// sign returns the signature of the payload signed by the secret of the webhook.
+func (w *Webhook) sign(payload []byte) string {
+	sig := hmac.New(sha256.New, []byte(w.Secret))
+	_, _ = sig.Write(payload)
+	return hex.EncodeToString(sig.Sum(nil))
+}
+
 // deleteWebhook uses argument bean as query condition,
 // ID must be specified and do not assign unnecessary fields.
 func deleteWebhook(bean *Webhook) (err error) {
@@ -406,14 +453,18 @@ func IsValidHookTaskType(name string) bool {
 type HookEventType string
 
 const (
-	HOOK_EVENT_CREATE        HookEventType = "create"
-	HOOK_EVENT_DELETE        HookEventType = "delete"
-	HOOK_EVENT_FORK          HookEventType = "fork"
-	HOOK_EVENT_PUSH          HookEventType = "push"
-	HOOK_EVENT_ISSUES        HookEventType = "issues"
-	HOOK_EVENT_PULL_REQUEST  HookEventType = "pull_request"
-	HOOK_EVENT_ISSUE_COMMENT HookEventType = "issue_comment"
-	HOOK_EVENT_RELEASE       HookEventType = "release"
+	HOOK_EVENT_CREATE              HookEventType = "create"
+	HOOK_EVENT_DELETE              HookEventType = "delete"
+	HOOK_EVENT_FORK                HookEventType = "fork"
+	HOOK_EVENT_PUSH                HookEventType = "push"
+	HOOK_EVENT_ISSUES              HookEventType = "issues"
+	HOOK_EVENT_PULL_REQUEST        HookEventType = "pull_request"
+	HOOK_EVENT_PULL_REQUEST_REVIEW HookEventType = "pull_request_review"
+	HOOK_EVENT_ISSUE_COMMENT       HookEventType = "issue_comment"
+	HOOK_EVENT_RELEASE             HookEventType = "release"
+	HOOK_EVENT_REPOSITORY          HookEventType = "repository"
+	HOOK_EVENT_USER                HookEventType = "user"
+	HOOK_EVENT_ORGANIZATION        HookEventType = "organization"
 )
 
 // HookRequest represents hook task request information.
@@ -428,6 +479,19 @@ type HookResponse struct {
 	Body    string            `json:"body"`
 }
 
+// HookAttempt represents information of an attempt to deliver a hook task.
+type HookAttempt struct {
+	Attempt   int    `json:"attempt"`
+	Delivered int64  `json:"delivered"`
+	Status    int    `json:"status"` // HTTP status code of the response, 0 if no response.
+	Error     string `json:"error,omitempty"`
+	IsSucceed bool   `json:"is_succeed"`
+}
+
+func (a *HookAttempt) DeliveredString() string {
+	return time.Unix(0, a.Delivered).Format("2006-01-02 15:04:05 MST")
+}
+
 // HookTask represents a hook task.
 type HookTask struct {
 	ID              int64
@@ -452,6 +516,16 @@ type HookTask struct {
 	RequestInfo     *HookRequest  `xorm:"-" json:"-"`
 	ResponseContent string        `xorm:"TEXT"`
 	ResponseInfo    *HookResponse `xorm:"-" json:"-"`
+
+	// Retry info.
+	Attempts          int
+	AttemptsContent   string         `xorm:"TEXT"`
+	AttemptHistory    []*HookAttempt `xorm:"-" json:"-"`
+	NextAttemptUnix   int64          `xorm:"INDEX NOT NULL DEFAULT 0"` // 0 if no further attempt is scheduled.
+	NextAttemptString string         `xorm:"-" json:"-"`
+
+	// ID of the hook task whose payload is delivered again by this task.
+	RedeliveryOf int64 `xorm:"INDEX NOT NULL DEFAULT 0"`
 }
 
 func (t *HookTask) BeforeUpdate() {
@@ -461,6 +535,9 @@ func (t *HookTask) BeforeUpdate() {
 	if t.ResponseInfo != nil {
 		t.ResponseContent = t.ToJSON(t.ResponseInfo)
 	}
+	if t.AttemptHistory != nil {
+		t.AttemptsContent = t.ToJSON(t.AttemptHistory)
+	}
 }
 
 func (t *HookTask) AfterSet(colName string, _ xorm.Cell) {
@@ -488,6 +565,20 @@ func (t *HookTask) AfterSet(colName string, _ xorm.Cell) {
 		if err = jsoniter.Unmarshal([]byte(t.ResponseContent), t.ResponseInfo); err != nil {
 			log.Error("Unmarshal [%d]: %v", t.ID, err)
 		}
+
+	case "attempts_content":
+		if len(t.AttemptsContent) == 0 {
+			return
+		}
+
+		if err = jsoniter.Unmarshal([]byte(t.AttemptsContent), &t.AttemptHistory); err != nil {
+			log.Error("Unmarshal [%d]: %v", t.ID, err)
+		}
+
+	case "next_attempt_unix":
+		if t.NextAttemptUnix > 0 {
+			t.NextAttemptString = time.Unix(t.NextAttemptUnix, 0).Format("2006-01-02 15:04:05 MST")
+		}
 	}
 }
 
@@ -552,6 +643,90 @@ func GetHookTaskOfWebhookByUUID(webhookID int64, uuid string) (*HookTask, error)
 	return hookTask, nil
 }
 
+// GetHookTaskOfWebhookByID returns hook task of given webhook by ID.
+func GetHookTaskOfWebhookByID(webhookID, id int64) (*HookTask, error) {
+	hookTask := new(HookTask)
+	has, err := x.Where("id = ? AND hook_id = ?", id, webhookID).Get(hookTask)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrHookTaskNotExist{args: map[string]interface{}{"webhookID": webhookID, "id": id}}
+	}
+	return hookTask, nil
+}
+
+// Redeliver creates a new hook task that delivers the stored payload of given task
+// again with current settings of the webhook, and adds it to task queue. The new
+// task is linked to the given task.
+func (w *Webhook) Redeliver(t *HookTask) (*HookTask, error) {
+	redelivery := &HookTask{
+		RepoID:         t.RepoID,
+		HookID:         w.ID,
+		UUID:           gouuid.NewV4().String(),
+		Type:           w.HookTaskType,
+		URL:            w.URL,
+		PayloadContent: t.PayloadContent,
+		ContentType:    w.ContentType,
+		EventType:      t.EventType,
+		IsSSL:          w.IsSSL,
+		RedeliveryOf:   t.ID,
+	}
+	if len(w.Secret) > 0 {
+		redelivery.Signature = w.sign([]byte(t.PayloadContent))
+	}
+	if _, err := x.Insert(redelivery); err != nil {
+		return nil, err
+	}
+
+	go HookQueue.Add(redelivery.RepoID)
+	return redelivery, nil
+}
+
+// APIHookDelivery represents a hook task in API format.
+type APIHookDelivery struct {
+	ID             int64          `json:"id"`
+	UUID           string         `json:"uuid"`
+	Event          string         `json:"event"`
+	URL            string         `json:"url"`
+	RedeliveryOf   int64          `json:"redelivery_of,omitempty"`
+	IsDelivered    bool           `json:"is_delivered"`
+	IsSucceed      bool           `json:"is_succeed"`
+	StatusCode     int            `json:"status_code"`
+	Attempts       int            `json:"attempts"`
+	AttemptHistory []*HookAttempt `json:"attempt_history"`
+	Delivered      *time.Time     `json:"delivered_at"`
+	NextAttempt    *time.Time     `json:"next_attempt_at"`
+}
+
+func (t *HookTask) APIFormat() *APIHookDelivery {
+	d := &APIHookDelivery{
+		ID:             t.ID,
+		UUID:           t.UUID,
+		Event:          string(t.EventType),
+		URL:            t.URL,
+		RedeliveryOf:   t.RedeliveryOf,
+		IsDelivered:    t.IsDelivered,
+		IsSucceed:      t.IsSucceed,
+		Attempts:       t.Attempts,
+		AttemptHistory: t.AttemptHistory,
+	}
+	if t.ResponseInfo != nil {
+		d.StatusCode = t.ResponseInfo.Status
+	}
+	if t.Delivered > 0 {
+		delivered := time.Unix(0, t.Delivered)
+		d.Delivered = &delivered
+	}
+	if t.NextAttemptUnix > 0 {
+		nextAttempt := time.Unix(t.NextAttemptUnix, 0)
+		d.NextAttempt = &nextAttempt
+	}
+	if d.AttemptHistory == nil {
+		d.AttemptHistory = []*HookAttempt{}
+	}
+	return d
+}
+
 // UpdateHookTask updates information of hook task.
 func UpdateHookTask(t *HookTask) error {
 	_, err := x.Id(t.ID).AllCols().Update(t)
@@ -559,7 +734,7 @@ func UpdateHookTask(t *HookTask) error {
 }
 
 // prepareHookTasks adds list of webhooks to task queue.
-func prepareHookTasks(e Engine, repo *Repository, event HookEventType, p api.Payloader, webhooks []*Webhook) (err error) {
+func prepareHookTasks(e Engine, repoID int64, event HookEventType, p api.Payloader, webhooks []*Webhook) (err error) {
 	if len(webhooks) == 0 {
 		return nil
 	}
@@ -591,6 +766,10 @@ func prepareHookTasks(e Engine, repo *Repository, event HookEventType, p api.Pay
 			if !w.HasPullRequestEvent() {
 				continue
 			}
+		case HOOK_EVENT_PULL_REQUEST_REVIEW:
+			if !w.HasPullRequestReviewEvent() {
+				continue
+			}
 		case HOOK_EVENT_ISSUE_COMMENT:
 			if !w.HasIssueCommentEvent() {
 				continue
@@ -599,6 +778,18 @@ func prepareHookTasks(e Engine, repo *Repository, event HookEventType, p api.Pay
 			if !w.HasReleaseEvent() {
 				continue
 			}
+		case HOOK_EVENT_REPOSITORY:
+			if !w.HasRepositoryEvent() {
+				continue
+			}
+		case HOOK_EVENT_USER:
+			if !w.HasUserEvent() {
+				continue
+			}
+		case HOOK_EVENT_ORGANIZATION:
+			if !w.HasOrganizationEvent() {
+				continue
+			}
 		}
 
 		// Use separate objects so modifcations won't be made on payload on non-Gogs type hooks.
@@ -628,13 +819,11 @@ func prepareHookTasks(e Engine, repo *Repository, event HookEventType, p api.Pay
 			if err != nil {
 				log.Error("prepareWebhooks.JSONPayload: %v", err)
 			}
-			sig := hmac.New(sha256.New, []byte(w.Secret))
-			_, _ = sig.Write(data)
-			signature = hex.EncodeToString(sig.Sum(nil))
+			signature = w.sign(data)
 		}
 
 		if err = createHookTask(e, &HookTask{
-			RepoID:      repo.ID,
+			RepoID:      repoID,
 			HookID:      w.ID,
 			Type:        w.HookTaskType,
 			URL:         w.URL,
@@ -651,7 +840,7 @@ func prepareHookTasks(e Engine, repo *Repository, event HookEventType, p api.Pay
 	// It's safe to fail when the whole function is called during hook execution
 	// because resource released after exit. Also, there is no process started to
 	// consume this input during hook execution.
-	go HookQueue.Add(repo.ID)
+	go HookQueue.Add(repoID)
 	return nil
 }
 
@@ -670,7 +859,13 @@ func prepareWebhooks(e Engine, repo *Repository, event HookEventType, p api.Payl
 		}
 		webhooks = append(webhooks, orgws...)
 	}
-	return prepareHookTasks(e, repo, event, p, webhooks)
+
+	systemws, err := getActiveSystemWebhooks(e)
+	if err != nil {
+		return fmt.Errorf("getActiveSystemWebhooks: %v", err)
+	}
+	webhooks = append(webhooks, systemws...)
+	return prepareHookTasks(e, repo.ID, event, p, webhooks)
 }
 
 // PrepareWebhooks adds all active webhooks to task queue.
@@ -684,11 +879,46 @@ func TestWebhook(repo *Repository, event HookEventType, p api.Payloader, webhook
 	if err != nil {
 		return fmt.Errorf("GetWebhookOfRepoByID [repo_id: %d, id: %d]: %v", repo.ID, webhookID, err)
 	}
-	return prepareHookTasks(x, repo, event, p, []*Webhook{webhook})
+	return prepareHookTasks(x, repo.ID, event, p, []*Webhook{webhook})
+}
+
+// hookRetryDelay returns the delay before the next attempt after given number of
+// attempts. The delay doubles for every attempt up to the maximum retry interval,
+// and a random jitter of up to half of the delay is applied to spread retries.
+func hookRetryDelay(attempts int) time.Duration {
+	delay := conf.Webhook.RetryInterval
+	for i := 1; i < attempts && delay < conf.Webhook.MaxRetryInterval; i++ {
+		delay *= 2
+	}
+	if delay > conf.Webhook.MaxRetryInterval {
+		delay = conf.Webhook.MaxRetryInterval
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return delay - half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// scheduleRetry queues the repository of the task again when its next attempt is due.
+func (t *HookTask) scheduleRetry() {
+	if t.NextAttemptUnix <= 0 {
+		return
+	}
+	repoID := t.RepoID
+	time.AfterFunc(time.Until(time.Unix(t.NextAttemptUnix, 0)), func() {
+		HookQueue.Add(repoID)
+	})
 }
 
 func (t *HookTask) deliver() {
 	t.IsDelivered = true
+	t.IsSucceed = false
+	t.Attempts++
+	t.NextAttemptUnix = 0
+	t.NextAttemptString = ""
+	retryable := false
 
 	timeout := time.Duration(conf.Webhook.DeliverTimeout) * time.Second
 	req := httplib.Post(t.URL).SetTimeout(timeout, timeout).
@@ -720,9 +950,27 @@ func (t *HookTask) deliver() {
 
 	defer func() {
 		t.Delivered = time.Now().UnixNano()
-		if t.IsSucceed {
+		attempt := &HookAttempt{
+			Attempt:   t.Attempts,
+			Delivered: t.Delivered,
+			Status:    t.ResponseInfo.Status,
+			IsSucceed: t.IsSucceed,
+		}
+		if !t.IsSucceed && t.ResponseInfo.Status == 0 {
+			attempt.Error = t.ResponseInfo.Body
+		}
+		t.AttemptHistory = append(t.AttemptHistory, attempt)
+
+		switch {
+		case t.IsSucceed:
 			log.Trace("Hook delivered: %s", t.UUID)
-		} else {
+		case retryable && t.Attempts < conf.Webhook.MaxAttempts:
+			// Keep the task undelivered until the next attempt.
+			t.IsDelivered = false
+			t.NextAttemptUnix = time.Now().Add(hookRetryDelay(t.Attempts)).Unix()
+			t.NextAttemptString = time.Unix(t.NextAttemptUnix, 0).Format("2006-01-02 15:04:05 MST")
+			log.Trace("Hook delivery failed: %s, attempt %d will be made at %s", t.UUID, t.Attempts+1, t.NextAttemptString)
+		default:
 			log.Trace("Hook delivery failed: %s", t.UUID)
 		}
 
@@ -745,13 +993,17 @@ func (t *HookTask) deliver() {
 
 	resp, err := req.Response()
 	if err != nil {
+		// Network errors are likely to be temporary.
+		retryable = true
 		t.ResponseInfo.Body = fmt.Sprintf("Delivery: %v", err)
 		return
 	}
 	defer resp.Body.Close()
 
-	// Status code is 20x can be seen as succeed.
+	// Status code is 20x can be seen as succeed, and only server errors are worth
+	// retrying because the same request will always be rejected otherwise.
 	t.IsSucceed = resp.StatusCode/100 == 2
+	retryable = resp.StatusCode/100 == 5
 	t.ResponseInfo.Status = resp.StatusCode
 	for k, vals := range resp.Header {
 		t.ResponseInfo.Headers[k] = strings.Join(vals, ",")
@@ -765,13 +1017,19 @@ func (t *HookTask) deliver() {
 	t.ResponseInfo.Body = string(p)
 }
 
-// DeliverHooks checks and delivers undelivered hooks.
+// DeliverHooks checks and delivers undelivered hooks, undelivered hooks whose next
+// attempts are not yet due are scheduled to be delivered later.
 // TODO: shoot more hooks at same time.
 func DeliverHooks() {
 	tasks := make([]*HookTask, 0, 10)
+	now := time.Now().Unix()
 	_ = x.Where("is_delivered = ?", false).Iterate(new(HookTask),
 		func(idx int, bean interface{}) error {
 			t := bean.(*HookTask)
+			if t.NextAttemptUnix > now {
+				t.scheduleRetry()
+				return nil
+			}
 			t.deliver()
 			tasks = append(tasks, t)
 			return nil
@@ -781,7 +1039,9 @@ func DeliverHooks() {
 	for _, t := range tasks {
 		if err := UpdateHookTask(t); err != nil {
 			log.Error("UpdateHookTask [%d]: %v", t.ID, err)
+			continue
 		}
+		t.scheduleRetry()
 	}
 
 	// Start listening on new hook requests.
@@ -790,7 +1050,8 @@ func DeliverHooks() {
 		HookQueue.Remove(repoID)
 
 		tasks = make([]*HookTask, 0, 5)
-		if err := x.Where("repo_id = ?", repoID).And("is_delivered = ?", false).Find(&tasks); err != nil {
+		if err := x.Where("repo_id = ?", repoID).And("is_delivered = ?", false).
+			And("next_attempt_unix <= ?", time.Now().Unix()).Find(&tasks); err != nil {
 			log.Error("Get repository [%s] hook tasks: %v", repoID, err)
 			continue
 		}
@@ -800,6 +1061,7 @@ func DeliverHooks() {
 				log.Error("UpdateHookTask [%d]: %v", t.ID, err)
 				continue
 			}
+			t.scheduleRetry()
 		}
 	}
 }
@@ -12,6 +12,8 @@ import (
 
 	"github.com/gogs/git-module"
 	api "github.com/gogs/go-gogs-client"
+
+	"gogs.io/gogs/internal/conf"
 )
 
 const (
@@ -69,13 +71,21 @@ func GetDingtalkPayload(p api.Payloader, event HookEventType) (payload *Dingtalk
 	case HOOK_EVENT_PUSH:
 		payload, err = getDingtalkPushPayload(p.(*api.PushPayload))
 	case HOOK_EVENT_ISSUES:
-		payload, err = getDingtalkIssuesPayload(p.(*api.IssuesPayload))
+		payload, err = getDingtalkIssuesPayload(toIssuesPayload(p))
 	case HOOK_EVENT_ISSUE_COMMENT:
 		payload, err = getDingtalkIssueCommentPayload(p.(*api.IssueCommentPayload))
 	case HOOK_EVENT_PULL_REQUEST:
-		payload, err = getDingtalkPullRequestPayload(p.(*api.PullRequestPayload))
+		payload, err = getDingtalkPullRequestPayload(toPullRequestPayload(p))
+	case HOOK_EVENT_PULL_REQUEST_REVIEW:
+		payload, err = getDingtalkPullRequestReviewPayload(p.(*PullRequestReviewPayload))
 	case HOOK_EVENT_RELEASE:
 		payload, err = getDingtalkReleasePayload(p.(*api.ReleasePayload))
+	case HOOK_EVENT_REPOSITORY:
+		payload, err = getDingtalkRepositoryPayload(p.(*RepositoryPayload))
+	case HOOK_EVENT_USER:
+		payload, err = getDingtalkUserPayload(p.(*UserPayload))
+	case HOOK_EVENT_ORGANIZATION:
+		payload, err = getDingtalkOrganizationPayload(p.(*OrganizationPayload))
 	}
 
 	if err != nil {
@@ -148,7 +158,7 @@ func getDingtalkPushPayload(p *api.PushPayload) (*DingtalkPayload, error) {
 	return &DingtalkPayload{MsgType: "actionCard", ActionCard: actionCard}, nil
 }
 
-func getDingtalkIssuesPayload(p *api.IssuesPayload) (*DingtalkPayload, error) {
+func getDingtalkIssuesPayload(p *api.IssuesPayload, assignee *api.User) (*DingtalkPayload, error) {
 	issueName := fmt.Sprintf("#%d %s", p.Index, p.Issue.Title)
 	issueURL := fmt.Sprintf("%s/issues/%d", p.Repository.HTMLURL, p.Index)
 
@@ -158,7 +168,9 @@ func getDingtalkIssuesPayload(p *api.IssuesPayload) (*DingtalkPayload, error) {
 	actionCard.Text += "\n- Issue: **" + MarkdownLinkFormatter(issueURL, issueName) + "**"
 
 	if p.Action == api.HOOK_ISSUE_ASSIGNED {
-		actionCard.Text += "\n- New Assignee: **" + p.Issue.Assignee.UserName + "**"
+		actionCard.Text += "\n- New Assignee: **" + assignee.UserName + "**"
+	} else if p.Action == api.HOOK_ISSUE_UNASSIGNED {
+		actionCard.Text += "\n- Removed Assignee: **" + assignee.UserName + "**"
 	} else if p.Action == api.HOOK_ISSUE_MILESTONED {
 		actionCard.Text += "\n- New Milestone: **" + p.Issue.Milestone.Title + "**"
 	} else if p.Action == api.HOOK_ISSUE_LABEL_UPDATED {
@@ -199,17 +211,24 @@ func getDingtalkIssueCommentPayload(p *api.IssueCommentPayload) (*DingtalkPayloa
 	return &DingtalkPayload{MsgType: "actionCard", ActionCard: actionCard}, nil
 }
 
-func getDingtalkPullRequestPayload(p *api.PullRequestPayload) (*DingtalkPayload, error) {
+func getDingtalkPullRequestPayload(p *api.PullRequestPayload, assignee *api.User) (*DingtalkPayload, error) {
 	title := "# Pull Request " + strings.Title(string(p.Action))
-	if p.Action == api.HOOK_ISSUE_CLOSED && p.PullRequest.HasMerged {
+	switch {
+	case p.Action == api.HOOK_ISSUE_CLOSED && p.PullRequest.HasMerged:
 		title = "# Pull Request Merged"
+	case p.Action == HOOK_PULL_REQUEST_READY_FOR_REVIEW:
+		title = "# Pull Request Ready For Review"
+	case p.Action == HOOK_PULL_REQUEST_CONVERTED_TO_DRAFT:
+		title = "# Pull Request Converted To Draft"
 	}
 
 	pullRequestURL := fmt.Sprintf("%s/pulls/%d", p.Repository.HTMLURL, p.Index)
 
 	content := "- PR: " + MarkdownLinkFormatter(pullRequestURL, fmt.Sprintf("#%d %s", p.Index, p.PullRequest.Title))
 	if p.Action == api.HOOK_ISSUE_ASSIGNED {
-		content += "\n- New Assignee: **" + p.PullRequest.Assignee.UserName + "**"
+		content += "\n- New Assignee: **" + assignee.UserName + "**"
+	} else if p.Action == api.HOOK_ISSUE_UNASSIGNED {
+		content += "\n- Removed Assignee: **" + assignee.UserName + "**"
 	} else if p.Action == api.HOOK_ISSUE_MILESTONED {
 		content += "\n- New Milestone: *" + p.PullRequest.Milestone.Title + "*"
 	} else if p.Action == api.HOOK_ISSUE_LABEL_UPDATED {
@@ -230,6 +249,23 @@ func getDingtalkPullRequestPayload(p *api.PullRequestPayload) (*DingtalkPayload,
 	return &DingtalkPayload{MsgType: "actionCard", ActionCard: actionCard}, nil
 }
 
+func getDingtalkPullRequestReviewPayload(p *PullRequestReviewPayload) (*DingtalkPayload, error) {
+	pullRequestURL := fmt.Sprintf("%s/pulls/%d", p.Repository.HTMLURL, p.Index)
+
+	actionCard := NewDingtalkActionCard("View Review", p.Review.HTMLURL)
+
+	actionCard.Text += "# Pull Request Review " + strings.Title(string(p.Action))
+	actionCard.Text += "\n- PR: " + MarkdownLinkFormatter(pullRequestURL, fmt.Sprintf("#%d %s", p.Index, p.PullRequest.Title))
+	actionCard.Text += "\n- Reviewer: **" + p.Review.Reviewer.UserName + "**"
+	actionCard.Text += "\n- State: *" + strings.Replace(p.Review.State, "_", " ", -1) + "*"
+
+	if p.Action == HOOK_REVIEW_SUBMITTED && p.Review.Body != "" {
+		actionCard.Text += "\n> " + p.Review.Body
+	}
+
+	return &DingtalkPayload{MsgType: "actionCard", ActionCard: actionCard}, nil
+}
+
 func getDingtalkReleasePayload(p *api.ReleasePayload) (*DingtalkPayload, error) {
 	releaseURL := p.Repository.HTMLURL + "/src/" + p.Release.TagName
 
@@ -255,6 +291,45 @@ func getDingtalkReleasePayload(p *api.ReleasePayload) (*DingtalkPayload, error)
 	return &DingtalkPayload{MsgType: "actionCard", ActionCard: actionCard}, nil
 }
 
+func getDingtalkRepositoryPayload(p *RepositoryPayload) (*DingtalkPayload, error) {
+	actionCard := NewDingtalkActionCard("View Repo", p.Repository.HTMLURL)
+
+	actionCard.Text += "# Repository " + strings.Title(string(p.Action))
+	if p.Action == HOOK_REPOSITORY_DELETED {
+		actionCard.Text += "\n- Repo: **" + p.Repository.FullName + "**"
+	} else {
+		actionCard.Text += "\n- Repo: **" + MarkdownLinkFormatter(p.Repository.HTMLURL, p.Repository.FullName) + "**"
+	}
+	if p.Sender != nil {
+		actionCard.Text += "\n- Sender: **" + p.Sender.UserName + "**"
+	}
+
+	return &DingtalkPayload{MsgType: "actionCard", ActionCard: actionCard}, nil
+}
+
+func getDingtalkUserPayload(p *UserPayload) (*DingtalkPayload, error) {
+	userURL := conf.Server.ExternalURL + p.User.UserName
+
+	actionCard := NewDingtalkActionCard("View User", userURL)
+
+	actionCard.Text += "# User " + strings.Title(string(p.Action))
+	actionCard.Text += "\n- User: **" + MarkdownLinkFormatter(userURL, p.User.UserName) + "**"
+
+	return &DingtalkPayload{MsgType: "actionCard", ActionCard: actionCard}, nil
+}
+
+func getDingtalkOrganizationPayload(p *OrganizationPayload) (*DingtalkPayload, error) {
+	orgURL := conf.Server.ExternalURL + p.Organization.UserName
+
+	actionCard := NewDingtalkActionCard("View Organization", orgURL)
+
+	actionCard.Text += "# Organization " + strings.Title(string(p.Action))
+	actionCard.Text += "\n- Organization: **" + MarkdownLinkFormatter(orgURL, p.Organization.UserName) + "**"
+	actionCard.Text += "\n- Sender: **" + p.Sender.UserName + "**"
+
+	return &DingtalkPayload{MsgType: "actionCard", ActionCard: actionCard}, nil
+}
+
 //Format link addr and title into markdown style
 func MarkdownLinkFormatter(link, text string) string {
 	return "[" + text + "](" + link + ")"
@@ -170,7 +170,7 @@ func getDiscordPushPayload(p *api.PushPayload, slack *SlackMeta) (*DiscordPayloa
 	}, nil
 }
 
-func getDiscordIssuesPayload(p *api.IssuesPayload, slack *SlackMeta) (*DiscordPayload, error) {
+func getDiscordIssuesPayload(p *api.IssuesPayload, assignee *api.User, slack *SlackMeta) (*DiscordPayload, error) {
 	title := fmt.Sprintf("#%d %s", p.Index, p.Issue.Title)
 	url := fmt.Sprintf("%s/issues/%d", p.Repository.HTMLURL, p.Index)
 	content := ""
@@ -190,10 +190,14 @@ func getDiscordIssuesPayload(p *api.IssuesPayload, slack *SlackMeta) (*DiscordPa
 		title = "Issue assigned: " + title
 		fields = []*DiscordEmbedFieldObject{{
 			Name:  "New Assignee",
-			Value: p.Issue.Assignee.UserName,
+			Value: assignee.UserName,
 		}}
 	case api.HOOK_ISSUE_UNASSIGNED:
 		title = "Issue unassigned: " + title
+		fields = []*DiscordEmbedFieldObject{{
+			Name:  "Removed Assignee",
+			Value: assignee.UserName,
+		}}
 	case api.HOOK_ISSUE_LABEL_UPDATED:
 		title = "Issue labels updated: " + title
 		labels := make([]string, len(p.Issue.Labels))
@@ -281,7 +285,7 @@ func getDiscordIssueCommentPayload(p *api.IssueCommentPayload, slack *SlackMeta)
 	}, nil
 }
 
-func getDiscordPullRequestPayload(p *api.PullRequestPayload, slack *SlackMeta) (*DiscordPayload, error) {
+func getDiscordPullRequestPayload(p *api.PullRequestPayload, assignee *api.User, slack *SlackMeta) (*DiscordPayload, error) {
 	title := fmt.Sprintf("#%d %s", p.Index, p.PullRequest.Title)
 	url := fmt.Sprintf("%s/pulls/%d", p.Repository.HTMLURL, p.Index)
 	content := ""
@@ -305,10 +309,14 @@ func getDiscordPullRequestPayload(p *api.PullRequestPayload, slack *SlackMeta) (
 		title = "Pull request assigned: " + title
 		fields = []*DiscordEmbedFieldObject{{
 			Name:  "New Assignee",
-			Value: p.PullRequest.Assignee.UserName,
+			Value: assignee.UserName,
 		}}
 	case api.HOOK_ISSUE_UNASSIGNED:
 		title = "Pull request unassigned: " + title
+		fields = []*DiscordEmbedFieldObject{{
+			Name:  "Removed Assignee",
+			Value: assignee.UserName,
+		}}
 	case api.HOOK_ISSUE_LABEL_UPDATED:
 		title = "Pull request labels updated: " + title
 		labels := make([]string, len(p.PullRequest.Labels))
@@ -331,6 +339,52 @@ func getDiscordPullRequestPayload(p *api.PullRequestPayload, slack *SlackMeta) (
 		}}
 	case api.HOOK_ISSUE_DEMILESTONED:
 		title = "Pull request demilestoned: " + title
+	case HOOK_PULL_REQUEST_READY_FOR_REVIEW:
+		title = "Pull request ready for review: " + title
+	case HOOK_PULL_REQUEST_CONVERTED_TO_DRAFT:
+		title = "Pull request converted to draft: " + title
+	}
+
+	color, _ := strconv.ParseInt(strings.TrimLeft(slack.Color, "#"), 16, 32)
+	return &DiscordPayload{
+		Username:  slack.Username,
+		AvatarURL: slack.IconURL,
+		Embeds: []*DiscordEmbedObject{{
+			Title:       title,
+			Description: content,
+			URL:         url,
+			Color:       int(color),
+			Footer: &DiscordEmbedFooterObject{
+				Text: p.Repository.FullName,
+			},
+			Author: &DiscordEmbedAuthorObject{
+				Name:    p.Sender.UserName,
+				IconURL: p.Sender.AvatarUrl,
+			},
+			Fields: fields,
+		}},
+	}, nil
+}
+
+func getDiscordPullRequestReviewPayload(p *PullRequestReviewPayload, slack *SlackMeta) (*DiscordPayload, error) {
+	title := fmt.Sprintf("#%d %s", p.Index, p.PullRequest.Title)
+	url := p.Review.HTMLURL
+	content := ""
+	fields := make([]*DiscordEmbedFieldObject, 0, 1)
+	switch p.Action {
+	case HOOK_REVIEW_SUBMITTED:
+		title = "Pull request review submitted: " + title
+		content = p.Review.Body
+		fields = []*DiscordEmbedFieldObject{{
+			Name:  "State",
+			Value: strings.Replace(p.Review.State, "_", " ", -1),
+		}}
+	case HOOK_REVIEW_DISMISSED:
+		title = "Pull request review dismissed: " + title
+		fields = []*DiscordEmbedFieldObject{{
+			Name:  "Reviewer",
+			Value: p.Review.Reviewer.UserName,
+		}}
 	}
 
 	color, _ := strconv.ParseInt(strings.TrimLeft(slack.Color, "#"), 16, 32)
@@ -370,6 +424,59 @@ func getDiscordReleasePayload(p *api.ReleasePayload) (*DiscordPayload, error) {
 	}, nil
 }
 
+func getDiscordRepositoryPayload(p *RepositoryPayload) (*DiscordPayload, error) {
+	// There is nothing to link to when the repository is deleted.
+	url := ""
+	repoName := p.Repository.FullName
+	if p.Action != HOOK_REPOSITORY_DELETED {
+		url = p.Repository.HTMLURL
+		repoName = DiscordLinkFormatter(p.Repository.HTMLURL, p.Repository.FullName)
+	}
+	embed := &DiscordEmbedObject{
+		Description: fmt.Sprintf("Repository %s %s", repoName, p.Action),
+		URL:         url,
+	}
+	if p.Sender != nil {
+		embed.Author = &DiscordEmbedAuthorObject{
+			Name:    p.Sender.UserName,
+			IconURL: p.Sender.AvatarUrl,
+		}
+	}
+	return &DiscordPayload{
+		Embeds: []*DiscordEmbedObject{embed},
+	}, nil
+}
+
+func getDiscordUserPayload(p *UserPayload) (*DiscordPayload, error) {
+	userURL := conf.Server.ExternalURL + p.User.UserName
+	content := fmt.Sprintf("User %s %s", DiscordLinkFormatter(userURL, p.User.UserName), p.Action)
+	return &DiscordPayload{
+		Embeds: []*DiscordEmbedObject{{
+			Description: content,
+			URL:         userURL,
+			Author: &DiscordEmbedAuthorObject{
+				Name:    p.User.UserName,
+				IconURL: p.User.AvatarUrl,
+			},
+		}},
+	}, nil
+}
+
+func getDiscordOrganizationPayload(p *OrganizationPayload) (*DiscordPayload, error) {
+	orgURL := conf.Server.ExternalURL + p.Organization.UserName
+	content := fmt.Sprintf("Organization %s %s", DiscordLinkFormatter(orgURL, p.Organization.UserName), p.Action)
+	return &DiscordPayload{
+		Embeds: []*DiscordEmbedObject{{
+			Description: content,
+			URL:         orgURL,
+			Author: &DiscordEmbedAuthorObject{
+				Name:    p.Sender.UserName,
+				IconURL: p.Sender.AvatarUrl,
+			},
+		}},
+	}, nil
+}
+
 func GetDiscordPayload(p api.Payloader, event HookEventType, meta string) (payload *DiscordPayload, err error) {
 	slack := &SlackMeta{}
 	if err := jsoniter.Unmarshal([]byte(meta), &slack); err != nil {
@@ -386,13 +493,23 @@ func GetDiscordPayload(p api.Payloader, event HookEventType, meta string) (paylo
 	case HOOK_EVENT_PUSH:
 		payload, err = getDiscordPushPayload(p.(*api.PushPayload), slack)
 	case HOOK_EVENT_ISSUES:
-		payload, err = getDiscordIssuesPayload(p.(*api.IssuesPayload), slack)
+		issues, assignee := toIssuesPayload(p)
+		payload, err = getDiscordIssuesPayload(issues, assignee, slack)
 	case HOOK_EVENT_ISSUE_COMMENT:
 		payload, err = getDiscordIssueCommentPayload(p.(*api.IssueCommentPayload), slack)
 	case HOOK_EVENT_PULL_REQUEST:
-		payload, err = getDiscordPullRequestPayload(p.(*api.PullRequestPayload), slack)
+		pullRequest, assignee := toPullRequestPayload(p)
+		payload, err = getDiscordPullRequestPayload(pullRequest, assignee, slack)
+	case HOOK_EVENT_PULL_REQUEST_REVIEW:
+		payload, err = getDiscordPullRequestReviewPayload(p.(*PullRequestReviewPayload), slack)
 	case HOOK_EVENT_RELEASE:
 		payload, err = getDiscordReleasePayload(p.(*api.ReleasePayload))
+	case HOOK_EVENT_REPOSITORY:
+		payload, err = getDiscordRepositoryPayload(p.(*RepositoryPayload))
+	case HOOK_EVENT_USER:
+		payload, err = getDiscordUserPayload(p.(*UserPayload))
+	case HOOK_EVENT_ORGANIZATION:
+		payload, err = getDiscordOrganizationPayload(p.(*OrganizationPayload))
 	}
 	if err != nil {
 		return nil, fmt.Errorf("event '%s': %v", event, err)
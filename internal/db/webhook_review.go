@@ -0,0 +1,54 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"time"
+
+	"github.com/json-iterator/go"
+
+	api "github.com/gogs/go-gogs-client"
+)
+
+// Payloads of pull request review events, which are not supported by
+// go-gogs-client yet.
+
+// APIReview represents a pull request review in API responses and webhook payloads.
+type APIReview struct {
+	ID        int64     `json:"id"`
+	Reviewer  *api.User `json:"user"`
+	Body      string    `json:"body"`
+	State     string    `json:"state"`
+	CommitID  string    `json:"commit_id"`
+	HTMLURL   string    `json:"html_url"`
+	Submitted time.Time `json:"submitted_at"`
+	Updated   time.Time `json:"updated_at"`
+}
+
+// HookReviewAction represents the action of a pull request review webhook.
+type HookReviewAction string
+
+const (
+	HOOK_REVIEW_SUBMITTED HookReviewAction = "submitted"
+	HOOK_REVIEW_DISMISSED HookReviewAction = "dismissed"
+)
+
+// PullRequestReviewPayload represents the payload of the pull_request_review event.
+type PullRequestReviewPayload struct {
+	Action      HookReviewAction `json:"action"`
+	Index       int64            `json:"number"`
+	PullRequest *api.PullRequest `json:"pull_request"`
+	Review      *APIReview       `json:"review"`
+	Repository  *api.Repository  `json:"repository"`
+	Sender      *api.User        `json:"sender"`
+}
+
+func (p *PullRequestReviewPayload) JSONPayload() ([]byte, error) {
+	data, err := jsoniter.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return []byte{}, err
+	}
+	return data, nil
+}
@@ -146,7 +146,7 @@ func getSlackPushPayload(p *api.PushPayload, slack *SlackMeta) (*SlackPayload, e
 	}, nil
 }
 
-func getSlackIssuesPayload(p *api.IssuesPayload, slack *SlackMeta) (*SlackPayload, error) {
+func getSlackIssuesPayload(p *api.IssuesPayload, assignee *api.User, slack *SlackMeta) (*SlackPayload, error) {
 	senderLink := SlackLinkFormatter(conf.Server.ExternalURL+p.Sender.UserName, p.Sender.UserName)
 	titleLink := SlackLinkFormatter(fmt.Sprintf("%s/issues/%d", p.Repository.HTMLURL, p.Index),
 		fmt.Sprintf("#%d %s", p.Index, p.Issue.Title))
@@ -165,10 +165,12 @@ func getSlackIssuesPayload(p *api.IssuesPayload, slack *SlackMeta) (*SlackPayloa
 		attachmentText = SlackTextFormatter(p.Issue.Body)
 	case api.HOOK_ISSUE_ASSIGNED:
 		text = fmt.Sprintf("[%s] Issue assigned to %s: %s by %s", p.Repository.FullName,
-			SlackLinkFormatter(conf.Server.ExternalURL+p.Issue.Assignee.UserName, p.Issue.Assignee.UserName),
+			SlackLinkFormatter(conf.Server.ExternalURL+assignee.UserName, assignee.UserName),
 			titleLink, senderLink)
 	case api.HOOK_ISSUE_UNASSIGNED:
-		text = fmt.Sprintf("[%s] Issue unassigned: %s by %s", p.Repository.FullName, titleLink, senderLink)
+		text = fmt.Sprintf("[%s] Issue unassigned from %s: %s by %s", p.Repository.FullName,
+			SlackLinkFormatter(conf.Server.ExternalURL+assignee.UserName, assignee.UserName),
+			titleLink, senderLink)
 	case api.HOOK_ISSUE_LABEL_UPDATED:
 		text = fmt.Sprintf("[%s] Issue labels updated: %s by %s", p.Repository.FullName, titleLink, senderLink)
 	case api.HOOK_ISSUE_LABEL_CLEARED:
@@ -226,7 +228,7 @@ func getSlackIssueCommentPayload(p *api.IssueCommentPayload, slack *SlackMeta) (
 	}, nil
 }
 
-func getSlackPullRequestPayload(p *api.PullRequestPayload, slack *SlackMeta) (*SlackPayload, error) {
+func getSlackPullRequestPayload(p *api.PullRequestPayload, assignee *api.User, slack *SlackMeta) (*SlackPayload, error) {
 	senderLink := SlackLinkFormatter(conf.Server.ExternalURL+p.Sender.UserName, p.Sender.UserName)
 	titleLink := SlackLinkFormatter(fmt.Sprintf("%s/pulls/%d", p.Repository.HTMLURL, p.Index),
 		fmt.Sprintf("#%d %s", p.Index, p.PullRequest.Title))
@@ -249,10 +251,12 @@ func getSlackPullRequestPayload(p *api.PullRequestPayload, slack *SlackMeta) (*S
 		attachmentText = SlackTextFormatter(p.PullRequest.Body)
 	case api.HOOK_ISSUE_ASSIGNED:
 		text = fmt.Sprintf("[%s] Pull request assigned to %s: %s by %s", p.Repository.FullName,
-			SlackLinkFormatter(conf.Server.ExternalURL+p.PullRequest.Assignee.UserName, p.PullRequest.Assignee.UserName),
+			SlackLinkFormatter(conf.Server.ExternalURL+assignee.UserName, assignee.UserName),
 			titleLink, senderLink)
 	case api.HOOK_ISSUE_UNASSIGNED:
-		text = fmt.Sprintf("[%s] Pull request unassigned: %s by %s", p.Repository.FullName, titleLink, senderLink)
+		text = fmt.Sprintf("[%s] Pull request unassigned from %s: %s by %s", p.Repository.FullName,
+			SlackLinkFormatter(conf.Server.ExternalURL+assignee.UserName, assignee.UserName),
+			titleLink, senderLink)
 	case api.HOOK_ISSUE_LABEL_UPDATED:
 		text = fmt.Sprintf("[%s] Pull request labels updated: %s by %s", p.Repository.FullName, titleLink, senderLink)
 	case api.HOOK_ISSUE_LABEL_CLEARED:
@@ -263,6 +267,38 @@ func getSlackPullRequestPayload(p *api.PullRequestPayload, slack *SlackMeta) (*S
 		text = fmt.Sprintf("[%s] Pull request milestoned: %s by %s", p.Repository.FullName, titleLink, senderLink)
 	case api.HOOK_ISSUE_DEMILESTONED:
 		text = fmt.Sprintf("[%s] Pull request demilestoned: %s by %s", p.Repository.FullName, titleLink, senderLink)
+	case HOOK_PULL_REQUEST_READY_FOR_REVIEW:
+		text = fmt.Sprintf("[%s] Pull request ready for review: %s by %s", p.Repository.FullName, titleLink, senderLink)
+	case HOOK_PULL_REQUEST_CONVERTED_TO_DRAFT:
+		text = fmt.Sprintf("[%s] Pull request converted to draft: %s by %s", p.Repository.FullName, titleLink, senderLink)
+	}
+
+	return &SlackPayload{
+		Channel:  slack.Channel,
+		Text:     text,
+		Username: slack.Username,
+		IconURL:  slack.IconURL,
+		Attachments: []*SlackAttachment{{
+			Color: slack.Color,
+			Title: title,
+			Text:  attachmentText,
+		}},
+	}, nil
+}
+
+func getSlackPullRequestReviewPayload(p *PullRequestReviewPayload, slack *SlackMeta) (*SlackPayload, error) {
+	senderLink := SlackLinkFormatter(conf.Server.ExternalURL+p.Sender.UserName, p.Sender.UserName)
+	titleLink := SlackLinkFormatter(p.Review.HTMLURL, fmt.Sprintf("#%d %s", p.Index, p.PullRequest.Title))
+	var text, title, attachmentText string
+	switch p.Action {
+	case HOOK_REVIEW_SUBMITTED:
+		text = fmt.Sprintf("[%s] Pull request review submitted by %s: %s", p.Repository.FullName, senderLink,
+			strings.Replace(p.Review.State, "_", " ", -1))
+		title = titleLink
+		attachmentText = SlackTextFormatter(p.Review.Body)
+	case HOOK_REVIEW_DISMISSED:
+		text = fmt.Sprintf("[%s] Pull request review of %s dismissed: %s by %s", p.Repository.FullName,
+			p.Review.Reviewer.UserName, titleLink, senderLink)
 	}
 
 	return &SlackPayload{
@@ -287,6 +323,37 @@ func getSlackReleasePayload(p *api.ReleasePayload) (*SlackPayload, error) {
 	}, nil
 }
 
+func getSlackRepositoryPayload(p *RepositoryPayload) (*SlackPayload, error) {
+	// There is nothing to link to when the repository is deleted.
+	repoName := p.Repository.FullName
+	if p.Action != HOOK_REPOSITORY_DELETED {
+		repoName = SlackLinkFormatter(p.Repository.HTMLURL, p.Repository.FullName)
+	}
+	text := fmt.Sprintf("Repository %s %s", repoName, p.Action)
+	if p.Sender != nil {
+		text += " by " + p.Sender.UserName
+	}
+	return &SlackPayload{
+		Text: text,
+	}, nil
+}
+
+func getSlackUserPayload(p *UserPayload) (*SlackPayload, error) {
+	userLink := SlackLinkFormatter(conf.Server.ExternalURL+p.User.UserName, p.User.UserName)
+	text := fmt.Sprintf("User %s %s", userLink, p.Action)
+	return &SlackPayload{
+		Text: text,
+	}, nil
+}
+
+func getSlackOrganizationPayload(p *OrganizationPayload) (*SlackPayload, error) {
+	orgLink := SlackLinkFormatter(conf.Server.ExternalURL+p.Organization.UserName, p.Organization.UserName)
+	text := fmt.Sprintf("Organization %s %s by %s", orgLink, p.Action, p.Sender.UserName)
+	return &SlackPayload{
+		Text: text,
+	}, nil
+}
+
 func GetSlackPayload(p api.Payloader, event HookEventType, meta string) (payload *SlackPayload, err error) {
 	slack := &SlackMeta{}
 	if err := jsoniter.Unmarshal([]byte(meta), &slack); err != nil {
@@ -303,13 +370,23 @@ func GetSlackPayload(p api.Payloader, event HookEventType, meta string) (payload
 	case HOOK_EVENT_PUSH:
 		payload, err = getSlackPushPayload(p.(*api.PushPayload), slack)
 	case HOOK_EVENT_ISSUES:
-		payload, err = getSlackIssuesPayload(p.(*api.IssuesPayload), slack)
+		issues, assignee := toIssuesPayload(p)
+		payload, err = getSlackIssuesPayload(issues, assignee, slack)
 	case HOOK_EVENT_ISSUE_COMMENT:
 		payload, err = getSlackIssueCommentPayload(p.(*api.IssueCommentPayload), slack)
 	case HOOK_EVENT_PULL_REQUEST:
-		payload, err = getSlackPullRequestPayload(p.(*api.PullRequestPayload), slack)
+		pullRequest, assignee := toPullRequestPayload(p)
+		payload, err = getSlackPullRequestPayload(pullRequest, assignee, slack)
+	case HOOK_EVENT_PULL_REQUEST_REVIEW:
+		payload, err = getSlackPullRequestReviewPayload(p.(*PullRequestReviewPayload), slack)
 	case HOOK_EVENT_RELEASE:
 		payload, err = getSlackReleasePayload(p.(*api.ReleasePayload))
+	case HOOK_EVENT_REPOSITORY:
+		payload, err = getSlackRepositoryPayload(p.(*RepositoryPayload))
+	case HOOK_EVENT_USER:
+		payload, err = getSlackUserPayload(p.(*UserPayload))
+	case HOOK_EVENT_ORGANIZATION:
+		payload, err = getSlackOrganizationPayload(p.(*OrganizationPayload))
 	}
 	if err != nil {
 		return nil, fmt.Errorf("event '%s': %v", event, err)
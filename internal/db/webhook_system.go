@@ -0,0 +1,161 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"fmt"
+
+	"github.com/json-iterator/go"
+
+	api "github.com/gogs/go-gogs-client"
+)
+
+// System webhooks are configured by site admins, they receive events of every
+// repository as well as events of the instance that do not belong to any
+// repository, e.g. creation of users and organizations.
+
+// GetSystemWebhooks returns all system webhooks.
+func GetSystemWebhooks() ([]*Webhook, error) {
+	webhooks := make([]*Webhook, 0, 5)
+	return webhooks, x.Where("is_system_webhook = ?", true).Asc("id").Find(&webhooks)
+}
+
+// getActiveSystemWebhooks returns all active system webhooks.
+func getActiveSystemWebhooks(e Engine) ([]*Webhook, error) {
+	webhooks := make([]*Webhook, 0, 5)
+	return webhooks, e.Where("is_system_webhook = ?", true).And("is_active = ?", true).Find(&webhooks)
+}
+
+// GetSystemWebhookByID returns system webhook by given ID.
+func GetSystemWebhookByID(id int64) (*Webhook, error) {
+	return getWebhook(&Webhook{
+		ID:              id,
+		IsSystemWebhook: true,
+	})
+}
+
+// DeleteSystemWebhookByID deletes system webhook by given ID.
+func DeleteSystemWebhookByID(id int64) error {
+	return deleteWebhook(&Webhook{
+		ID:              id,
+		IsSystemWebhook: true,
+	})
+}
+
+// prepareSystemWebhooks adds all active system webhooks to task queue for the
+// event that does not belong to any repository.
+func prepareSystemWebhooks(e Engine, event HookEventType, p api.Payloader) error {
+	webhooks, err := getActiveSystemWebhooks(e)
+	if err != nil {
+		return fmt.Errorf("getActiveSystemWebhooks: %v", err)
+	}
+	return prepareHookTasks(e, 0, event, p, webhooks)
+}
+
+// HookRepositoryAction represents the action of a repository webhook.
+type HookRepositoryAction string
+
+const (
+	HOOK_REPOSITORY_CREATED HookRepositoryAction = "created"
+	HOOK_REPOSITORY_DELETED HookRepositoryAction = "deleted"
+)
+
+// RepositoryPayload represents the payload of the repository event.
+type RepositoryPayload struct {
+	Action     HookRepositoryAction `json:"action"`
+	Repository *api.Repository      `json:"repository"`
+	// Sender is nil when the acting user is unknown, e.g. the repository is
+	// deleted along with its owner.
+	Sender *api.User `json:"sender,omitempty"`
+}
+
+func (p *RepositoryPayload) JSONPayload() ([]byte, error) {
+	data, err := jsoniter.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return []byte{}, err
+	}
+	return data, nil
+}
+
+// HookUserAction represents the action of a user webhook.
+type HookUserAction string
+
+const (
+	HOOK_USER_CREATED HookUserAction = "created"
+)
+
+// UserPayload represents the payload of the user event.
+type UserPayload struct {
+	Action HookUserAction `json:"action"`
+	User   *api.User      `json:"user"`
+}
+
+func (p *UserPayload) JSONPayload() ([]byte, error) {
+	data, err := jsoniter.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return []byte{}, err
+	}
+	return data, nil
+}
+
+// HookOrganizationAction represents the action of an organization webhook.
+type HookOrganizationAction string
+
+const (
+	HOOK_ORGANIZATION_CREATED HookOrganizationAction = "created"
+)
+
+// OrganizationPayload represents the payload of the organization event.
+type OrganizationPayload struct {
+	Action       HookOrganizationAction `json:"action"`
+	Organization *api.Organization      `json:"organization"`
+	Sender       *api.User              `json:"sender"`
+}
+
+func (p *OrganizationPayload) JSONPayload() ([]byte, error) {
+	data, err := jsoniter.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return []byte{}, err
+	}
+	return data, nil
+}
+
+// sendRepositoryWebhook adds system webhooks of the repository event to task
+// queue, the sender can be nil if it is unknown.
+func sendRepositoryWebhook(apiRepo *api.Repository, action HookRepositoryAction, sender *User) error {
+	p := &RepositoryPayload{
+		Action:     action,
+		Repository: apiRepo,
+	}
+	if sender != nil {
+		p.Sender = sender.APIFormat()
+	}
+	return prepareSystemWebhooks(x, HOOK_EVENT_REPOSITORY, p)
+}
+
+// sendUserWebhook adds system webhooks of the user event to task queue.
+func sendUserWebhook(u *User, action HookUserAction) error {
+	return prepareSystemWebhooks(x, HOOK_EVENT_USER, &UserPayload{
+		Action: action,
+		User:   u.APIFormat(),
+	})
+}
+
+// sendOrganizationWebhook adds system webhooks of the organization event to task queue.
+func sendOrganizationWebhook(org *User, action HookOrganizationAction, sender *User) error {
+	return prepareSystemWebhooks(x, HOOK_EVENT_ORGANIZATION, &OrganizationPayload{
+		Action: action,
+		Organization: &api.Organization{
+			ID:          org.ID,
+			AvatarUrl:   org.AvatarLink(),
+			UserName:    org.Name,
+			FullName:    org.FullName,
+			Description: org.Description,
+			Website:     org.Website,
+			Location:    org.Location,
+		},
+		Sender: sender.APIFormat(),
+	})
+}
@@ -0,0 +1,82 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"gogs.io/gogs/internal/conf"
+)
+
+func Test_hookRetryDelay(t *testing.T) {
+	oldInterval, oldMaxInterval := conf.Webhook.RetryInterval, conf.Webhook.MaxRetryInterval
+	defer func() {
+		conf.Webhook.RetryInterval, conf.Webhook.MaxRetryInterval = oldInterval, oldMaxInterval
+	}()
+	conf.Webhook.RetryInterval = time.Minute
+	conf.Webhook.MaxRetryInterval = 10 * time.Minute
+
+	for _, test := range []struct {
+		attempts int
+		max      time.Duration
+	}{
+		{attempts: 1, max: time.Minute},
+		{attempts: 2, max: 2 * time.Minute},
+		{attempts: 3, max: 4 * time.Minute},
+		{attempts: 4, max: 8 * time.Minute},
+		{attempts: 5, max: 10 * time.Minute},
+		{attempts: 50, max: 10 * time.Minute},
+	} {
+		for i := 0; i < 10; i++ {
+			delay := hookRetryDelay(test.attempts)
+			assert.True(t, delay >= test.max/2 && delay <= test.max, "attempts %d: %v", test.attempts, delay)
+		}
+	}
+
+	conf.Webhook.RetryInterval = 0
+	assert.Zero(t, hookRetryDelay(1))
+}
+
+func TestHookTask_APIFormat(t *testing.T) {
+	d := (&HookTask{
+		ID:           2,
+		EventType:    HOOK_EVENT_PUSH,
+		RedeliveryOf: 1,
+	}).APIFormat()
+	assert.Equal(t, "push", d.Event)
+	assert.Equal(t, int64(1), d.RedeliveryOf)
+	assert.Nil(t, d.Delivered)
+	assert.Nil(t, d.NextAttempt)
+	assert.NotNil(t, d.AttemptHistory)
+
+	d = (&HookTask{
+		Delivered:       time.Unix(100, 0).UnixNano(),
+		NextAttemptUnix: 200,
+		ResponseInfo:    &HookResponse{Status: 502},
+	}).APIFormat()
+	assert.Equal(t, int64(100), d.Delivered.Unix())
+	assert.Equal(t, int64(200), d.NextAttempt.Unix())
+	assert.Equal(t, 502, d.StatusCode)
+}
+
+func TestWebhook_EventsArray(t *testing.T) {
+	w := &Webhook{
+		HookEvent: &HookEvent{
+			ChooseEvents: true,
+			HookEvents: HookEvents{
+				Push:       true,
+				Repository: true,
+				User:       true,
+			},
+		},
+	}
+	assert.Equal(t, []string{"push"}, w.EventsArray())
+
+	w.IsSystemWebhook = true
+	assert.Equal(t, []string{"push", "repository", "user"}, w.EventsArray())
+}
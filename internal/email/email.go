@@ -97,6 +97,7 @@ type Repository interface {
 }
 
 type Issue interface {
+	ID() int64
 	MailSubject() string
 	Content() string
 	HTMLURL() string
@@ -195,34 +196,59 @@ func composeTplData(subject, body, link string) map[string]interface{} {
 	return data
 }
 
-func composeIssueMessage(issue Issue, repo Repository, doer User, tplName string, tos []string, info string) *Message {
+// composeIssueMessages composes issue emails to target receivers. A message is
+// composed for each receiver with its own reply-to address when replying by email
+// is enabled, otherwise a single message is composed for all receivers.
+func composeIssueMessages(issue Issue, repo Repository, doer User, tplName string, tos []User, info string) []*Message {
 	subject := issue.MailSubject()
 	body := string(markup.Markdown([]byte(issue.Content()), repo.HTMLURL(), repo.ComposeMetas()))
 	data := composeTplData(subject, body, issue.HTMLURL())
 	data["Doer"] = doer
+	data["CanReply"] = conf.IncomingEmail.Enabled
 	content, err := render(tplName, data)
 	if err != nil {
 		log.Error("HTMLString (%s): %v", tplName, err)
 	}
 	from := gomail.NewMessage().FormatAddress(conf.Email.FromEmail, doer.DisplayName())
-	msg := NewMessageFrom(tos, from, subject, content)
-	msg.Info = fmt.Sprintf("Subject: %s, %s", subject, info)
-	return msg
+
+	if !conf.IncomingEmail.Enabled {
+		emails := make([]string, len(tos))
+		for i := range tos {
+			emails[i] = tos[i].Email()
+		}
+		msg := NewMessageFrom(emails, from, subject, content)
+		msg.Info = fmt.Sprintf("Subject: %s, %s", subject, info)
+		return []*Message{msg}
+	}
+
+	msgs := make([]*Message, len(tos))
+	for i := range tos {
+		msg := NewMessageFrom([]string{tos[i].Email()}, from, subject, content)
+		msg.SetHeader("Reply-To", ReplyToAddress(tos[i].ID(), issue.ID()))
+		msg.Info = fmt.Sprintf("UID: %d, Subject: %s, %s", tos[i].ID(), subject, info)
+		msgs[i] = msg
+	}
+	return msgs
 }
 
 // SendIssueCommentMail composes and sends issue comment emails to target receivers.
-func SendIssueCommentMail(issue Issue, repo Repository, doer User, tos []string) {
+func SendIssueCommentMail(issue Issue, repo Repository, doer User, tos []User) {
 	if len(tos) == 0 {
 		return
 	}
 
-	Send(composeIssueMessage(issue, repo, doer, MAIL_ISSUE_COMMENT, tos, "issue comment"))
+	for _, msg := range composeIssueMessages(issue, repo, doer, MAIL_ISSUE_COMMENT, tos, "issue comment") {
+		Send(msg)
+	}
 }
 
 // SendIssueMentionMail composes and sends issue mention emails to target receivers.
-func SendIssueMentionMail(issue Issue, repo Repository, doer User, tos []string) {
+func SendIssueMentionMail(issue Issue, repo Repository, doer User, tos []User) {
 	if len(tos) == 0 {
 		return
 	}
-	Send(composeIssueMessage(issue, repo, doer, MAIL_ISSUE_MENTION, tos, "issue mention"))
+
+	for _, msg := range composeIssueMessages(issue, repo, doer, MAIL_ISSUE_MENTION, tos, "issue mention") {
+		Send(msg)
+	}
 }
@@ -0,0 +1,80 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package incoming
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
+	log "unknwon.dev/clog/v2"
+
+	"gogs.io/gogs/internal/conf"
+)
+
+// fetchIMAP processes unseen emails in the mailbox of the IMAP server, emails are
+// flagged as seen once fetched.
+func fetchIMAP() error {
+	c, err := client.DialTLS(conf.IncomingEmail.IMAPHost, &tls.Config{
+		InsecureSkipVerify: conf.IncomingEmail.IMAPSkipVerify,
+	})
+	if err != nil {
+		return fmt.Errorf("dial: %v", err)
+	}
+	defer func() {
+		_ = c.Logout()
+	}()
+
+	if err = c.Login(conf.IncomingEmail.IMAPUser, conf.IncomingEmail.IMAPPassword); err != nil {
+		return fmt.Errorf("login: %v", err)
+	} else if _, err = c.Select(conf.IncomingEmail.IMAPMailbox, false); err != nil {
+		return fmt.Errorf("select mailbox: %v", err)
+	}
+
+	criteria := imap.NewSearchCriteria()
+	criteria.WithoutFlags = []string{imap.SeenFlag}
+	uids, err := c.UidSearch(criteria)
+	if err != nil {
+		return fmt.Errorf("search: %v", err)
+	} else if len(uids) == 0 {
+		return nil
+	}
+
+	seqset := new(imap.SeqSet)
+	seqset.AddNum(uids...)
+	section := new(imap.BodySectionName)
+	messages := make(chan *imap.Message, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.UidFetch(seqset, []imap.FetchItem{section.FetchItem()}, messages)
+	}()
+
+	// Commands cannot be sent to the server during fetching, so all emails are read
+	// before being processed.
+	raws := make([][]byte, 0, len(uids))
+	for msg := range messages {
+		body := msg.GetBody(section)
+		if body == nil {
+			continue
+		}
+
+		raw, err := ioutil.ReadAll(body)
+		if err != nil {
+			log.Error("Failed to read incoming email [uid: %d]: %v", msg.Uid, err)
+			continue
+		}
+		raws = append(raws, raw)
+	}
+	if err = <-done; err != nil {
+		return fmt.Errorf("fetch: %v", err)
+	}
+
+	for _, raw := range raws {
+		processMessage(raw)
+	}
+	return nil
+}
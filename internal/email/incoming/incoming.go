@@ -0,0 +1,175 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package incoming creates issue comments from replies to issue notification emails.
+package incoming
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	log "unknwon.dev/clog/v2"
+
+	"gogs.io/gogs/internal/conf"
+	"gogs.io/gogs/internal/db"
+	"gogs.io/gogs/internal/email"
+)
+
+// Start starts to check incoming emails periodically in background if replying to
+// issue notifications by email is enabled.
+func Start() {
+	if !conf.IncomingEmail.Enabled {
+		return
+	}
+
+	fetch := fetchMaildir
+	if conf.IncomingEmail.Protocol == "imap" {
+		fetch = fetchIMAP
+	}
+
+	go func() {
+		ticker := time.NewTicker(conf.IncomingEmail.PollInterval)
+		defer ticker.Stop()
+		for {
+			if err := fetch(); err != nil {
+				log.Error("Failed to fetch incoming emails: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// processMessage handles a raw incoming email and logs errors if any.
+func processMessage(raw []byte) {
+	if err := handleMessage(raw); err != nil {
+		if err == errNoReplyToken {
+			log.Trace("Skipped incoming email: %v", err)
+			return
+		}
+		log.Error("Failed to handle incoming email: %v", err)
+	}
+}
+
+var errNoReplyToken = errors.New("no reply token found in recipients")
+
+func handleMessage(raw []byte) error {
+	msg, err := parseMessage(bytes.NewReader(raw))
+	if err != nil {
+		return fmt.Errorf("parse message: %v", err)
+	} else if msg.AutoSubmitted {
+		log.Trace("Skipped automatically submitted incoming email")
+		return nil
+	}
+
+	var token string
+	for _, addr := range msg.Recipients {
+		if t, ok := email.ReplyTokenFromAddress(addr); ok {
+			token = t
+			break
+		}
+	}
+	if token == "" {
+		return errNoReplyToken
+	}
+
+	userID, issueID, ok := email.ParseReplyToken(token)
+	if !ok {
+		return fmt.Errorf("invalid reply token %q", token)
+	}
+
+	doer, err := db.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("get user by ID [%d]: %v", userID, err)
+	} else if !doer.IsActive || doer.ProhibitLogin {
+		return fmt.Errorf("user %q is not allowed to comment", doer.Name)
+	}
+
+	issue, err := db.GetIssueByID(issueID)
+	if err != nil {
+		return fmt.Errorf("get issue by ID [%d]: %v", issueID, err)
+	} else if !db.Perms.Authorize(doer.ID, issue.Repo, db.AccessModeRead) {
+		return fmt.Errorf("user %q does not have access to repository %q", doer.Name, issue.Repo.FullName())
+	} else if err = issue.CanComment(db.Perms.Authorize(doer.ID, issue.Repo, db.AccessModeWrite)); err != nil {
+		return err
+	}
+
+	content := stripQuotedText(msg.Text)
+	attachments, err := newAttachments(msg.Attachments)
+	if err != nil {
+		return fmt.Errorf("new attachments: %v", err)
+	}
+	if content == "" && len(attachments) == 0 {
+		log.Trace("Skipped empty incoming email from user %q", doer.Name)
+		return nil
+	}
+
+	uuids := make([]string, len(attachments))
+	for i := range attachments {
+		uuids[i] = attachments[i].UUID
+	}
+	comment, err := db.CreateIssueComment(doer, issue.Repo, issue, content, uuids)
+	if err != nil {
+		removeAttachments(attachments)
+		return fmt.Errorf("create issue comment: %v", err)
+	}
+	log.Trace("Comment created from incoming email: %d/%d/%d", issue.RepoID, issue.ID, comment.ID)
+	return nil
+}
+
+// newAttachments saves attachments that are allowed by attachment settings, others
+// are dropped silently. Saved attachments are removed if any of them fails.
+func newAttachments(attachments []*attachment) (_ []*db.Attachment, err error) {
+	if !conf.Attachment.Enabled {
+		return nil, nil
+	}
+
+	saved := make([]*db.Attachment, 0, len(attachments))
+	defer func() {
+		if err != nil {
+			removeAttachments(saved)
+		}
+	}()
+	for _, a := range attachments {
+		if len(saved) >= conf.Attachment.MaxFiles {
+			break
+		} else if int64(len(a.Data)) > conf.Attachment.MaxSize<<20 {
+			continue
+		}
+
+		fileType := http.DetectContentType(a.Data)
+		allowed := false
+		for _, t := range conf.Attachment.AllowedTypes {
+			t := strings.Trim(t, " ")
+			if t == "*/*" || t == fileType {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			continue
+		}
+
+		attach, err := db.NewAttachment(a.Name, a.Data, bytes.NewReader(nil))
+		if err != nil {
+			return nil, err
+		}
+		saved = append(saved, attach)
+	}
+	return saved, nil
+}
+
+// removeAttachments deletes attachments along with their files, it is used to
+// clean up attachments that are not linked to any comment.
+func removeAttachments(attachments []*db.Attachment) {
+	if len(attachments) == 0 {
+		return
+	}
+	if _, err := db.DeleteAttachments(attachments, true); err != nil {
+		log.Error("Failed to remove attachments of incoming email: %v", err)
+	}
+}
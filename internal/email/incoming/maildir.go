@@ -0,0 +1,48 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package incoming
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "unknwon.dev/clog/v2"
+
+	"gogs.io/gogs/internal/conf"
+)
+
+// fetchMaildir processes emails in the "new" subdirectory of the Maildir, and moves
+// them to the "cur" subdirectory with the seen flag afterwards.
+func fetchMaildir() error {
+	newDir := filepath.Join(conf.IncomingEmail.MaildirPath, "new")
+	curDir := filepath.Join(conf.IncomingEmail.MaildirPath, "cur")
+
+	fis, err := ioutil.ReadDir(newDir)
+	if err != nil {
+		return fmt.Errorf("read directory: %v", err)
+	}
+
+	for _, fi := range fis {
+		if fi.IsDir() || strings.HasPrefix(fi.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(newDir, fi.Name())
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Error("Failed to read incoming email %q: %v", path, err)
+			continue
+		}
+		processMessage(raw)
+
+		if err = os.Rename(path, filepath.Join(curDir, fi.Name()+":2,S")); err != nil {
+			return fmt.Errorf("move %q to %q: %v", path, curDir, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package incoming
+
+import (
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+
+	"github.com/jaytaylor/html2text"
+)
+
+type attachment struct {
+	Name string
+	Data []byte
+}
+
+// message is the parsed content of an incoming email.
+type message struct {
+	Recipients    []string
+	AutoSubmitted bool
+	Text          string
+	Attachments   []*attachment
+
+	html string
+}
+
+// parseMessage parses an email in RFC 5322 format, the text is converted from the
+// HTML part if there is no plain text part.
+func parseMessage(r io.Reader) (*message, error) {
+	m, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := new(message)
+	for _, key := range []string{"To", "Cc", "Delivered-To", "X-Original-To"} {
+		addrs, err := m.Header.AddressList(key)
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			msg.Recipients = append(msg.Recipients, addr.Address)
+		}
+	}
+
+	autoSubmitted := strings.ToLower(strings.TrimSpace(m.Header.Get("Auto-Submitted")))
+	msg.AutoSubmitted = autoSubmitted != "" && autoSubmitted != "no"
+
+	if err = msg.readPart(textproto.MIMEHeader(m.Header), m.Body); err != nil {
+		return nil, err
+	}
+
+	if msg.Text == "" && msg.html != "" {
+		msg.Text, err = html2text.FromString(msg.html)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return msg, nil
+}
+
+func decodeTransferEncoding(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	}
+	return r
+}
+
+// readPart reads the text and attachments in the MIME part, the first plain text
+// part and the first HTML part are used as the text.
+func (msg *message) readPart(header textproto.MIMEHeader, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+	body = decodeTransferEncoding(header.Get("Content-Transfer-Encoding"), body)
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			if err = msg.readPart(p.Header, p); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dispositionParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+
+	switch {
+	case disposition == "attachment" || filename != "":
+		dec := new(mime.WordDecoder)
+		if name, err := dec.DecodeHeader(filename); err == nil {
+			filename = name
+		}
+		if filename == "" {
+			filename = "attachment"
+		}
+		msg.Attachments = append(msg.Attachments, &attachment{
+			Name: filename,
+			Data: data,
+		})
+	case mediaType == "text/plain" && msg.Text == "":
+		msg.Text = string(data)
+	case mediaType == "text/html" && msg.html == "":
+		msg.html = string(data)
+	}
+	return nil
+}
+
+var quoteHeaderPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^On\s.+\swrote:$`),
+	regexp.MustCompile(`^-+\s*Original Message\s*-+$`),
+	regexp.MustCompile(`^_{20,}$`),
+}
+
+func isQuoteHeader(line string) bool {
+	for _, p := range quoteHeaderPatterns {
+		if p.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripQuotedText removes quoted lines, the quoted original email and the signature
+// from the text of a reply.
+func stripQuotedText(text string) string {
+	lines := strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n")
+	kept := make([]string, 0, len(lines))
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if line == "-- " || isQuoteHeader(trimmed) {
+			break
+		}
+		// Some clients wrap the header of the quoted email into two lines.
+		if i+1 < len(lines) && isQuoteHeader(trimmed+" "+strings.TrimSpace(lines[i+1])) {
+			break
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		kept = append(kept, strings.TrimRight(line, " \t"))
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
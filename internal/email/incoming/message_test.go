@@ -0,0 +1,87 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package incoming
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMessage(t *testing.T) {
+	raw := strings.Join([]string{
+		"From: Alice <alice@example.com>",
+		"To: reply+10-2-abc@gogs.example.com",
+		"Subject: Re: [gogs] Bug (#1)",
+		"MIME-Version: 1.0",
+		`Content-Type: multipart/mixed; boundary="b1"`,
+		"",
+		"--b1",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Transfer-Encoding: quoted-printable",
+		"",
+		"Looks good=21",
+		"--b1",
+		"Content-Type: text/plain; name=\"log.txt\"",
+		"Content-Disposition: attachment; filename=\"log.txt\"",
+		"Content-Transfer-Encoding: base64",
+		"",
+		"aGVsbG8=",
+		"--b1--",
+		"",
+	}, "\r\n")
+
+	msg, err := parseMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"reply+10-2-abc@gogs.example.com"}, msg.Recipients)
+	assert.False(t, msg.AutoSubmitted)
+	assert.Equal(t, "Looks good!", msg.Text)
+	if assert.Len(t, msg.Attachments, 1) {
+		assert.Equal(t, "log.txt", msg.Attachments[0].Name)
+		assert.Equal(t, "hello", string(msg.Attachments[0].Data))
+	}
+}
+
+func Test_stripQuotedText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "quoted lines",
+			text: "Thanks!\r\n\r\n> original\r\n> content\r\n",
+			want: "Thanks!",
+		},
+		{
+			name: "quote header",
+			text: "Fixed in master.\n\nOn Mon, Jan 6, 2020 at 10:00 AM Bob <bob@example.com> wrote:\n> Is it fixed?\n",
+			want: "Fixed in master.",
+		},
+		{
+			name: "wrapped quote header",
+			text: "Fixed in master.\n\nOn Mon, Jan 6, 2020 at 10:00 AM Bob <\nbob@example.com> wrote:\n\nIs it fixed?\n",
+			want: "Fixed in master.",
+		},
+		{
+			name: "signature",
+			text: "LGTM\n-- \nAlice\n",
+			want: "LGTM",
+		},
+		{
+			name: "original message",
+			text: "See below.\n-----Original Message-----\nFrom: Bob\n",
+			want: "See below.",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, stripQuotedText(test.text))
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package email
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gogs.io/gogs/internal/conf"
+)
+
+// ReplyTokenPlaceholder is the placeholder of reply token in the reply-to address.
+const ReplyTokenPlaceholder = "%{token}"
+
+func replyTokenSignature(payload string) string {
+	h := hmac.New(sha256.New, []byte(conf.Security.SecretKey))
+	_, _ = h.Write([]byte("issue-reply:" + payload))
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// ReplyToken returns the signed token that allows the user to reply to the issue
+// by email.
+func ReplyToken(userID, issueID int64) string {
+	payload := fmt.Sprintf("%d-%d", issueID, userID)
+	return payload + "-" + replyTokenSignature(payload)
+}
+
+// ParseReplyToken returns IDs of the user and the issue in the token, ok is false
+// if the token is malformed or its signature does not match.
+func ParseReplyToken(token string) (userID, issueID int64, ok bool) {
+	fields := strings.Split(strings.ToLower(token), "-")
+	if len(fields) != 3 {
+		return 0, 0, false
+	}
+
+	payload := fields[0] + "-" + fields[1]
+	if !hmac.Equal([]byte(fields[2]), []byte(replyTokenSignature(payload))) {
+		return 0, 0, false
+	}
+
+	issueID, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	userID, err = strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return userID, issueID, true
+}
+
+// ReplyToAddress returns the address that the user can reply to for commenting on
+// the issue by email.
+func ReplyToAddress(userID, issueID int64) string {
+	return strings.Replace(conf.IncomingEmail.ReplyToAddress, ReplyTokenPlaceholder, ReplyToken(userID, issueID), 1)
+}
+
+// ReplyTokenFromAddress returns the reply token in given address if it matches the
+// configured reply-to address.
+func ReplyTokenFromAddress(address string) (string, bool) {
+	i := strings.Index(conf.IncomingEmail.ReplyToAddress, ReplyTokenPlaceholder)
+	if i < 0 {
+		return "", false
+	}
+	prefix := strings.ToLower(conf.IncomingEmail.ReplyToAddress[:i])
+	suffix := strings.ToLower(conf.IncomingEmail.ReplyToAddress[i+len(ReplyTokenPlaceholder):])
+
+	address = strings.ToLower(address)
+	if len(address) <= len(prefix)+len(suffix) ||
+		!strings.HasPrefix(address, prefix) ||
+		!strings.HasSuffix(address, suffix) {
+		return "", false
+	}
+	return address[len(prefix) : len(address)-len(suffix)], true
+}
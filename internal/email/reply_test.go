@@ -0,0 +1,49 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package email
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"gogs.io/gogs/internal/conf"
+)
+
+func TestReplyToken(t *testing.T) {
+	before := conf.Security.SecretKey
+	conf.Security.SecretKey = "secret"
+	defer func() {
+		conf.Security.SecretKey = before
+	}()
+
+	token := ReplyToken(2, 10)
+	userID, issueID, ok := ParseReplyToken(token)
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), userID)
+	assert.Equal(t, int64(10), issueID)
+
+	_, _, ok = ParseReplyToken("10-3-" + token[len("10-2-"):])
+	assert.False(t, ok)
+	_, _, ok = ParseReplyToken("10-2")
+	assert.False(t, ok)
+}
+
+func TestReplyTokenFromAddress(t *testing.T) {
+	before := conf.IncomingEmail.ReplyToAddress
+	conf.IncomingEmail.ReplyToAddress = "reply+%{token}@gogs.example.com"
+	defer func() {
+		conf.IncomingEmail.ReplyToAddress = before
+	}()
+
+	token, ok := ReplyTokenFromAddress("Reply+10-2-abc@gogs.example.com")
+	assert.True(t, ok)
+	assert.Equal(t, "10-2-abc", token)
+
+	_, ok = ReplyTokenFromAddress("reply+@gogs.example.com")
+	assert.False(t, ok)
+	_, ok = ReplyTokenFromAddress("noreply@gogs.example.com")
+	assert.False(t, ok)
+}
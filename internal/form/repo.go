@@ -104,6 +104,11 @@ type RepoSetting struct {
 	EnablePulls           bool
 	PullsIgnoreWhitespace bool
 	PullsAllowRebase      bool
+	PullsAllowSquash      bool
+	PullsAllowFastForward bool
+
+	IssuesBlockCloseByDependencies bool
+	EnableTimeTracker              bool
 }
 
 func (f *RepoSetting) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
@@ -118,11 +123,15 @@ func (f *RepoSetting) Validate(ctx *macaron.Context, errs binding.Errors) bindin
 //         \/             \/     \/     \/     \/
 
 type ProtectBranch struct {
-	Protected          bool
-	RequirePullRequest bool
-	EnableWhitelist    bool
-	WhitelistUsers     string
-	WhitelistTeams     string
+	Protected                bool
+	RequirePullRequest       bool
+	RequiredApprovals        int
+	RequireCodeOwnerApproval bool
+	RequiredStatusChecks     string
+	EnableWhitelist          bool
+	WhitelistUsers           string
+	WhitelistTeams           string
+	WhitelistCanOverride     bool
 }
 
 func (f *ProtectBranch) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
@@ -137,16 +146,20 @@ func (f *ProtectBranch) Validate(ctx *macaron.Context, errs binding.Errors) bind
 //        \/       \/    \/     \/     \/            \/
 
 type Webhook struct {
-	Events       string
-	Create       bool
-	Delete       bool
-	Fork         bool
-	Push         bool
-	Issues       bool
-	IssueComment bool
-	PullRequest  bool
-	Release      bool
-	Active       bool
+	Events            string
+	Create            bool
+	Delete            bool
+	Fork              bool
+	Push              bool
+	Issues            bool
+	IssueComment      bool
+	PullRequest       bool
+	PullRequestReview bool
+	Release           bool
+	Repository        bool
+	User              bool
+	Organization      bool
+	Active            bool
 }
 
 func (f Webhook) PushOnly() bool {
@@ -220,6 +233,8 @@ type NewIssue struct {
 	AssigneeID  int64
 	Content     string
 	Files       []string
+	IsDraft     bool   // Only for pull requests
+	Template    string // The file name of the template used
 }
 
 func (f *NewIssue) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
@@ -236,6 +251,36 @@ func (f *CreateComment) Validate(ctx *macaron.Context, errs binding.Errors) bind
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+type AddTrackedTime struct {
+	Hours   int64
+	Minutes int64
+}
+
+func (f *AddTrackedTime) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+type CreateReview struct {
+	Content string
+	State   string `binding:"Required;In(pending,approved,changes_requested)"`
+}
+
+func (f *CreateReview) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+type CreateCodeComment struct {
+	Content   string `binding:"Required"`
+	CommitSHA string `binding:"Required;MaxSize(40)"`
+	TreePath  string `binding:"Required"`
+	Side      string `binding:"Required;In(left,right)"`
+	Line      int64  `binding:"Required"`
+}
+
+func (f *CreateCodeComment) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 //    _____  .__.__                   __
 //   /     \ |__|  |   ____   _______/  |_  ____   ____   ____
 //  /  \ /  \|  |  | _/ __ \ /  ___/\   __\/  _ \ /    \_/ __ \
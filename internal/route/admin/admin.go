@@ -137,6 +137,7 @@ const (
 	SyncSSHAuthorizedKey
 	SyncRepositoryHooks
 	ReinitMissingRepository
+	RebuildIssueIndex
 )
 
 func Operation(c *context.Context) {
@@ -164,6 +165,9 @@ func Operation(c *context.Context) {
 	case ReinitMissingRepository:
 		success = c.Tr("admin.dashboard.reinit_missing_repos_success")
 		err = db.ReinitMissingRepositories()
+	case RebuildIssueIndex:
+		success = c.Tr("admin.dashboard.rebuild_issue_index_success")
+		err = db.RebuildIssueIndex()
 	}
 
 	if err != nil {
@@ -0,0 +1,16 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"gogs.io/gogs/internal/context"
+)
+
+// HooksContext marks pages of system webhooks in the admin panel. Pages are served
+// by webhook handlers of repositories, which manage system webhooks in this context.
+func HooksContext(c *context.Context) {
+	c.Data["PageIsAdmin"] = true
+	c.Data["PageIsAdminHooks"] = true
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"fmt"
+	"net/http"
+
+	api "github.com/gogs/go-gogs-client"
+
+	"gogs.io/gogs/internal/conf"
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/db"
+	"gogs.io/gogs/internal/route/api/v1/convert"
+	"gogs.io/gogs/internal/route/api/v1/repo"
+)
+
+func toSystemHook(w *db.Webhook) *api.Hook {
+	hook := convert.ToHook("", w)
+	hook.URL = fmt.Sprintf("%s/admin/hooks/%d", conf.Server.Subpath, w.ID)
+	return hook
+}
+
+// ListHooks lists all system webhooks.
+func ListHooks(c *context.APIContext) {
+	hooks, err := db.GetSystemWebhooks()
+	if err != nil {
+		c.Errorf(err, "get system webhooks")
+		return
+	}
+
+	apiHooks := make([]*api.Hook, len(hooks))
+	for i := range hooks {
+		apiHooks[i] = toSystemHook(hooks[i])
+	}
+	c.JSONSuccess(&apiHooks)
+}
+
+// CreateHook creates a system webhook.
+func CreateHook(c *context.APIContext, form api.CreateHookOption) {
+	w := repo.NewHookFromOption(c, form)
+	if c.Written() {
+		return
+	}
+
+	w.IsSystemWebhook = true
+	if err := db.CreateWebhook(w); err != nil {
+		c.Errorf(err, "create webhook")
+		return
+	}
+
+	c.JSON(http.StatusCreated, toSystemHook(w))
+}
+
+// EditHook edits the system webhook.
+func EditHook(c *context.APIContext, form api.EditHookOption) {
+	w, err := db.GetSystemWebhookByID(c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get system webhook by ID")
+		return
+	}
+
+	repo.UpdateHookFromOption(c, w, form)
+	if c.Written() {
+		return
+	}
+
+	if err := db.UpdateWebhook(w); err != nil {
+		c.Errorf(err, "update webhook")
+		return
+	}
+
+	c.JSONSuccess(toSystemHook(w))
+}
+
+// DeleteHook deletes the system webhook.
+func DeleteHook(c *context.APIContext) {
+	if err := db.DeleteSystemWebhookByID(c.ParamsInt64(":id")); err != nil {
+		c.Errorf(err, "delete system webhook by ID")
+		return
+	}
+
+	c.NoContent()
+}
@@ -165,6 +165,20 @@ func mustEnableIssues(c *context.APIContext) {
 	}
 }
 
+func mustEnableTimeTracker(c *context.APIContext) {
+	if !c.Repo.Repository.IsTimeTrackerEnabled() {
+		c.NotFound()
+		return
+	}
+}
+
+func mustAllowPulls(c *context.APIContext) {
+	if !c.Repo.Repository.AllowsPulls() {
+		c.NotFound()
+		return
+	}
+}
+
 // RegisterRoutes registers all route in API v1 to the web application.
 // FIXME: custom form error response
 func RegisterRoutes(m *macaron.Macaron) {
@@ -233,6 +247,15 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Get("/issues", repo.ListUserIssues)
 		}, reqToken())
 
+		m.Group("/notifications", func() {
+			m.Combo("").
+				Get(user.ListNotifications).
+				Patch(user.MarkNotificationsRead)
+			m.Combo("/threads/:id").
+				Get(user.GetNotification).
+				Patch(user.UpdateNotification)
+		}, reqToken())
+
 		// Repositories
 		m.Get("/users/:username/repos", reqToken(), repo.ListUserRepositories)
 		m.Get("/orgs/:org/repos", reqToken(), repo.ListOrgRepositories)
@@ -243,6 +266,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 
 		m.Group("/repos", func() {
 			m.Get("/search", repo.Search)
+			m.Get("/issues/search", reqToken(), repo.SearchIssues)
 
 			m.Get("/:username/:reponame", repoAssignment(), repo.Get)
 			m.Get("/:username/:reponame/releases", repoAssignment(), repo.Releases)
@@ -260,6 +284,8 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Combo("/:id").
 						Patch(bind(api.EditHookOption{}), repo.EditHook).
 						Delete(repo.DeleteHook)
+					m.Get("/:id/deliveries", repo.ListHookDeliveries)
+					m.Post("/:id/deliveries/:delivery_id/redeliver", repo.RedeliverHook)
 				}, reqRepoAdmin())
 
 				m.Group("/collaborators", func() {
@@ -286,8 +312,12 @@ func RegisterRoutes(m *macaron.Macaron) {
 				})
 				m.Group("/commits", func() {
 					m.Get("/:sha", repo.GetSingleCommit)
+					m.Get("/:ref/status", repo.GetCombinedCommitStatus)
 					m.Get("/*", repo.GetReferenceSHA)
 				})
+				m.Combo("/statuses/:sha").
+					Get(repo.ListCommitStatuses).
+					Post(reqRepoWriter(), bind(repo.CreateCommitStatusOption{}), repo.CreateCommitStatus)
 
 				m.Group("/keys", func() {
 					m.Combo("").
@@ -301,15 +331,21 @@ func RegisterRoutes(m *macaron.Macaron) {
 				m.Group("/issues", func() {
 					m.Combo("").
 						Get(repo.ListIssues).
-						Post(bind(api.CreateIssueOption{}), repo.CreateIssue)
+						Post(bind(repo.CreateIssueOption{}), repo.CreateIssue)
+					m.Post("/bulk", reqRepoWriter(), bind(repo.BulkEditIssuesOption{}), repo.BulkEditIssues)
+					m.Get("/pinned", repo.ListPinnedIssues)
 					m.Group("/comments", func() {
 						m.Get("", repo.ListRepoIssueComments)
 						m.Patch("/:id", bind(api.EditIssueCommentOption{}), repo.EditIssueComment)
+						m.Combo("/:id/reactions").
+							Get(repo.ListReactions).
+							Post(bind(repo.ReactionOption{}), repo.CreateReaction).
+							Delete(bind(repo.ReactionOption{}), repo.DeleteReaction)
 					})
 					m.Group("/:index", func() {
 						m.Combo("").
 							Get(repo.GetIssue).
-							Patch(bind(api.EditIssueOption{}), repo.EditIssue)
+							Patch(bind(repo.EditIssueOption{}), repo.EditIssue)
 
 						m.Group("/comments", func() {
 							m.Combo("").
@@ -319,6 +355,17 @@ func RegisterRoutes(m *macaron.Macaron) {
 								Patch(bind(api.EditIssueCommentOption{}), repo.EditIssueComment).
 								Delete(repo.DeleteIssueComment)
 						})
+						m.Combo("/reactions").
+							Get(repo.ListReactions).
+							Post(bind(repo.ReactionOption{}), repo.CreateReaction).
+							Delete(bind(repo.ReactionOption{}), repo.DeleteReaction)
+						m.Combo("/lock", reqRepoWriter()).
+							Put(bind(repo.LockIssueOption{}), repo.LockIssue).
+							Delete(repo.UnlockIssue)
+						m.Combo("/pin", reqRepoWriter()).
+							Put(bind(repo.PinIssueOption{}), repo.PinIssue).
+							Delete(repo.UnpinIssue)
+						m.Post("/transfer", reqRepoWriter(), bind(repo.TransferIssueOption{}), repo.TransferIssue)
 
 						m.Get("/labels", repo.ListIssueLabels)
 						m.Group("/labels", func() {
@@ -328,8 +375,35 @@ func RegisterRoutes(m *macaron.Macaron) {
 								Delete(repo.ClearIssueLabels)
 							m.Delete("/:id", repo.DeleteIssueLabel)
 						}, reqRepoWriter())
+
+						m.Combo("/times", mustEnableTimeTracker).
+							Get(repo.ListIssueTrackedTimes).
+							Post(reqRepoWriter(), bind(repo.AddTrackedTimeOption{}), repo.AddIssueTrackedTime)
 					})
 				}, mustEnableIssues)
+				m.Get("/times", mustEnableTimeTracker, repo.ListRepoTrackedTimes)
+
+				m.Group("/pulls", func() {
+					m.Combo("").
+						Get(repo.ListPullRequests).
+						Post(bind(repo.CreatePullRequestOption{}), repo.CreatePullRequest)
+					m.Group("/:index", func() {
+						m.Combo("").
+							Get(repo.GetPullRequest).
+							Patch(bind(repo.EditIssueOption{}), repo.EditPullRequest)
+						m.Combo("/merge").
+							Get(repo.IsPullRequestMerged).
+							Post(reqRepoWriter(), bind(repo.MergePullRequestOption{}), repo.MergePullRequest)
+
+						m.Group("/reviews", func() {
+							m.Combo("").
+								Get(repo.ListPullReviews).
+								Post(bind(repo.CreatePullReviewOption{}), repo.CreatePullReview)
+							m.Get("/:id", repo.GetPullReview)
+							m.Put("/:id/dismissals", reqRepoWriter(), repo.DismissPullReview)
+						})
+					})
+				}, mustAllowPulls)
 
 				m.Group("/labels", func() {
 					m.Get("", repo.ListLabels)
@@ -404,6 +478,15 @@ func RegisterRoutes(m *macaron.Macaron) {
 						Delete(admin.RemoveTeamRepository)
 				}, orgAssignment(false, true))
 			})
+
+			m.Group("/hooks", func() {
+				m.Combo("").
+					Get(admin.ListHooks).
+					Post(bind(api.CreateHookOption{}), admin.CreateHook)
+				m.Combo("/:id").
+					Patch(bind(api.EditHookOption{}), admin.EditHook).
+					Delete(admin.DeleteHook)
+			})
 		}, reqAdmin())
 
 		m.Any("/*", func(c *context.Context) {
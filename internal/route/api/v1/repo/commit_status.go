@@ -0,0 +1,92 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/http"
+
+	"github.com/gogs/git-module"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/db"
+	"gogs.io/gogs/internal/gitutil"
+)
+
+// getCommitByRef returns the commit by given revision in the repository,
+// it writes a 404 response if the commit does not exist.
+func getCommitByRef(c *context.APIContext, ref string) *git.Commit {
+	gitRepo, err := git.Open(c.Repo.Repository.RepoPath())
+	if err != nil {
+		c.Error(err, "open repository")
+		return nil
+	}
+	commit, err := gitRepo.CatFileCommit(ref)
+	if err != nil {
+		c.NotFoundOrError(gitutil.NewError(err), "get commit")
+		return nil
+	}
+	return commit
+}
+
+type CreateCommitStatusOption struct {
+	State       string `json:"state" binding:"Required;In(pending,success,error,failure)"`
+	TargetURL   string `json:"target_url"`
+	Description string `json:"description"`
+	Context     string `json:"context" binding:"MaxSize(255)"`
+}
+
+func CreateCommitStatus(c *context.APIContext, form CreateCommitStatusOption) {
+	commit := getCommitByRef(c, c.Params(":sha"))
+	if c.Written() {
+		return
+	}
+
+	status, err := db.CreateCommitStatus(c.Repo.Repository, c.User, commit.ID.String(), db.CreateCommitStatusOptions{
+		State:       db.CommitStatusState(form.State),
+		Context:     form.Context,
+		TargetURL:   form.TargetURL,
+		Description: form.Description,
+	})
+	if err != nil {
+		c.Error(err, "create commit status")
+		return
+	}
+
+	c.JSON(http.StatusCreated, status.APIFormat())
+}
+
+func ListCommitStatuses(c *context.APIContext) {
+	commit := getCommitByRef(c, c.Params(":sha"))
+	if c.Written() {
+		return
+	}
+
+	statuses, err := db.GetCommitStatuses(c.Repo.Repository.ID, commit.ID.String())
+	if err != nil {
+		c.Error(err, "get commit statuses")
+		return
+	}
+
+	apiStatuses := make([]*db.APICommitStatus, len(statuses))
+	for i := range statuses {
+		apiStatuses[i] = statuses[i].APIFormat()
+	}
+	c.JSONSuccess(&apiStatuses)
+}
+
+func GetCombinedCommitStatus(c *context.APIContext) {
+	commit := getCommitByRef(c, c.Params(":ref"))
+	if c.Written() {
+		return
+	}
+
+	combined, err := db.GetCombinedCommitStatus(commit.ID.String(), c.Repo.Repository.ID)
+	if err != nil {
+		c.Error(err, "get combined commit status")
+		return
+	}
+
+	c.JSONSuccess(combined.APIFormat(c.Repo.Repository))
+}
@@ -32,43 +32,53 @@ func ListHooks(c *context.APIContext) {
 	c.JSONSuccess(&apiHooks)
 }
 
-// https://github.com/gogs/go-gogs-client/wiki/Repositories#create-a-hook
-func CreateHook(c *context.APIContext, form api.CreateHookOption) {
+// hookEvents returns hook events enabled by given event names.
+func hookEvents(events []string) db.HookEvents {
+	return db.HookEvents{
+		Create:            com.IsSliceContainsStr(events, string(db.HOOK_EVENT_CREATE)),
+		Delete:            com.IsSliceContainsStr(events, string(db.HOOK_EVENT_DELETE)),
+		Fork:              com.IsSliceContainsStr(events, string(db.HOOK_EVENT_FORK)),
+		Push:              com.IsSliceContainsStr(events, string(db.HOOK_EVENT_PUSH)),
+		Issues:            com.IsSliceContainsStr(events, string(db.HOOK_EVENT_ISSUES)),
+		IssueComment:      com.IsSliceContainsStr(events, string(db.HOOK_EVENT_ISSUE_COMMENT)),
+		PullRequest:       com.IsSliceContainsStr(events, string(db.HOOK_EVENT_PULL_REQUEST)),
+		PullRequestReview: com.IsSliceContainsStr(events, string(db.HOOK_EVENT_PULL_REQUEST_REVIEW)),
+		Release:           com.IsSliceContainsStr(events, string(db.HOOK_EVENT_RELEASE)),
+		Repository:        com.IsSliceContainsStr(events, string(db.HOOK_EVENT_REPOSITORY)),
+		User:              com.IsSliceContainsStr(events, string(db.HOOK_EVENT_USER)),
+		Organization:      com.IsSliceContainsStr(events, string(db.HOOK_EVENT_ORGANIZATION)),
+	}
+}
+
+// NewHookFromOption returns a new webhook with given options, the owner of the
+// webhook must be set by the caller. It returns nil with error response written
+// if any option is invalid.
+func NewHookFromOption(c *context.APIContext, form api.CreateHookOption) *db.Webhook {
 	if !db.IsValidHookTaskType(form.Type) {
 		c.ErrorStatus(http.StatusUnprocessableEntity, errors.New("Invalid hook type."))
-		return
+		return nil
 	}
 	for _, name := range []string{"url", "content_type"} {
 		if _, ok := form.Config[name]; !ok {
 			c.ErrorStatus(http.StatusUnprocessableEntity, errors.New("Missing config option: "+name))
-			return
+			return nil
 		}
 	}
 	if !db.IsValidHookContentType(form.Config["content_type"]) {
 		c.ErrorStatus(http.StatusUnprocessableEntity, errors.New("Invalid content type."))
-		return
+		return nil
 	}
 
 	if len(form.Events) == 0 {
 		form.Events = []string{"push"}
 	}
 	w := &db.Webhook{
-		RepoID:      c.Repo.Repository.ID,
 		URL:         form.Config["url"],
 		ContentType: db.ToHookContentType(form.Config["content_type"]),
 		Secret:      form.Config["secret"],
 		HookEvent: &db.HookEvent{
 			ChooseEvents: true,
-			HookEvents: db.HookEvents{
-				Create:       com.IsSliceContainsStr(form.Events, string(db.HOOK_EVENT_CREATE)),
-				Delete:       com.IsSliceContainsStr(form.Events, string(db.HOOK_EVENT_DELETE)),
-				Fork:         com.IsSliceContainsStr(form.Events, string(db.HOOK_EVENT_FORK)),
-				Push:         com.IsSliceContainsStr(form.Events, string(db.HOOK_EVENT_PUSH)),
-				Issues:       com.IsSliceContainsStr(form.Events, string(db.HOOK_EVENT_ISSUES)),
-				IssueComment: com.IsSliceContainsStr(form.Events, string(db.HOOK_EVENT_ISSUE_COMMENT)),
-				PullRequest:  com.IsSliceContainsStr(form.Events, string(db.HOOK_EVENT_PULL_REQUEST)),
-				Release:      com.IsSliceContainsStr(form.Events, string(db.HOOK_EVENT_RELEASE)),
-			},
+			HookEvents:   hookEvents(form.Events),
 		},
 		IsActive:     form.Active,
 		HookTaskType: db.ToHookTaskType(form.Type),
@@ -77,7 +87,7 @@ func CreateHook(c *context.APIContext, form api.CreateHookOption) {
 		channel, ok := form.Config["channel"]
 		if !ok {
 			c.ErrorStatus(http.StatusUnprocessableEntity, errors.New("Missing config option: channel"))
-			return
+			return nil
 		}
 		meta, err := jsoniter.Marshal(&db.SlackMeta{
 			Channel:  channel,
@@ -87,15 +97,27 @@ func CreateHook(c *context.APIContext, form api.CreateHookOption) {
 		})
 		if err != nil {
 			c.Errorf(err, "marshal JSON")
-			return
+			return nil
 		}
 		w.Meta = string(meta)
 	}
 
 	if err := w.UpdateEvent(); err != nil {
 		c.Errorf(err, "update event")
+		return nil
+	}
+	return w
+}
+
+// https://github.com/gogs/go-gogs-client/wiki/Repositories#create-a-hook
+func CreateHook(c *context.APIContext, form api.CreateHookOption) {
+	w := NewHookFromOption(c, form)
+	if c.Written() {
 		return
-	} else if err := db.CreateWebhook(w); err != nil {
+	}
+
+	w.RepoID = c.Repo.Repository.ID
+	if err := db.CreateWebhook(w); err != nil {
 		c.Errorf(err, "create webhook")
 		return
 	}
@@ -103,14 +125,9 @@ func CreateHook(c *context.APIContext, form api.CreateHookOption) {
 	c.JSON(http.StatusCreated, convert.ToHook(c.Repo.RepoLink, w))
 }
 
-// https://github.com/gogs/go-gogs-client/wiki/Repositories#edit-a-hook
-func EditHook(c *context.APIContext, form api.EditHookOption) {
-	w, err := db.GetWebhookOfRepoByID(c.Repo.Repository.ID, c.ParamsInt64(":id"))
-	if err != nil {
-		c.NotFoundOrError(err, "get webhook of repository by ID")
-		return
-	}
-
+// UpdateHookFromOption applies given options to the webhook. It writes error
+// response if any option is invalid.
+func UpdateHookFromOption(c *context.APIContext, w *db.Webhook, form api.EditHookOption) {
 	if form.Config != nil {
 		if url, ok := form.Config["url"]; ok {
 			w.URL = url
@@ -147,15 +164,8 @@ func EditHook(c *context.APIContext, form api.EditHookOption) {
 	w.PushOnly = false
 	w.SendEverything = false
 	w.ChooseEvents = true
-	w.Create = com.IsSliceContainsStr(form.Events, string(db.HOOK_EVENT_CREATE))
-	w.Delete = com.IsSliceContainsStr(form.Events, string(db.HOOK_EVENT_DELETE))
-	w.Fork = com.IsSliceContainsStr(form.Events, string(db.HOOK_EVENT_FORK))
-	w.Push = com.IsSliceContainsStr(form.Events, string(db.HOOK_EVENT_PUSH))
-	w.Issues = com.IsSliceContainsStr(form.Events, string(db.HOOK_EVENT_ISSUES))
-	w.IssueComment = com.IsSliceContainsStr(form.Events, string(db.HOOK_EVENT_ISSUE_COMMENT))
-	w.PullRequest = com.IsSliceContainsStr(form.Events, string(db.HOOK_EVENT_PULL_REQUEST))
-	w.Release = com.IsSliceContainsStr(form.Events, string(db.HOOK_EVENT_RELEASE))
-	if err = w.UpdateEvent(); err != nil {
+	w.HookEvents = hookEvents(form.Events)
+	if err := w.UpdateEvent(); err != nil {
 		c.Errorf(err, "update event")
 		return
 	}
@@ -163,6 +173,20 @@ func EditHook(c *context.APIContext, form api.EditHookOption) {
 	if form.Active != nil {
 		w.IsActive = *form.Active
 	}
+}
+
+// https://github.com/gogs/go-gogs-client/wiki/Repositories#edit-a-hook
+func EditHook(c *context.APIContext, form api.EditHookOption) {
+	w, err := db.GetWebhookOfRepoByID(c.Repo.Repository.ID, c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get webhook of repository by ID")
+		return
+	}
+
+	UpdateHookFromOption(c, w, form)
+	if c.Written() {
+		return
+	}
 
 	if err := db.UpdateWebhook(w); err != nil {
 		c.Errorf(err, "update webhook")
@@ -180,3 +204,51 @@ func DeleteHook(c *context.APIContext) {
 
 	c.NoContent()
 }
+
+// ListHookDeliveries lists recent deliveries of the webhook, newest first.
+func ListHookDeliveries(c *context.APIContext) {
+	w, err := db.GetWebhookOfRepoByID(c.Repo.Repository.ID, c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get webhook of repository by ID")
+		return
+	}
+
+	page := c.QueryInt("page")
+	if page <= 0 {
+		page = 1
+	}
+	tasks, err := w.History(page)
+	if err != nil {
+		c.Errorf(err, "get webhook history")
+		return
+	}
+
+	deliveries := make([]*db.APIHookDelivery, len(tasks))
+	for i := range tasks {
+		deliveries[i] = tasks[i].APIFormat()
+	}
+	c.JSONSuccess(&deliveries)
+}
+
+// RedeliverHook delivers the stored payload of a past delivery again as a new
+// delivery, and responds with the new delivery.
+func RedeliverHook(c *context.APIContext) {
+	w, err := db.GetWebhookOfRepoByID(c.Repo.Repository.ID, c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get webhook of repository by ID")
+		return
+	}
+
+	t, err := db.GetHookTaskOfWebhookByID(w.ID, c.ParamsInt64(":delivery_id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get hook task of webhook by ID")
+		return
+	}
+
+	redelivery, err := w.Redeliver(t)
+	if err != nil {
+		c.Errorf(err, "redeliver hook task")
+		return
+	}
+	c.JSON(http.StatusAccepted, redelivery.APIFormat())
+}
@@ -30,13 +30,17 @@ func listIssues(c *context.APIContext, opts *db.IssuesOptions) {
 	}
 
 	// FIXME: use IssueList to improve performance.
-	apiIssues := make([]*api.Issue, len(issues))
+	apiIssues := make([]*db.APIIssue, len(issues))
 	for i := range issues {
 		if err = issues[i].LoadAttributes(); err != nil {
 			c.Error(err, "load attributes")
 			return
 		}
-		apiIssues[i] = issues[i].APIFormat()
+		apiIssues[i], err = issues[i].APIFormatWithDependencies(c.UserID())
+		if err != nil {
+			c.Error(err, "convert issue to API format")
+			return
+		}
 	}
 
 	c.SetLinkHeader(int(count), conf.UI.IssuePagingNum)
@@ -63,16 +67,105 @@ func ListIssues(c *context.APIContext) {
 	listIssues(c, &opts)
 }
 
+// SearchIssues returns issues or pull requests that match the keyword in repositories
+// the user owns or has access to, ordered by relevance.
+func SearchIssues(c *context.APIContext) {
+	keyword := strings.TrimSpace(c.Query("q"))
+	if keyword == "" {
+		c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("empty keyword"))
+		return
+	}
+
+	repoIDs, err := c.User.GetAccessibleRepoIDs()
+	if err != nil {
+		c.Error(err, "get accessible repository IDs")
+		return
+	}
+
+	var issueIDs []int64
+	if len(repoIDs) > 0 {
+		issueIDs, err = db.SearchIssueIndex(keyword, repoIDs)
+		if err != nil {
+			c.Error(err, "search issue index")
+			return
+		}
+	}
+
+	opts := db.IssuesOptions{
+		RepoIDs:  repoIDs,
+		Page:     c.QueryInt("page"),
+		IsClosed: api.StateType(c.Query("state")) == api.STATE_CLOSED,
+		IsPull:   c.Query("type") == "pulls",
+		SortType: "relevance",
+		IssueIDs: issueIDs,
+	}
+	listIssues(c, &opts)
+}
+
 func GetIssue(c *context.APIContext) {
-	issue, err := db.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	index := c.ParamsInt64(":index")
+	issue, err := db.GetIssueByIndex(c.Repo.Repository.ID, index)
 	if err != nil {
-		c.NotFoundOrError(err, "get issue by index")
+		if db.IsErrIssueNotExist(err) {
+			redirectTransferredIssue(c, index)
+			return
+		}
+		c.Error(err, "get issue by index")
+		return
+	}
+	issueAPIFormat(c, http.StatusOK, issue)
+}
+
+// redirectTransferredIssue permanently redirects to the issue that was transferred
+// from given index of current repository if current user has read access to it.
+func redirectTransferredIssue(c *context.APIContext, index int64) {
+	issue, err := db.GetRedirectedIssue(c.Repo.Repository.ID, index)
+	if err != nil {
+		c.NotFoundOrError(err, "get redirected issue")
+		return
+	} else if !db.Perms.Authorize(c.UserID(), issue.Repo, db.AccessModeRead) {
+		c.NotFound()
+		return
+	}
+	c.Redirect(fmt.Sprintf("%sapi/v1/repos/%s/issues/%d", conf.Server.ExternalURL, issue.Repo.FullName(), issue.Index), http.StatusMovedPermanently)
+}
+
+// issueAPIFormat writes the API format of the issue with its dependencies to the
+// response with given status.
+func issueAPIFormat(c *context.APIContext, status int, issue *db.Issue) {
+	apiIssue, err := issue.APIFormatWithDependencies(c.UserID())
+	if err != nil {
+		c.Error(err, "convert issue to API format")
 		return
 	}
-	c.JSONSuccess(issue.APIFormat())
+	c.JSON(status, apiIssue)
 }
 
-func CreateIssue(c *context.APIContext, form api.CreateIssueOption) {
+// getAssigneeIDsByNames returns IDs of users by given names, it writes an error
+// response if any of the users does not exist.
+func getAssigneeIDsByNames(c *context.APIContext, names []string) []int64 {
+	ids := make([]int64, 0, len(names))
+	for _, name := range names {
+		assignee, err := db.GetUserByName(name)
+		if err != nil {
+			if db.IsErrUserNotExist(err) {
+				c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("assignee does not exist: [name: %s]", name))
+			} else {
+				c.Error(err, "get user by name")
+			}
+			return nil
+		}
+		ids = append(ids, assignee.ID)
+	}
+	return ids
+}
+
+type CreateIssueOption struct {
+	api.CreateIssueOption
+	Assignees []string `json:"assignees"`
+}
+
+func CreateIssue(c *context.APIContext, form CreateIssueOption) {
 	issue := &db.Issue{
 		RepoID:   c.Repo.Repository.ID,
 		Title:    form.Title,
@@ -83,16 +176,11 @@ func CreateIssue(c *context.APIContext, form api.CreateIssueOption) {
 
 	if c.Repo.IsWriter() {
 		if len(form.Assignee) > 0 {
-			assignee, err := db.GetUserByName(form.Assignee)
-			if err != nil {
-				if db.IsErrUserNotExist(err) {
-					c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("assignee does not exist: [name: %s]", form.Assignee))
-				} else {
-					c.Error(err, "get user by name")
-				}
-				return
-			}
-			issue.AssigneeID = assignee.ID
+			form.Assignees = append([]string{form.Assignee}, form.Assignees...)
+		}
+		issue.AssigneeIDs = getAssigneeIDsByNames(c, form.Assignees)
+		if c.Written() {
+			return
 		}
 		issue.MilestoneID = form.Milestone
 	} else {
@@ -118,21 +206,47 @@ func CreateIssue(c *context.APIContext, form api.CreateIssueOption) {
 		c.Error(err, "get issue by ID")
 		return
 	}
-	c.JSON(http.StatusCreated, issue.APIFormat())
+	issueAPIFormat(c, http.StatusCreated, issue)
+}
+
+// EditIssueOption is the option of editing an issue or a pull request. Assignees
+// replaces all assignees when present, otherwise Assignee replaces all assignees
+// with a single user, or removes all of them if it is empty.
+type EditIssueOption struct {
+	api.EditIssueOption
+	Assignees []string `json:"assignees"`
 }
 
-func EditIssue(c *context.APIContext, form api.EditIssueOption) {
+func EditIssue(c *context.APIContext, form EditIssueOption) {
 	issue, err := db.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
 	if err != nil {
 		c.NotFoundOrError(err, "get issue by index")
 		return
 	}
 
+	updateIssue(c, issue, form)
+	if c.Written() {
+		return
+	}
+
+	// Refetch from database to assign some automatic values
+	issue, err = db.GetIssueByID(issue.ID)
+	if err != nil {
+		c.Error(err, "get issue by ID")
+		return
+	}
+	issueAPIFormat(c, http.StatusCreated, issue)
+}
+
+// updateIssue applies changes of given form to the issue (or pull request),
+// it writes an error response if anything goes wrong.
+func updateIssue(c *context.APIContext, issue *db.Issue, form EditIssueOption) {
 	if !issue.IsPoster(c.User.ID) && !c.Repo.IsWriter() {
 		c.Status(http.StatusForbidden)
 		return
 	}
 
+	var err error
 	if len(form.Title) > 0 {
 		issue.Title = form.Title
 	}
@@ -140,25 +254,18 @@ func EditIssue(c *context.APIContext, form api.EditIssueOption) {
 		issue.Content = *form.Body
 	}
 
-	if c.Repo.IsWriter() && form.Assignee != nil &&
-		(issue.Assignee == nil || issue.Assignee.LowerName != strings.ToLower(*form.Assignee)) {
-		if len(*form.Assignee) == 0 {
-			issue.AssigneeID = 0
-		} else {
-			assignee, err := db.GetUserByName(*form.Assignee)
-			if err != nil {
-				if db.IsErrUserNotExist(err) {
-					c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("assignee does not exist: [name: %s]", *form.Assignee))
-				} else {
-					c.Error(err, "get user by name")
-				}
-				return
-			}
-			issue.AssigneeID = assignee.ID
+	if c.Repo.IsWriter() && (form.Assignees != nil || form.Assignee != nil) {
+		names := form.Assignees
+		if names == nil && len(*form.Assignee) > 0 {
+			names = []string{*form.Assignee}
+		}
+		assigneeIDs := getAssigneeIDsByNames(c, names)
+		if c.Written() {
+			return
 		}
 
-		if err = db.UpdateIssueUserByAssignee(issue); err != nil {
-			c.Error(err, "update issue user by assignee")
+		if err = issue.ChangeAssignees(c.User, assigneeIDs); err != nil {
+			c.Error(err, "change assignees")
 			return
 		}
 	}
@@ -178,16 +285,12 @@ func EditIssue(c *context.APIContext, form api.EditIssueOption) {
 	}
 	if form.State != nil {
 		if err = issue.ChangeStatus(c.User, c.Repo.Repository, api.STATE_CLOSED == api.StateType(*form.State)); err != nil {
-			c.Error(err, "change status")
+			if db.IsErrIssueHasOpenBlockers(err) {
+				c.ErrorStatus(http.StatusUnprocessableEntity, err)
+			} else {
+				c.Error(err, "change status")
+			}
 			return
 		}
 	}
-
-	// Refetch from database to assign some automatic values
-	issue, err = db.GetIssueByID(issue.ID)
-	if err != nil {
-		c.Error(err, "get issue by ID")
-		return
-	}
-	c.JSON(http.StatusCreated, issue.APIFormat())
 }
@@ -0,0 +1,101 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+
+	api "github.com/gogs/go-gogs-client"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/db"
+)
+
+type BulkEditIssuesOption struct {
+	// Indexes of issues or pull requests to be changed.
+	Issues []int64 `json:"issues" binding:"Required"`
+	// Zero to remove the milestone.
+	Milestone    *int64  `json:"milestone"`
+	AddLabels    []int64 `json:"add_labels"`
+	RemoveLabels []int64 `json:"remove_labels"`
+	// Empty to remove all assignees.
+	Assignee *string `json:"assignee"`
+	// Either "open" or "closed".
+	State *string `json:"state"`
+	// Posted to every issue before its state is changed.
+	Comment string `json:"comment"`
+}
+
+func BulkEditIssues(c *context.APIContext, form BulkEditIssuesOption) {
+	issueIDs := make([]int64, 0, len(form.Issues))
+	for _, index := range form.Issues {
+		issue, err := db.GetRawIssueByIndex(c.Repo.Repository.ID, index)
+		if err != nil {
+			if db.IsErrIssueNotExist(err) {
+				c.ErrorStatus(http.StatusUnprocessableEntity, err)
+			} else {
+				c.Error(err, "get issue by index")
+			}
+			return
+		}
+		issueIDs = append(issueIDs, issue.ID)
+	}
+
+	opts := db.BulkEditIssuesOptions{
+		AddLabelIDs:    form.AddLabels,
+		RemoveLabelIDs: form.RemoveLabels,
+		Comment:        form.Comment,
+	}
+	if form.Milestone != nil {
+		opts.MilestoneID = *form.Milestone
+		if opts.MilestoneID == 0 {
+			opts.MilestoneID = -1
+		}
+	}
+	if form.Assignee != nil {
+		opts.AssigneeID = -1
+		if *form.Assignee != "" {
+			assignee, err := db.GetUserByName(*form.Assignee)
+			if err != nil {
+				if db.IsErrUserNotExist(err) {
+					c.ErrorStatus(http.StatusUnprocessableEntity, err)
+				} else {
+					c.Error(err, "get user by name")
+				}
+				return
+			}
+			opts.AssigneeID = assignee.ID
+		}
+	}
+	if form.State != nil {
+		opts.State = api.StateType(*form.State)
+		if opts.State != api.STATE_OPEN && opts.State != api.STATE_CLOSED {
+			c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("invalid state: %s", *form.State))
+			return
+		}
+	}
+
+	issues, err := db.BulkEditIssues(c.User, c.Repo.Repository, issueIDs, opts)
+	if err != nil {
+		switch {
+		case db.IsErrIssueHasOpenBlockers(err), db.IsErrMilestoneNotExist(err), db.IsErrUserNotExist(err):
+			c.ErrorStatus(http.StatusUnprocessableEntity, err)
+		default:
+			c.Error(err, "bulk edit issues")
+		}
+		return
+	}
+
+	apiIssues := make([]*db.APIIssue, len(issues))
+	for i := range issues {
+		apiIssues[i], err = issues[i].APIFormatWithDependencies(c.UserID())
+		if err != nil {
+			c.Error(err, "convert issue to API format")
+			return
+		}
+	}
+	c.JSONSuccess(&apiIssues)
+}
@@ -36,8 +36,14 @@ func ListIssueComments(c *context.APIContext) {
 		c.Error(err, "get comments by issue ID")
 		return
 	}
+	comments = db.VisibleComments(comments, c.User)
 
-	apiComments := make([]*api.Comment, len(comments))
+	if err = db.LoadCommentsReactions(comments); err != nil {
+		c.Error(err, "load comments reactions")
+		return
+	}
+
+	apiComments := make([]*db.APIComment, len(comments))
 	for i := range comments {
 		apiComments[i] = comments[i].APIFormat()
 	}
@@ -60,8 +66,14 @@ func ListRepoIssueComments(c *context.APIContext) {
 		c.Error(err, "get comments by repository ID")
 		return
 	}
+	comments = db.VisibleComments(comments, c.User)
+
+	if err = db.LoadCommentsReactions(comments); err != nil {
+		c.Error(err, "load comments reactions")
+		return
+	}
 
-	apiComments := make([]*api.Comment, len(comments))
+	apiComments := make([]*db.APIComment, len(comments))
 	for i := range comments {
 		apiComments[i] = comments[i].APIFormat()
 	}
@@ -75,6 +87,11 @@ func CreateIssueComment(c *context.APIContext, form api.CreateIssueCommentOption
 		return
 	}
 
+	if err = issue.CanComment(c.Repo.IsWriter()); err != nil {
+		c.ErrorStatus(http.StatusForbidden, err)
+		return
+	}
+
 	comment, err := db.CreateIssueComment(c.User, c.Repo.Repository, issue, form.Body, nil)
 	if err != nil {
 		c.Error(err, "create issue comment")
@@ -104,6 +121,9 @@ func EditIssueComment(c *context.APIContext, form api.EditIssueCommentOption) {
 	if err := db.UpdateComment(c.User, comment, oldContent); err != nil {
 		c.Error(err, "update comment")
 		return
+	} else if err = db.LoadCommentsReactions([]*db.Comment{comment}); err != nil {
+		c.Error(err, "load comment reactions")
+		return
 	}
 	c.JSONSuccess(comment.APIFormat())
 }
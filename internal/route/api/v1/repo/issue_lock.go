@@ -0,0 +1,49 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/http"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/db"
+)
+
+type LockIssueOption struct {
+	// One of "off-topic", "too heated", "resolved" and "spam", or empty.
+	LockReason string `json:"lock_reason"`
+}
+
+func LockIssue(c *context.APIContext, form LockIssueOption) {
+	issue, err := db.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return
+	}
+
+	if err = issue.Lock(c.User, form.LockReason); err != nil {
+		if db.IsErrInvalidLockReason(err) {
+			c.ErrorStatus(http.StatusUnprocessableEntity, err)
+		} else {
+			c.Error(err, "lock issue")
+		}
+		return
+	}
+	c.NoContent()
+}
+
+func UnlockIssue(c *context.APIContext) {
+	issue, err := db.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return
+	}
+
+	if err = issue.Unlock(c.User); err != nil {
+		c.Error(err, "unlock issue")
+		return
+	}
+	c.NoContent()
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/http"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/db"
+)
+
+type PinIssueOption struct {
+	// Position among pinned issues starting from 1, the issue is pinned at last if
+	// not given.
+	Position *int `json:"position"`
+}
+
+// ListPinnedIssues lists pinned issues of the repository in the pinned order, or
+// pinned pull requests if "type" is "pulls".
+func ListPinnedIssues(c *context.APIContext) {
+	issues, err := db.GetPinnedIssues(c.Repo.Repository.ID, c.Query("type") == "pulls")
+	if err != nil {
+		c.Error(err, "get pinned issues")
+		return
+	}
+
+	apiIssues := make([]*db.APIIssue, len(issues))
+	for i := range issues {
+		apiIssues[i], err = issues[i].APIFormatWithDependencies(c.UserID())
+		if err != nil {
+			c.Error(err, "convert issue to API format")
+			return
+		}
+	}
+	c.JSONSuccess(&apiIssues)
+}
+
+func PinIssue(c *context.APIContext, form PinIssueOption) {
+	issue, err := db.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return
+	}
+
+	if err = issue.Pin(); err != nil {
+		if db.IsErrPinnedIssuesLimitReached(err) {
+			c.ErrorStatus(http.StatusUnprocessableEntity, err)
+		} else {
+			c.Error(err, "pin issue")
+		}
+		return
+	}
+	if form.Position != nil {
+		if err = issue.MovePin(*form.Position); err != nil {
+			c.Error(err, "move pinned issue")
+			return
+		}
+	}
+	c.NoContent()
+}
+
+func UnpinIssue(c *context.APIContext) {
+	issue, err := db.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return
+	}
+
+	if err = issue.Unpin(); err != nil {
+		c.Error(err, "unpin issue")
+		return
+	}
+	c.NoContent()
+}
@@ -0,0 +1,89 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/http"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/db"
+)
+
+type ReactionOption struct {
+	// One of "+1", "-1", "laugh", "hooray", "confused", "heart", "rocket" and "eyes".
+	Content string `json:"content" binding:"Required"`
+}
+
+// getReactionTarget returns IDs of the issue and the comment of current request,
+// the comment ID is zero for reactions to the issue itself.
+func getReactionTarget(c *context.APIContext) (issueID, commentID int64) {
+	if c.Params(":id") == "" {
+		issue, err := db.GetRawIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+		if err != nil {
+			c.NotFoundOrError(err, "get raw issue by index")
+			return 0, 0
+		}
+		return issue.ID, 0
+	}
+
+	comment, err := db.GetCommentByID(c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get comment by ID")
+		return 0, 0
+	} else if comment.Issue.RepoID != c.Repo.Repository.ID {
+		c.NotFound()
+		return 0, 0
+	}
+	return comment.IssueID, comment.ID
+}
+
+func ListReactions(c *context.APIContext) {
+	issueID, commentID := getReactionTarget(c)
+	if c.Written() {
+		return
+	}
+
+	reactions, err := db.GetReactions(issueID, commentID)
+	if err != nil {
+		c.Error(err, "get reactions")
+		return
+	}
+	c.JSONSuccess(reactions.APIFormat())
+}
+
+func CreateReaction(c *context.APIContext, form ReactionOption) {
+	issueID, commentID := getReactionTarget(c)
+	if c.Written() {
+		return
+	}
+
+	r, err := db.CreateReaction(c.User, issueID, commentID, form.Content)
+	if err != nil {
+		if db.IsErrInvalidReaction(err) {
+			c.ErrorStatus(http.StatusUnprocessableEntity, err)
+		} else {
+			c.Error(err, "create reaction")
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, r.APIFormat())
+}
+
+func DeleteReaction(c *context.APIContext, form ReactionOption) {
+	issueID, commentID := getReactionTarget(c)
+	if c.Written() {
+		return
+	}
+
+	if err := db.DeleteReaction(c.User, issueID, commentID, form.Content); err != nil {
+		if db.IsErrInvalidReaction(err) {
+			c.ErrorStatus(http.StatusUnprocessableEntity, err)
+		} else {
+			c.Error(err, "delete reaction")
+		}
+		return
+	}
+	c.NoContent()
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/db"
+)
+
+// parseTrackedTimeOptions parses filters of tracked times from query parameters,
+// "since" and "before" are in RFC 3339 format. It writes an error response if any
+// of the parameters is invalid.
+func parseTrackedTimeOptions(c *context.APIContext) db.TrackedTimeOptions {
+	var opts db.TrackedTimeOptions
+	if name := c.Query("user"); name != "" {
+		u, err := db.GetUserByName(name)
+		if err != nil {
+			if db.IsErrUserNotExist(err) {
+				c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("user does not exist: [name: %s]", name))
+			} else {
+				c.Error(err, "get user by name")
+			}
+			return opts
+		}
+		opts.UserID = u.ID
+	}
+
+	var err error
+	if opts.Since, err = parseTimeQuery(c, "since"); err != nil {
+		c.ErrorStatus(http.StatusUnprocessableEntity, err)
+		return opts
+	}
+	if opts.Before, err = parseTimeQuery(c, "before"); err != nil {
+		c.ErrorStatus(http.StatusUnprocessableEntity, err)
+		return opts
+	}
+	return opts
+}
+
+// parseTimeQuery returns the Unix timestamp of given query parameter in RFC 3339
+// format, or 0 if the parameter is not present.
+func parseTimeQuery(c *context.APIContext, key string) (int64, error) {
+	value := c.Query(key)
+	if value == "" {
+		return 0, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %v", key, err)
+	}
+	return t.Unix(), nil
+}
+
+func listTrackedTimes(c *context.APIContext, opts db.TrackedTimeOptions) {
+	times, err := db.GetTrackedTimes(opts)
+	if err != nil {
+		c.Error(err, "get tracked times")
+		return
+	}
+
+	apiTimes := make([]*db.APITrackedTime, len(times))
+	for i := range times {
+		apiTimes[i] = times[i].APIFormat()
+	}
+	c.JSONSuccess(&apiTimes)
+}
+
+func ListIssueTrackedTimes(c *context.APIContext) {
+	issue, err := db.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return
+	}
+
+	opts := parseTrackedTimeOptions(c)
+	if c.Written() {
+		return
+	}
+	opts.IssueID = issue.ID
+	listTrackedTimes(c, opts)
+}
+
+func ListRepoTrackedTimes(c *context.APIContext) {
+	opts := parseTrackedTimeOptions(c)
+	if c.Written() {
+		return
+	}
+	opts.RepoID = c.Repo.Repository.ID
+	listTrackedTimes(c, opts)
+}
+
+type AddTrackedTimeOption struct {
+	// The time spent in seconds.
+	Time int64 `json:"time" binding:"Required"`
+}
+
+func AddIssueTrackedTime(c *context.APIContext, form AddTrackedTimeOption) {
+	issue, err := db.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return
+	}
+
+	if form.Time <= 0 {
+		c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("time must be positive: %d", form.Time))
+		return
+	}
+
+	t, err := db.AddTrackedTime(issue, c.User, form.Time)
+	if err != nil {
+		c.Error(err, "add tracked time")
+		return
+	}
+	c.JSON(http.StatusCreated, t.APIFormat())
+}
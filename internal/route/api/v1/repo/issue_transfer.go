@@ -0,0 +1,49 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"errors"
+	"net/http"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/db"
+)
+
+type TransferIssueOption struct {
+	// Name of the target repository, which must have the same owner.
+	Repository string `json:"repository" binding:"Required"`
+}
+
+func TransferIssue(c *context.APIContext, form TransferIssueOption) {
+	issue, err := db.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return
+	}
+
+	target, err := db.GetRepositoryByName(c.Repo.Owner.ID, form.Repository)
+	if err != nil {
+		if db.IsErrRepoNotExist(err) {
+			c.ErrorStatus(http.StatusUnprocessableEntity, err)
+		} else {
+			c.Error(err, "get repository by name")
+		}
+		return
+	} else if !db.Perms.Authorize(c.User.ID, target, db.AccessModeWrite) {
+		c.ErrorStatus(http.StatusForbidden, errors.New("User does not have write access to the target repository."))
+		return
+	}
+
+	if err = issue.TransferTo(c.User, target); err != nil {
+		if db.IsErrIssueTransferNotAllowed(err) {
+			c.ErrorStatus(http.StatusUnprocessableEntity, err)
+		} else {
+			c.Error(err, "transfer issue")
+		}
+		return
+	}
+	issueAPIFormat(c, http.StatusOK, issue)
+}
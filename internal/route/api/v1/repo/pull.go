@@ -0,0 +1,305 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gogs/git-module"
+	api "github.com/gogs/go-gogs-client"
+	"github.com/pkg/errors"
+	log "unknwon.dev/clog/v2"
+
+	"gogs.io/gogs/internal/conf"
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/db"
+	"gogs.io/gogs/internal/gitutil"
+)
+
+// pullRequestAPIFormat returns the API format of the pull request of given issue.
+func pullRequestAPIFormat(issue *db.Issue) *api.PullRequest {
+	issue.PullRequest.Issue = issue
+	return issue.PullRequest.APIFormat()
+}
+
+func ListPullRequests(c *context.APIContext) {
+	opts := &db.IssuesOptions{
+		RepoID:   c.Repo.Repository.ID,
+		Page:     c.QueryInt("page"),
+		IsClosed: api.StateType(c.Query("state")) == api.STATE_CLOSED,
+		IsPull:   true,
+		SortType: c.Query("sort"),
+	}
+
+	issues, err := db.Issues(opts)
+	if err != nil {
+		c.Error(err, "list pull requests")
+		return
+	}
+
+	count, err := db.IssuesCount(opts)
+	if err != nil {
+		c.Error(err, "count pull requests")
+		return
+	}
+
+	apiPullRequests := make([]*api.PullRequest, len(issues))
+	for i := range issues {
+		if err = issues[i].LoadAttributes(); err != nil {
+			c.Error(err, "load attributes")
+			return
+		}
+		apiPullRequests[i] = pullRequestAPIFormat(issues[i])
+	}
+
+	c.SetLinkHeader(int(count), conf.UI.IssuePagingNum)
+	c.JSONSuccess(&apiPullRequests)
+}
+
+func GetPullRequest(c *context.APIContext) {
+	issue := getPullIssueByIndex(c)
+	if c.Written() {
+		return
+	}
+	c.JSONSuccess(pullRequestAPIFormat(issue))
+}
+
+type CreatePullRequestOption struct {
+	Title     string   `json:"title" binding:"Required;MaxSize(255)"`
+	Head      string   `json:"head" binding:"Required"`
+	Base      string   `json:"base" binding:"Required"`
+	Body      string   `json:"body"`
+	Assignee  string   `json:"assignee"`
+	Assignees []string `json:"assignees"`
+	Milestone int64    `json:"milestone"`
+	Labels    []int64  `json:"labels"`
+	Draft     bool     `json:"draft"`
+}
+
+func CreatePullRequest(c *context.APIContext, form CreatePullRequestOption) {
+	baseRepo := c.Repo.Repository
+	baseGitRepo, err := git.Open(baseRepo.RepoPath())
+	if err != nil {
+		c.Error(err, "open repository")
+		return
+	}
+	if !baseGitRepo.HasBranch(form.Base) {
+		c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("base branch does not exist: [name: %s]", form.Base))
+		return
+	}
+
+	// The head is either "<branch>" for the same repository or "<username>:<branch>" for a fork.
+	var (
+		headUser   = c.Repo.Owner
+		headBranch = form.Head
+	)
+	if i := strings.Index(form.Head, ":"); i > -1 {
+		headUser, err = db.GetUserByName(form.Head[:i])
+		if err != nil {
+			if db.IsErrUserNotExist(err) {
+				c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("head user does not exist: [name: %s]", form.Head[:i]))
+			} else {
+				c.Error(err, "get user by name")
+			}
+			return
+		}
+		headBranch = form.Head[i+1:]
+	}
+
+	headRepo := baseRepo
+	headGitRepo := baseGitRepo
+	if headUser.ID != baseRepo.OwnerID {
+		var has bool
+		headRepo, has, err = db.HasForkedRepo(headUser.ID, baseRepo.ID)
+		if err != nil {
+			c.Error(err, "get forked repository")
+			return
+		} else if !has {
+			c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("head user does not have a fork: [name: %s]", headUser.Name))
+			return
+		}
+
+		headGitRepo, err = git.Open(db.RepoPath(headUser.Name, headRepo.Name))
+		if err != nil {
+			c.Error(err, "open repository")
+			return
+		}
+	}
+
+	if !c.User.IsWriterOfRepo(headRepo) && !c.User.IsAdmin {
+		c.Status(http.StatusForbidden)
+		return
+	}
+
+	if !headGitRepo.HasBranch(headBranch) {
+		c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("head branch does not exist: [name: %s]", headBranch))
+		return
+	}
+
+	if _, err = db.GetUnmergedPullRequest(headRepo.ID, baseRepo.ID, headBranch, form.Base); err == nil {
+		c.ErrorStatus(http.StatusConflict, errors.New("pull request with same head and base branches already exists"))
+		return
+	} else if !db.IsErrPullRequestNotExist(err) {
+		c.Error(err, "get unmerged pull request")
+		return
+	}
+
+	meta, err := gitutil.Module.PullRequestMeta(headGitRepo.Path(), baseRepo.RepoPath(), headBranch, form.Base)
+	if err != nil {
+		if gitutil.IsErrNoMergeBase(err) {
+			c.ErrorStatus(http.StatusUnprocessableEntity, errors.New("head and base branches have no merge base"))
+		} else {
+			c.Error(err, "get pull request meta")
+		}
+		return
+	}
+
+	patch, err := headGitRepo.DiffBinary(meta.MergeBase, headBranch)
+	if err != nil {
+		c.Error(err, "get patch")
+		return
+	}
+
+	pullIssue := &db.Issue{
+		RepoID:   baseRepo.ID,
+		Index:    baseRepo.NextIssueIndex(),
+		Title:    form.Title,
+		PosterID: c.User.ID,
+		Poster:   c.User,
+		IsPull:   true,
+		Content:  form.Body,
+	}
+	if c.Repo.IsWriter() {
+		if len(form.Assignee) > 0 {
+			form.Assignees = append([]string{form.Assignee}, form.Assignees...)
+		}
+		pullIssue.AssigneeIDs = getAssigneeIDsByNames(c, form.Assignees)
+		if c.Written() {
+			return
+		}
+		pullIssue.MilestoneID = form.Milestone
+	} else {
+		form.Labels = nil
+	}
+
+	pullRequest := &db.PullRequest{
+		HeadRepoID:   headRepo.ID,
+		BaseRepoID:   baseRepo.ID,
+		HeadUserName: headUser.Name,
+		HeadBranch:   headBranch,
+		BaseBranch:   form.Base,
+		HeadRepo:     headRepo,
+		BaseRepo:     baseRepo,
+		MergeBase:    meta.MergeBase,
+		Type:         db.PULL_REQUEST_GOGS,
+		IsDraft:      form.Draft,
+	}
+	if err = db.NewPullRequest(baseRepo, pullIssue, form.Labels, nil, pullRequest, patch); err != nil {
+		c.Error(err, "new pull request")
+		return
+	} else if err = pullRequest.PushToBaseRepo(); err != nil {
+		c.Error(err, "push to base repository")
+		return
+	}
+	log.Trace("Pull request created: %d/%d", baseRepo.ID, pullIssue.ID)
+
+	// Refetch from database to assign some automatic values
+	pullIssue, err = db.GetIssueByID(pullIssue.ID)
+	if err != nil {
+		c.Error(err, "get issue by ID")
+		return
+	}
+	c.JSON(http.StatusCreated, pullRequestAPIFormat(pullIssue))
+}
+
+func EditPullRequest(c *context.APIContext, form EditIssueOption) {
+	issue := getPullIssueByIndex(c)
+	if c.Written() {
+		return
+	}
+
+	if form.State != nil && issue.PullRequest.HasMerged {
+		c.ErrorStatus(http.StatusUnprocessableEntity, errors.New("cannot change state of a merged pull request"))
+		return
+	}
+
+	updateIssue(c, issue, form)
+	if c.Written() {
+		return
+	}
+
+	// Refetch from database to assign some automatic values
+	issue, err := db.GetIssueByID(issue.ID)
+	if err != nil {
+		c.Error(err, "get issue by ID")
+		return
+	}
+	c.JSON(http.StatusCreated, pullRequestAPIFormat(issue))
+}
+
+func IsPullRequestMerged(c *context.APIContext) {
+	issue := getPullIssueByIndex(c)
+	if c.Written() {
+		return
+	}
+
+	if !issue.PullRequest.HasMerged {
+		c.NotFound()
+		return
+	}
+	c.NoContent()
+}
+
+type MergePullRequestOption struct {
+	Style       string `json:"merge_style"`
+	Description string `json:"commit_description"`
+}
+
+func MergePullRequest(c *context.APIContext, form MergePullRequestOption) {
+	issue := getPullIssueByIndex(c)
+	if c.Written() {
+		return
+	}
+
+	pr := issue.PullRequest
+	if issue.IsClosed || pr.HasMerged {
+		c.ErrorStatus(http.StatusMethodNotAllowed, errors.New("pull request is closed or has been merged"))
+		return
+	} else if !pr.CanAutoMerge() {
+		c.ErrorStatus(http.StatusMethodNotAllowed, errors.New("pull request cannot be merged automatically"))
+		return
+	}
+
+	style := db.MergeStyle(form.Style)
+	if style == "" {
+		style = db.MERGE_STYLE_REGULAR
+	}
+	if !c.Repo.Repository.AllowsMergeStyle(style) {
+		c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("merge style is not allowed: %s", style))
+		return
+	}
+
+	baseGitRepo, err := git.Open(c.Repo.Repository.RepoPath())
+	if err != nil {
+		c.Error(err, "open repository")
+		return
+	}
+
+	pr.Issue = issue
+	pr.Issue.Repo = c.Repo.Repository
+	if err = pr.Merge(c.User, baseGitRepo, style, form.Description); err != nil {
+		if db.IsErrMergeRequirementsNotMet(err) || db.IsErrPullRequestNotFastForward(err) || db.IsErrPullRequestIsDraft(err) {
+			c.ErrorStatus(http.StatusMethodNotAllowed, err)
+		} else {
+			c.Error(err, "merge")
+		}
+		return
+	}
+	log.Trace("Pull request merged: %d", pr.ID)
+
+	c.NoContent()
+}
@@ -0,0 +1,132 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/db"
+)
+
+// getPullIssueByIndex returns the issue of the pull request by the index in URL parameters,
+// it writes a 404 response if the issue does not exist or is not a pull request.
+func getPullIssueByIndex(c *context.APIContext) *db.Issue {
+	issue, err := db.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return nil
+	} else if !issue.IsPull {
+		c.NotFound()
+		return nil
+	}
+	return issue
+}
+
+func ListPullReviews(c *context.APIContext) {
+	issue := getPullIssueByIndex(c)
+	if c.Written() {
+		return
+	}
+
+	reviews, err := db.GetReviewsByIssueID(issue.ID)
+	if err != nil {
+		c.Error(err, "get reviews by issue ID")
+		return
+	}
+
+	apiReviews := make([]*db.APIReview, len(reviews))
+	for i := range reviews {
+		apiReviews[i] = reviews[i].APIFormat()
+	}
+	c.JSONSuccess(&apiReviews)
+}
+
+func GetPullReview(c *context.APIContext) {
+	issue := getPullIssueByIndex(c)
+	if c.Written() {
+		return
+	}
+
+	review, err := db.GetReviewOfIssueByID(issue.ID, c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get review of issue by ID")
+		return
+	}
+
+	// Pending reviews are only visible to the reviewer.
+	if review.IsPending() && review.ReviewerID != c.User.ID {
+		c.NotFound()
+		return
+	}
+	c.JSONSuccess(review.APIFormat())
+}
+
+type CreatePullReviewOption struct {
+	Body  string `json:"body"`
+	State string `json:"state" binding:"Required;In(pending,approved,changes_requested)"`
+}
+
+func CreatePullReview(c *context.APIContext, form CreatePullReviewOption) {
+	issue := getPullIssueByIndex(c)
+	if c.Written() {
+		return
+	}
+	if err := issue.CanComment(c.Repo.IsWriter()); err != nil {
+		c.ErrorStatus(http.StatusForbidden, err)
+		return
+	}
+	if issue.IsClosed {
+		c.ErrorStatus(http.StatusUnprocessableEntity, errors.New("pull request is closed"))
+		return
+	}
+
+	commitSHA, err := issue.PullRequest.HeadCommitID()
+	if err != nil {
+		c.Error(err, "get head commit ID")
+		return
+	}
+
+	review, err := db.CreateReview(db.CreateReviewOptions{
+		Doer:      c.User,
+		Repo:      c.Repo.Repository,
+		Issue:     issue,
+		State:     db.ToReviewState(form.State),
+		Content:   form.Body,
+		CommitSHA: commitSHA,
+	})
+	if err != nil {
+		if db.IsErrReviewOwnPullRequest(err) {
+			c.ErrorStatus(http.StatusUnprocessableEntity, err)
+		} else {
+			c.Error(err, "create review")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, review.APIFormat())
+}
+
+func DismissPullReview(c *context.APIContext) {
+	issue := getPullIssueByIndex(c)
+	if c.Written() {
+		return
+	}
+
+	review, err := db.GetReviewOfIssueByID(issue.ID, c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get review of issue by ID")
+		return
+	}
+
+	if err = review.Dismiss(c.User); err != nil {
+		c.Error(err, "dismiss review")
+		return
+	}
+
+	c.JSONSuccess(review.APIFormat())
+}
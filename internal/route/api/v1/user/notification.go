@@ -0,0 +1,100 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/db"
+)
+
+// ListNotifications lists notifications of the authenticated user, only unread and
+// pinned ones are listed unless "all" is true or a "status" is given.
+func ListNotifications(c *context.APIContext) {
+	var statuses []db.NotificationStatus
+	if name := c.Query("status"); name != "" {
+		status := db.ParseNotificationStatus(name)
+		if status == 0 {
+			c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("invalid status %q", name))
+			return
+		}
+		statuses = []db.NotificationStatus{status}
+	} else if !c.QueryBool("all") {
+		statuses = []db.NotificationStatus{db.NOTIFICATION_STATUS_UNREAD, db.NOTIFICATION_STATUS_PINNED}
+	}
+
+	notifications, err := db.Notifications(&db.NotificationsOptions{
+		UserID:   c.User.ID,
+		Statuses: statuses,
+		Page:     c.QueryInt("page"),
+	})
+	if err != nil {
+		c.Error(err, "list notifications")
+		return
+	}
+
+	apiNotifications := make([]*db.APINotification, len(notifications))
+	for i := range notifications {
+		apiNotifications[i] = notifications[i].APIFormat()
+	}
+	c.JSONSuccess(&apiNotifications)
+}
+
+// MarkNotificationsRead marks all unread notifications of the authenticated user as
+// read, or only the ones updated no later than "last_read_at" if given.
+func MarkNotificationsRead(c *context.APIContext) {
+	before := time.Now()
+	if lastReadAt := c.Query("last_read_at"); lastReadAt != "" {
+		var err error
+		before, err = time.Parse(time.RFC3339, lastReadAt)
+		if err != nil {
+			c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("parse last_read_at: %v", err))
+			return
+		}
+	}
+
+	if err := db.MarkNotificationsRead(c.User.ID, before); err != nil {
+		c.Error(err, "mark notifications read")
+		return
+	}
+	c.NoContent()
+}
+
+func GetNotification(c *context.APIContext) {
+	n, err := db.GetNotificationByID(c.User.ID, c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get notification by ID")
+		return
+	}
+	c.JSONSuccess(n.APIFormat())
+}
+
+// UpdateNotification changes status of a notification of the authenticated user to
+// the one given by "status", it marks the notification as read by default.
+func UpdateNotification(c *context.APIContext) {
+	n, err := db.GetNotificationByID(c.User.ID, c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get notification by ID")
+		return
+	}
+
+	status := db.NOTIFICATION_STATUS_READ
+	if name := c.Query("status"); name != "" {
+		status = db.ParseNotificationStatus(name)
+		if status == 0 {
+			c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("invalid status %q", name))
+			return
+		}
+	}
+
+	if err = n.SetStatus(status); err != nil {
+		c.Error(err, "set notification status")
+		return
+	}
+	c.JSONSuccess(n.APIFormat())
+}
@@ -23,6 +23,7 @@ import (
 	"gogs.io/gogs/internal/cron"
 	"gogs.io/gogs/internal/db"
 	"gogs.io/gogs/internal/email"
+	"gogs.io/gogs/internal/email/incoming"
 	"gogs.io/gogs/internal/form"
 	"gogs.io/gogs/internal/markup"
 	"gogs.io/gogs/internal/osutil"
@@ -65,6 +66,9 @@ func GlobalInit(customConf string) error {
 	if conf.Email.Enabled {
 		log.Trace("Email service is enabled")
 	}
+	if conf.IncomingEmail.Enabled {
+		log.Trace("Incoming email service is enabled")
+	}
 
 	email.NewContext()
 
@@ -79,12 +83,16 @@ func GlobalInit(customConf string) error {
 		db.LoadAuthSources()
 		db.LoadRepoConfig()
 		db.NewRepoContext()
+		if err := db.InitIssueIndexer(); err != nil {
+			log.Fatal("Failed to initialize issue indexer: %v", err)
+		}
 
 		// Booting long running goroutines.
 		cron.NewContext()
 		db.InitSyncMirrors()
 		db.InitDeliverHooks()
 		db.InitTestPullRequests()
+		incoming.Start()
 	}
 	if conf.HasMinWinSvc {
 		log.Info("Builtin Windows Service is supported")
@@ -23,9 +23,10 @@ const (
 )
 
 type Branch struct {
-	Name        string
-	Commit      *git.Commit
-	IsProtected bool
+	Name         string
+	Commit       *git.Commit
+	CommitStatus *db.CombinedCommitStatus
+	IsProtected  bool
 }
 
 func loadBranches(c *context.Context) []*Branch {
@@ -62,6 +63,19 @@ func loadBranches(c *context.Context) []*Branch {
 		}
 	}
 
+	shas := make([]string, len(branches))
+	for i := range branches {
+		shas[i] = branches[i].Commit.ID.String()
+	}
+	statuses, err := db.GetCombinedCommitStatusesBySHAs(shas, c.Repo.Repository.ID)
+	if err != nil {
+		c.Error(err, "get combined commit statuses by SHAs")
+		return nil
+	}
+	for i := range branches {
+		branches[i].CommitStatus = statuses[shas[i]]
+	}
+
 	c.Data["AllowPullRequest"] = c.Repo.Repository.AllowsPulls()
 	return branches
 }
@@ -33,6 +33,22 @@ func RefCommits(c *context.Context) {
 	}
 }
 
+// setCommitStatuses sets combined statuses of given commits reported in given
+// repositories for rendering the commits table.
+func setCommitStatuses(c *context.Context, commits []*git.Commit, repoIDs ...int64) {
+	shas := make([]string, len(commits))
+	for i := range commits {
+		shas[i] = commits[i].ID.String()
+	}
+
+	statuses, err := db.GetCombinedCommitStatusesBySHAs(shas, repoIDs...)
+	if err != nil {
+		c.Error(err, "get combined commit statuses by SHAs")
+		return
+	}
+	c.Data["CommitStatuses"] = statuses
+}
+
 // TODO(unknwon)
 func RenderIssueLinks(oldCommits []*git.Commit, repoLink string) []*git.Commit {
 	return oldCommits
@@ -60,6 +76,10 @@ func renderCommits(c *context.Context, filename string) {
 
 	commits = RenderIssueLinks(commits, c.Repo.RepoLink)
 	c.Data["Commits"] = db.ValidateCommitsWithEmails(commits)
+	setCommitStatuses(c, commits, c.Repo.Repository.ID)
+	if c.Written() {
+		return
+	}
 
 	if page > 1 {
 		c.Data["HasPrevious"] = true
@@ -97,6 +117,10 @@ func SearchCommits(c *context.Context) {
 
 	commits = RenderIssueLinks(commits, c.Repo.RepoLink)
 	c.Data["Commits"] = db.ValidateCommitsWithEmails(commits)
+	setCommitStatuses(c, commits, c.Repo.Repository.ID)
+	if c.Written() {
+		return
+	}
 
 	c.Data["Keyword"] = keyword
 	c.Data["Username"] = c.Repo.Owner.Name
@@ -210,6 +234,10 @@ func CompareDiff(c *context.Context) {
 	c.Data["CommitRepoLink"] = c.Repo.RepoLink
 	c.Data["Commits"] = db.ValidateCommitsWithEmails(commits)
 	c.Data["CommitsCount"] = len(commits)
+	setCommitStatuses(c, commits, c.Repo.Repository.ID)
+	if c.Written() {
+		return
+	}
 	c.Data["BeforeCommitID"] = beforeCommitID
 	c.Data["AfterCommitID"] = afterCommitID
 	c.Data["Username"] = userName
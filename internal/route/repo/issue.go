@@ -19,6 +19,7 @@ import (
 	"gogs.io/gogs/internal/context"
 	"gogs.io/gogs/internal/db"
 	"gogs.io/gogs/internal/db/errors"
+	"gogs.io/gogs/internal/errutil"
 	"gogs.io/gogs/internal/form"
 	"gogs.io/gogs/internal/markup"
 	"gogs.io/gogs/internal/tool"
@@ -29,6 +30,8 @@ const (
 	ISSUE_NEW  = "repo/issue/new"
 	ISSUE_VIEW = "repo/issue/view"
 
+	ISSUE_CHOOSE_TEMPLATE = "repo/issue/choose"
+
 	LABELS = "repo/issue/labels"
 
 	MILESTONE      = "repo/issue/milestones"
@@ -144,6 +147,23 @@ func issues(c *context.Context, isPullList bool) {
 	selectLabels := c.Query("labels")
 	milestoneID := c.QueryInt64("milestone")
 	isShowClosed := c.Query("state") == "closed"
+	draft := db.ToDraftFilter(c.Query("draft"))
+
+	// Issues that match the keyword are sorted by relevance by default.
+	keyword := strings.TrimSpace(c.Query("q"))
+	var issueIDs []int64
+	if keyword != "" {
+		var err error
+		issueIDs, err = db.SearchIssueIndex(keyword, []int64{repo.ID})
+		if err != nil {
+			c.Error(err, "search issue index")
+			return
+		}
+		if sortType == "" {
+			sortType = "relevance"
+		}
+	}
+
 	issueStats := db.GetIssueStats(&db.IssueStatsOptions{
 		RepoID:      repo.ID,
 		UserID:      uid,
@@ -152,6 +172,8 @@ func issues(c *context.Context, isPullList bool) {
 		AssigneeID:  assigneeID,
 		FilterMode:  filterMode,
 		IsPull:      isPullList,
+		Draft:       draft,
+		IssueIDs:    issueIDs,
 	})
 
 	page := c.QueryInt("page")
@@ -178,8 +200,10 @@ func issues(c *context.Context, isPullList bool) {
 		IsClosed:    isShowClosed,
 		IsMention:   filterMode == db.FILTER_MODE_MENTION,
 		IsPull:      isPullList,
+		Draft:       draft,
 		Labels:      selectLabels,
 		SortType:    sortType,
+		IssueIDs:    issueIDs,
 	})
 	if err != nil {
 		c.Error(err, "list issues")
@@ -210,6 +234,12 @@ func issues(c *context.Context, isPullList bool) {
 	}
 	c.Data["Issues"] = issues
 
+	c.Data["PinnedIssues"], err = db.GetPinnedIssues(repo.ID, isPullList)
+	if err != nil {
+		c.Error(err, "get pinned issues")
+		return
+	}
+
 	// Get milestones.
 	c.Data["Milestones"], err = db.GetMilestonesByRepoID(repo.ID)
 	if err != nil {
@@ -232,9 +262,11 @@ func issues(c *context.Context, isPullList bool) {
 	c.Data["SelectLabels"] = com.StrTo(selectLabels).MustInt64()
 	c.Data["ViewType"] = viewType
 	c.Data["SortType"] = sortType
+	c.Data["Keyword"] = keyword
 	c.Data["MilestoneID"] = milestoneID
 	c.Data["AssigneeID"] = assigneeID
 	c.Data["IsShowClosed"] = isShowClosed
+	c.Data["Draft"] = string(draft)
 	if isShowClosed {
 		c.Data["State"] = "closed"
 	} else {
@@ -337,7 +369,26 @@ func NewIssue(c *context.Context) {
 	c.Data["RequireSimpleMDE"] = true
 	c.Data["title"] = c.Query("title")
 	c.Data["content"] = c.Query("content")
-	setTemplateIfExists(c, ISSUE_TEMPLATE_KEY, IssueTemplateCandidates)
+
+	// Let the user choose when there are multiple templates, or "none" for a blank issue.
+	switch name := c.Query("template"); name {
+	case "none":
+	case "":
+		templates := getIssueTemplates(c)
+		if len(templates) > 1 {
+			c.Data["IssueTemplates"] = templates
+			c.Success(ISSUE_CHOOSE_TEMPLATE)
+			return
+		} else if len(templates) == 1 {
+			setIssueTemplate(c, ISSUE_TEMPLATE_KEY, templates[0])
+		} else {
+			setTemplateIfExists(c, ISSUE_TEMPLATE_KEY, IssueTemplateCandidates)
+		}
+	default:
+		if tmpl := getIssueTemplate(c, name); tmpl != nil {
+			setIssueTemplate(c, ISSUE_TEMPLATE_KEY, tmpl)
+		}
+	}
 	renderAttachmentSettings(c)
 
 	RetrieveRepoMetas(c, c.Repo.Repository)
@@ -415,10 +466,19 @@ func NewIssuePost(c *context.Context, f form.NewIssue) {
 	}
 
 	if c.HasError() {
+		c.Data["template"] = f.Template
 		c.Success(ISSUE_NEW)
 		return
 	}
 
+	var tmpl *IssueTemplate
+	if f.Template != "" {
+		tmpl = getIssueTemplate(c, f.Template)
+	}
+	if tmpl != nil && assigneeID == 0 {
+		assigneeID = templateAssigneeID(c.Repo.Repository, tmpl)
+	}
+
 	var attachments []string
 	if conf.Attachment.Enabled {
 		attachments = f.Files
@@ -437,6 +497,9 @@ func NewIssuePost(c *context.Context, f form.NewIssue) {
 		c.Error(err, "new issue")
 		return
 	}
+	if tmpl != nil {
+		applyTemplateLabels(c, issue.ID, tmpl)
+	}
 
 	log.Trace("Issue created: %d/%d", c.Repo.Repository.ID, issue.ID)
 	c.RawRedirect(c.Repo.MakeURL(fmt.Sprintf("issues/%d", issue.Index)))
@@ -492,6 +555,20 @@ func UploadIssueAttachment(c *context.Context) {
 	uploadAttachment(c, conf.Attachment.AllowedTypes)
 }
 
+// redirectTransferredIssue redirects to the issue that was transferred from given
+// index of current repository if current user has read access to it.
+func redirectTransferredIssue(c *context.Context, index int64) {
+	issue, err := db.GetRedirectedIssue(c.Repo.Repository.ID, index)
+	if err != nil {
+		c.NotFoundOrError(err, "get redirected issue")
+		return
+	} else if !db.Perms.Authorize(c.UserID(), issue.Repo, db.AccessModeRead) {
+		c.NotFound()
+		return
+	}
+	c.RawRedirect(issue.HTMLURL())
+}
+
 func viewIssue(c *context.Context, isPullList bool) {
 	c.Data["RequireHighlightJS"] = true
 	c.Data["RequireDropzone"] = true
@@ -505,7 +582,11 @@ func viewIssue(c *context.Context, isPullList bool) {
 
 	issue, err := db.GetIssueByIndex(c.Repo.Repository.ID, index)
 	if err != nil {
-		c.NotFoundOrError(err, "get issue by index")
+		if db.IsErrIssueNotExist(err) {
+			redirectTransferredIssue(c, index)
+			return
+		}
+		c.Error(err, "get issue by index")
 		return
 	}
 	c.Data["Title"] = issue.Title
@@ -534,17 +615,60 @@ func viewIssue(c *context.Context, isPullList bool) {
 		c.Data["PageIsIssueList"] = true
 	}
 
+	if c.IsLogged {
+		if err = db.MarkIssueNotificationRead(c.User.ID, issue.ID); err != nil {
+			log.Error("Failed to mark notification as read [user_id: %d, issue_id: %d]: %v", c.User.ID, issue.ID, err)
+		}
+		c.Data["NotificationUnreadCount"] = db.CountUnreadNotifications(c.User.ID)
+	}
+
 	issue.RenderedContent = string(markup.Markdown(issue.Content, c.Repo.RepoLink, c.Repo.Repository.ComposeMetas()))
 
 	repo := c.Repo.Repository
 
 	// Get more information if it's a pull request.
 	if issue.IsPull {
+		c.Data["IsPullDraft"] = issue.PullRequest.IsDraft
+		c.Data["CanToggleDraft"] = c.IsLogged && !issue.IsClosed && !issue.PullRequest.HasMerged &&
+			(issue.IsPoster(c.User.ID) || c.Repo.IsWriter())
 		if issue.PullRequest.HasMerged {
 			c.Data["DisableStatusChange"] = issue.PullRequest.HasMerged
 			PrepareMergedViewPullInfo(c, issue)
-		} else {
-			PrepareViewPullInfo(c, issue)
+		} else if prMeta := PrepareViewPullInfo(c, issue); prMeta != nil && !issue.IsClosed {
+			reqs, err := issue.PullRequest.GetMergeRequirements(c.User)
+			if err != nil {
+				c.Error(err, "get merge requirements")
+				return
+			}
+			c.Data["MergeRequirements"] = reqs
+			if repo.PullsAllowSquash {
+				c.Data["SquashCommitDescription"] = squashCommitDescription(prMeta.Commits)
+			}
+			if issue.PullRequest.IsAutoMergeScheduled() {
+				autoMerger, err := db.GetUserByID(issue.PullRequest.AutoMergerID)
+				if err != nil {
+					if !db.IsErrUserNotExist(err) {
+						c.Error(err, "get auto merger by ID")
+						return
+					}
+					autoMerger = db.NewGhostUser()
+				}
+				c.Data["AutoMerger"] = autoMerger
+			}
+
+			headCommitID, err := issue.PullRequest.HeadCommitID()
+			if err != nil {
+				c.Error(err, "get head commit ID")
+				return
+			}
+			status, err := db.GetCombinedCommitStatus(headCommitID, issue.PullRequest.BaseRepoID, issue.PullRequest.HeadRepoID)
+			if err != nil {
+				c.Error(err, "get combined commit status")
+				return
+			}
+			if len(status.Statuses) > 0 {
+				c.Data["HeadCommitStatus"] = status
+			}
 		}
 		if c.Written() {
 			return
@@ -630,7 +754,37 @@ func viewIssue(c *context.Context, isPullList bool) {
 			if !isAdded && !issue.IsPoster(comment.Poster.ID) {
 				participants = append(participants, comment.Poster)
 			}
+		} else if comment.Type == db.COMMENT_TYPE_REVIEW || comment.Type == db.COMMENT_TYPE_CODE {
+			comment.RenderedContent = string(markup.Markdown(comment.Content, c.Repo.RepoLink, c.Repo.Repository.ComposeMetas()))
+		}
+	}
+
+	if issue.IsPull {
+		reviews, err := db.GetLatestReviewsByIssueID(issue.ID)
+		if err != nil {
+			c.Error(err, "get latest reviews by issue ID")
+			return
+		}
+		c.Data["Reviews"] = reviews
+
+		requests, err := db.GetReviewRequestsByIssueID(issue.ID)
+		if err != nil {
+			c.Error(err, "get review requests by issue ID")
+			return
+		}
+		// Requests of users who have already reviewed are fulfilled.
+		reviewed := make(map[int64]bool, len(reviews))
+		for _, r := range reviews {
+			reviewed[r.ReviewerID] = true
+		}
+		pendingRequests := make([]*db.ReviewRequest, 0, len(requests))
+		for _, r := range requests {
+			if !reviewed[r.ReviewerID] {
+				pendingRequests = append(pendingRequests, r)
+			}
 		}
+		c.Data["ReviewRequests"] = pendingRequests
+		c.Data["CanReview"] = c.IsLogged && !issue.IsClosed && issue.CanComment(c.Repo.IsWriter()) == nil
 	}
 
 	if issue.IsPull && issue.PullRequest.HasMerged {
@@ -656,10 +810,52 @@ func viewIssue(c *context.Context, isPullList bool) {
 		})
 	}
 
+	if err = issue.LoadReactions(); err != nil {
+		c.Error(err, "load reactions")
+		return
+	}
+
+	c.Data["BlockedBy"], err = issue.BlockedBy(c.UserID())
+	if err != nil {
+		c.Error(err, "get blocking issues")
+		return
+	}
+	c.Data["Blocks"], err = issue.Blocks(c.UserID())
+	if err != nil {
+		c.Error(err, "get blocked issues")
+		return
+	}
+
+	if c.Repo.Repository.IsTimeTrackerEnabled() {
+		c.Data["IsTimeTrackerEnabled"] = true
+		c.Data["TotalTrackedTime"], err = issue.TotalTrackedTime()
+		if err != nil {
+			c.Error(err, "get total tracked time")
+			return
+		}
+		c.Data["TrackedTimeSummaries"], err = db.GetTrackedTimeSummaries(issue.ID)
+		if err != nil {
+			c.Error(err, "get tracked time summaries")
+			return
+		}
+
+		if c.IsLogged {
+			stopwatch, err := db.GetStopwatch(issue.ID, c.User.ID)
+			if err == nil {
+				c.Data["Stopwatch"] = stopwatch
+			} else if !db.IsErrStopwatchNotExist(err) {
+				c.Error(err, "get stopwatch")
+				return
+			}
+		}
+	}
+
 	c.Data["Participants"] = participants
 	c.Data["NumParticipants"] = len(participants)
 	c.Data["Issue"] = issue
 	c.Data["IsIssueOwner"] = c.Repo.IsWriter() || (c.IsLogged && issue.IsPoster(c.User.ID))
+	c.Data["CanComment"] = c.IsLogged && issue.CanComment(c.Repo.IsWriter()) == nil
+	c.Data["LockReasons"] = db.LockReasons
 	c.Data["SignInLink"] = conf.Server.Subpath + "/user/login?redirect_to=" + c.Data["Link"].(string)
 	c.Success(ISSUE_VIEW)
 }
@@ -807,16 +1003,24 @@ func UpdateIssueAssignee(c *context.Context) {
 		return
 	}
 
-	assigneeID := c.QueryInt64("id")
-	if issue.AssigneeID == assigneeID {
-		c.JSONSuccess(map[string]interface{}{
-			"ok": true,
-		})
-		return
-	}
-
-	if err := issue.ChangeAssignee(c.User, assigneeID); err != nil {
-		c.Error(err, "change assignee")
+	switch c.Query("action") {
+	case "clear":
+		if err := issue.ChangeAssignees(c.User, nil); err != nil {
+			c.Error(err, "clear assignees")
+			return
+		}
+	case "attach":
+		if err := issue.AddAssignee(c.User, c.QueryInt64("id")); err != nil {
+			c.Error(err, "add assignee")
+			return
+		}
+	case "detach":
+		if err := issue.RemoveAssignee(c.User, c.QueryInt64("id")); err != nil {
+			c.Error(err, "remove assignee")
+			return
+		}
+	default:
+		c.Status(http.StatusBadRequest)
 		return
 	}
 
@@ -825,11 +1029,201 @@ func UpdateIssueAssignee(c *context.Context) {
 	})
 }
 
+// getDependencyIssue returns the issue referenced by "#index" in the current repository
+// or "owner/repo#index" in any repository that the user has read access to. It sets
+// a flash error message and returns nil if the issue cannot be found.
+func getDependencyIssue(c *context.Context, ref string) *db.Issue {
+	ref = strings.TrimSpace(ref)
+	if strings.HasPrefix(ref, "#") {
+		ref = c.Repo.Repository.FullName() + ref
+	}
+
+	issue, err := db.GetIssueByRef(ref)
+	if err != nil {
+		if errutil.IsNotFound(err) || errors.IsInvalidIssueReference(err) || errors.IsInvalidRepoReference(err) {
+			c.Flash.Error(c.Tr("repo.issues.dependency.issue_not_exist", ref))
+		} else {
+			c.Error(err, "get issue by reference")
+		}
+		return nil
+	} else if !issue.Repo.HasAccess(c.User.ID) {
+		c.Flash.Error(c.Tr("repo.issues.dependency.issue_not_exist", ref))
+		return nil
+	}
+	return issue
+}
+
+func AddIssueDependency(c *context.Context) {
+	issue := getActionIssue(c)
+	if c.Written() {
+		return
+	}
+	redirectTo := c.Repo.MakeURL(fmt.Sprintf("issues/%d", issue.Index))
+
+	other := getDependencyIssue(c, c.Query("issue"))
+	if c.Written() {
+		return
+	} else if other == nil {
+		c.RawRedirect(redirectTo)
+		return
+	}
+
+	// The type is from the perspective of the current issue, marking it as blocking
+	// another issue changes that issue, which requires write access to its repository.
+	blocked, blocker := issue, other
+	if c.Query("type") == "blocks" {
+		if !db.Perms.Authorize(c.User.ID, other.Repo, db.AccessModeWrite) {
+			c.Flash.Error(c.Tr("repo.issues.dependency.no_permission", other.Repo.FullName()))
+			c.RawRedirect(redirectTo)
+			return
+		}
+		blocked, blocker = other, issue
+	}
+
+	if err := blocked.AddDependency(blocker); err != nil {
+		switch {
+		case db.IsErrDependencyExists(err):
+			c.Flash.Error(c.Tr("repo.issues.dependency.exists"))
+		case db.IsErrCircularDependency(err):
+			c.Flash.Error(c.Tr("repo.issues.dependency.circular"))
+		default:
+			c.Error(err, "add dependency")
+			return
+		}
+	}
+
+	c.RawRedirect(redirectTo)
+}
+
+func RemoveIssueDependency(c *context.Context) {
+	issue := getActionIssue(c)
+	if c.Written() {
+		return
+	}
+
+	// The blocked issue is the one whose dependency is removed.
+	blocked, blockerID := issue, c.QueryInt64("id")
+	if c.Query("type") == "blocks" {
+		other, err := db.GetIssueByID(blockerID)
+		if err != nil {
+			c.NotFoundOrError(err, "get issue by ID")
+			return
+		} else if !db.Perms.Authorize(c.User.ID, other.Repo, db.AccessModeWrite) {
+			c.Status(http.StatusForbidden)
+			return
+		}
+		blocked, blockerID = other, issue.ID
+	}
+
+	if err := blocked.RemoveDependency(blockerID); err != nil {
+		c.Error(err, "remove dependency")
+		return
+	}
+
+	c.RawRedirect(c.Repo.MakeURL(fmt.Sprintf("issues/%d", issue.Index)))
+}
+
+func LockIssue(c *context.Context) {
+	issue := getActionIssue(c)
+	if c.Written() {
+		return
+	}
+
+	var err error
+	if c.Query("action") == "unlock" {
+		err = issue.Unlock(c.User)
+	} else {
+		err = issue.Lock(c.User, c.Query("reason"))
+	}
+	if err != nil {
+		if !db.IsErrInvalidLockReason(err) {
+			c.Error(err, "change lock")
+			return
+		}
+		c.Flash.Error(c.Tr("repo.issues.lock.invalid_reason"))
+	}
+
+	c.RawRedirect(c.Repo.MakeURL(fmt.Sprintf("issues/%d", issue.Index)))
+}
+
+func TransferIssue(c *context.Context) {
+	issue := getActionIssue(c)
+	if c.Written() {
+		return
+	}
+
+	redirectTo := c.Repo.MakeURL(fmt.Sprintf("issues/%d", issue.Index))
+	name := strings.TrimSpace(c.Query("repo"))
+	target, err := db.GetRepositoryByName(c.Repo.Owner.ID, name)
+	if err != nil {
+		if db.IsErrRepoNotExist(err) {
+			c.Flash.Error(c.Tr("repo.issues.transfer.repo_not_exist", name))
+			c.RawRedirect(redirectTo)
+		} else {
+			c.Error(err, "get repository by name")
+		}
+		return
+	} else if !db.Perms.Authorize(c.User.ID, target, db.AccessModeWrite) {
+		c.Flash.Error(c.Tr("repo.issues.transfer.no_permission", target.Name))
+		c.RawRedirect(redirectTo)
+		return
+	}
+
+	if err = issue.TransferTo(c.User, target); err != nil {
+		if db.IsErrIssueTransferNotAllowed(err) {
+			c.Flash.Error(c.Tr("repo.issues.transfer.not_allowed"))
+			c.RawRedirect(redirectTo)
+		} else {
+			c.Error(err, "transfer issue")
+		}
+		return
+	}
+
+	log.Trace("Issue transferred [%d]: %s -> %s", issue.ID, c.Repo.Repository.FullName(), target.FullName())
+	c.RawRedirect(issue.HTMLURL())
+}
+
+// PinIssue pins, unpins or moves the pinned issue according to the "action", then
+// redirects to "redirect_to" if given or the issue.
+func PinIssue(c *context.Context) {
+	issue := getActionIssue(c)
+	if c.Written() {
+		return
+	}
+
+	var err error
+	switch c.Query("action") {
+	case "unpin":
+		err = issue.Unpin()
+	case "move":
+		err = issue.MovePin(c.QueryInt("position"))
+	default:
+		err = issue.Pin()
+	}
+	if err != nil {
+		if !db.IsErrPinnedIssuesLimitReached(err) {
+			c.Error(err, "change pin")
+			return
+		}
+		c.Flash.Error(c.Tr("repo.issues.pin.limit_reached", conf.Repository.MaxPinnedIssues))
+	}
+
+	redirectTo := c.Query("redirect_to")
+	if redirectTo == "" || !tool.IsSameSiteURLPath(redirectTo) {
+		redirectTo = c.Repo.MakeURL(fmt.Sprintf("issues/%d", issue.Index))
+	}
+	c.RawRedirect(redirectTo)
+}
+
 func NewComment(c *context.Context, f form.CreateComment) {
 	issue := getActionIssue(c)
 	if c.Written() {
 		return
 	}
+	if issue.CanComment(c.Repo.IsWriter()) != nil {
+		c.PlainText(http.StatusForbidden, c.Tr("repo.issues.lock.comment_forbidden"))
+		return
+	}
 
 	var attachments []string
 	if conf.Attachment.Enabled {
@@ -878,7 +1272,11 @@ func NewComment(c *context.Context, f form.CreateComment) {
 				c.Flash.Info(c.Tr("repo.pulls.open_unmerged_pull_exists", pr.Index))
 			} else {
 				if err = issue.ChangeStatus(c.User, c.Repo.Repository, f.Status == "close"); err != nil {
-					log.Error("ChangeStatus: %v", err)
+					if db.IsErrIssueHasOpenBlockers(err) {
+						c.Flash.Error(c.Tr("repo.issues.dependency.close_blocked"))
+					} else {
+						log.Error("ChangeStatus: %v", err)
+					}
 				} else {
 					log.Trace("Issue [%d] status changed to closed: %v", issue.ID, issue.IsClosed)
 				}
@@ -1081,6 +1479,9 @@ func Milestones(c *context.Context) {
 	}
 	c.Data["Page"] = paginater.New(total, conf.UI.IssuePagingNum, page, 5)
 
+	isTimeTrackerEnabled := c.Repo.Repository.IsTimeTrackerEnabled()
+	c.Data["IsTimeTrackerEnabled"] = isTimeTrackerEnabled
+
 	miles, err := db.GetMilestones(c.Repo.Repository.ID, page, isShowClosed)
 	if err != nil {
 		c.Error(err, "get milestones")
@@ -1092,6 +1493,9 @@ func Milestones(c *context.Context) {
 		if m.NumOpenIssues+m.NumClosedIssues > 0 {
 			m.Completeness = m.NumClosedIssues * 100 / (m.NumOpenIssues + m.NumClosedIssues)
 		}
+		if isTimeTrackerEnabled {
+			m.TotalTrackedTime = m.CountTrackedTime()
+		}
 		m.RenderedContent = string(markup.Markdown(m.Content, c.Repo.RepoLink, c.Repo.Repository.ComposeMetas()))
 	}
 	c.Data["Milestones"] = miles
@@ -0,0 +1,54 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	api "github.com/gogs/go-gogs-client"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/db"
+	"gogs.io/gogs/internal/tool"
+)
+
+// BulkEditIssues applies changes in the form to all issues or pull requests that are
+// selected in the list.
+func BulkEditIssues(c *context.Context) {
+	redirectTo := c.Query("redirect_to")
+	if redirectTo == "" || !tool.IsSameSiteURLPath(redirectTo) {
+		redirectTo = c.Repo.MakeURL("issues")
+	}
+
+	issueIDs := tool.StringsToInt64s(c.QueryStrings("issue_ids"))
+	if len(issueIDs) == 0 {
+		c.Flash.Error(c.Tr("repo.issues.bulk.no_selection"))
+		c.RawRedirect(redirectTo)
+		return
+	}
+
+	issues, err := db.BulkEditIssues(c.User, c.Repo.Repository, issueIDs, db.BulkEditIssuesOptions{
+		MilestoneID:    c.QueryInt64("milestone"),
+		AddLabelIDs:    tool.StringsToInt64s(c.QueryStrings("add_labels")),
+		RemoveLabelIDs: tool.StringsToInt64s(c.QueryStrings("remove_labels")),
+		AssigneeID:     c.QueryInt64("assignee"),
+		State:          api.StateType(c.Query("state")),
+		Comment:        c.Query("content"),
+	})
+	if err != nil {
+		switch {
+		case db.IsErrIssueHasOpenBlockers(err):
+			c.Flash.Error(c.Tr("repo.issues.dependency.close_blocked"))
+		case db.IsErrMilestoneNotExist(err), db.IsErrUserNotExist(err):
+			c.Flash.Error(c.Tr("repo.issues.bulk.invalid"))
+		default:
+			c.Error(err, "bulk edit issues")
+			return
+		}
+		c.RawRedirect(redirectTo)
+		return
+	}
+
+	c.Flash.Success(c.Tr("repo.issues.bulk.success", len(issues)))
+	c.RawRedirect(redirectTo)
+}
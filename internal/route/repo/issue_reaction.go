@@ -0,0 +1,64 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/http"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/db"
+)
+
+// changeReaction gives or removes the reaction in the "content" form field of
+// current user, the action is either "react" or "unreact".
+func changeReaction(c *context.Context, issueID, commentID int64) {
+	content := c.Query("content")
+
+	var err error
+	switch c.Params(":action") {
+	case "react":
+		_, err = db.CreateReaction(c.User, issueID, commentID, content)
+	case "unreact":
+		err = db.DeleteReaction(c.User, issueID, commentID, content)
+	default:
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		if db.IsErrInvalidReaction(err) {
+			c.Status(http.StatusBadRequest)
+		} else {
+			c.Error(err, "change reaction")
+		}
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func ChangeIssueReaction(c *context.Context) {
+	issue := getActionIssue(c)
+	if c.Written() {
+		return
+	}
+
+	changeReaction(c, issue.ID, 0)
+}
+
+func ChangeCommentReaction(c *context.Context) {
+	comment, err := db.GetCommentByID(c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get comment by ID")
+		return
+	}
+
+	if comment.Issue.RepoID != c.Repo.Repository.ID ||
+		(!c.Repo.HasAccess() && comment.Issue.IsPull) {
+		c.NotFound()
+		return
+	}
+
+	changeReaction(c, comment.IssueID, comment.ID)
+}
@@ -0,0 +1,240 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	log "unknwon.dev/clog/v2"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/db"
+)
+
+const (
+	ISSUE_TEMPLATE_DIR         = ".gogs/ISSUE_TEMPLATE"
+	PULL_REQUEST_TEMPLATE_FILE = ".gogs/PULL_REQUEST_TEMPLATE.md"
+)
+
+// IssueTemplate represents a template of issue or pull request description,
+// with optional metadata defined in the YAML front matter.
+type IssueTemplate struct {
+	// The path of the template file in the repository.
+	FileName string
+	Name     string
+	About    string
+	// The prefix of titles of issues created with the template.
+	Title    string
+	Labels   []string
+	Assignee string
+	Content  string
+}
+
+// parseFrontMatterList parses a list value in flow style ("[a, b]") or
+// comma-separated style ("a, b").
+func parseFrontMatterList(value string) []string {
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	list := make([]string, 0, 3)
+	for _, v := range strings.Split(value, ",") {
+		v = unquoteFrontMatterValue(v)
+		if v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func unquoteFrontMatterValue(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 &&
+		(value[0] == '"' && value[len(value)-1] == '"' || value[0] == '\'' && value[len(value)-1] == '\'') {
+		value = value[1 : len(value)-1]
+	}
+	return value
+}
+
+// parseIssueTemplate parses content of the template file with given name. The YAML
+// front matter is optional and only supports top-level keys with scalar or list values.
+func parseIssueTemplate(fileName, content string) *IssueTemplate {
+	tmpl := &IssueTemplate{
+		FileName: fileName,
+		Name:     strings.TrimSuffix(path.Base(fileName), path.Ext(fileName)),
+		Content:  content,
+	}
+
+	content = strings.Replace(content, "\r\n", "\n", -1)
+	if !strings.HasPrefix(content, "---\n") {
+		return tmpl
+	}
+	end := strings.Index(content[4:], "\n---")
+	if end == -1 {
+		return tmpl
+	}
+	frontMatter := content[4 : 4+end]
+	tmpl.Content = strings.TrimLeft(strings.TrimPrefix(content[4+end+4:], "\n"), "\n")
+
+	var key string
+	for _, line := range strings.Split(frontMatter, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		// Item of a block style list for the previous key.
+		if strings.HasPrefix(trimmed, "- ") {
+			value := unquoteFrontMatterValue(trimmed[2:])
+			switch key {
+			case "labels":
+				tmpl.Labels = append(tmpl.Labels, value)
+			case "assignees":
+				if tmpl.Assignee == "" {
+					tmpl.Assignee = value
+				}
+			}
+			continue
+		}
+
+		i := strings.Index(trimmed, ":")
+		if i == -1 {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(trimmed[:i]))
+		value := strings.TrimSpace(trimmed[i+1:])
+		switch key {
+		case "name":
+			tmpl.Name = unquoteFrontMatterValue(value)
+		case "about":
+			tmpl.About = unquoteFrontMatterValue(value)
+		case "title":
+			tmpl.Title = unquoteFrontMatterValue(value)
+		case "labels":
+			tmpl.Labels = append(tmpl.Labels, parseFrontMatterList(value)...)
+		case "assignee", "assignees":
+			// Issues only have a single assignee, the first one takes effect.
+			if assignees := parseFrontMatterList(value); len(assignees) > 0 && tmpl.Assignee == "" {
+				tmpl.Assignee = assignees[0]
+			}
+		}
+	}
+	return tmpl
+}
+
+// getIssueTemplates returns templates in ISSUE_TEMPLATE_DIR of the default branch,
+// ordered by file name.
+func getIssueTemplates(c *context.Context) []*IssueTemplate {
+	if c.Repo.Commit == nil {
+		var err error
+		c.Repo.Commit, err = c.Repo.GitRepo.BranchCommit(c.Repo.Repository.DefaultBranch)
+		if err != nil {
+			return nil
+		}
+	}
+
+	tree, err := c.Repo.Commit.Subtree(ISSUE_TEMPLATE_DIR)
+	if err != nil {
+		return nil
+	}
+	entries, err := tree.Entries()
+	if err != nil {
+		log.Error("Failed to list issue templates [repo_id: %d]: %v", c.Repo.Repository.ID, err)
+		return nil
+	}
+
+	templates := make([]*IssueTemplate, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsTree() || strings.ToLower(path.Ext(entry.Name())) != ".md" {
+			continue
+		}
+
+		p, err := entry.Blob().Bytes()
+		if err != nil {
+			log.Error("Failed to read issue template %q [repo_id: %d]: %v", entry.Name(), c.Repo.Repository.ID, err)
+			continue
+		}
+		templates = append(templates, parseIssueTemplate(path.Join(ISSUE_TEMPLATE_DIR, entry.Name()), string(p)))
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].FileName < templates[j].FileName
+	})
+	return templates
+}
+
+// getIssueTemplate returns the template with given file name in ISSUE_TEMPLATE_DIR
+// or the pull request template. It returns nil if the template does not exist.
+func getIssueTemplate(c *context.Context, fileName string) *IssueTemplate {
+	if path.Dir(fileName) != ISSUE_TEMPLATE_DIR && fileName != PULL_REQUEST_TEMPLATE_FILE {
+		return nil
+	}
+
+	content, found := getFileContentFromDefaultBranch(c, fileName)
+	if !found {
+		return nil
+	}
+	return parseIssueTemplate(fileName, content)
+}
+
+// setIssueTemplate renders the new issue or pull request form with given template.
+func setIssueTemplate(c *context.Context, ctxDataKey string, tmpl *IssueTemplate) {
+	c.Data[ctxDataKey] = tmpl.Content
+	c.Data["template"] = tmpl.FileName
+	if tmpl.Title != "" {
+		title, _ := c.Data["title"].(string)
+		if !strings.HasPrefix(title, tmpl.Title) {
+			c.Data["title"] = tmpl.Title + title
+		}
+	}
+}
+
+// templateAssigneeID returns the ID of the assignee defined by the template,
+// it returns 0 if the user does not exist or cannot be assigned.
+func templateAssigneeID(repo *db.Repository, tmpl *IssueTemplate) int64 {
+	if tmpl.Assignee == "" {
+		return 0
+	}
+
+	u, err := db.GetUserByName(strings.TrimPrefix(tmpl.Assignee, "@"))
+	if err != nil {
+		if !db.IsErrUserNotExist(err) {
+			log.Error("Failed to get template assignee %q: %v", tmpl.Assignee, err)
+		}
+		return 0
+	}
+
+	if _, err = repo.GetAssigneeByID(u.ID); err != nil {
+		return 0
+	}
+	return u.ID
+}
+
+// applyTemplateLabels adds labels defined by the template to the newly created
+// issue, labels that do not exist in the repository are ignored.
+func applyTemplateLabels(c *context.Context, issueID int64, tmpl *IssueTemplate) {
+	labels := make([]*db.Label, 0, len(tmpl.Labels))
+	for _, name := range tmpl.Labels {
+		label, err := db.GetLabelOfRepoByName(c.Repo.Repository.ID, name)
+		if err != nil {
+			if !db.IsErrLabelNotExist(err) {
+				log.Error("Failed to get template label %q [repo_id: %d]: %v", name, c.Repo.Repository.ID, err)
+			}
+			continue
+		}
+		labels = append(labels, label)
+	}
+	if len(labels) == 0 {
+		return
+	}
+
+	// Reload the issue with all attributes for sending webhooks.
+	issue, err := db.GetIssueByID(issueID)
+	if err != nil {
+		log.Error("Failed to get issue by ID [%d]: %v", issueID, err)
+		return
+	}
+	if err = issue.AddLabels(c.User, labels); err != nil {
+		log.Error("Failed to add template labels [issue_id: %d]: %v", issue.ID, err)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseIssueTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		content  string
+		want     *IssueTemplate
+	}{
+		{
+			name:     "no front matter",
+			fileName: ".gogs/ISSUE_TEMPLATE/bug.md",
+			content:  "## Steps to reproduce\n",
+			want: &IssueTemplate{
+				FileName: ".gogs/ISSUE_TEMPLATE/bug.md",
+				Name:     "bug",
+				Content:  "## Steps to reproduce\n",
+			},
+		},
+		{
+			name:     "flow style",
+			fileName: ".gogs/ISSUE_TEMPLATE/bug.md",
+			content: `---
+name: Bug report
+about: "Something doesn't work"
+title: '[Bug] '
+labels: [bug, "needs triage"]
+assignee: alice
+---
+
+## Steps to reproduce
+`,
+			want: &IssueTemplate{
+				FileName: ".gogs/ISSUE_TEMPLATE/bug.md",
+				Name:     "Bug report",
+				About:    "Something doesn't work",
+				Title:    "[Bug] ",
+				Labels:   []string{"bug", "needs triage"},
+				Assignee: "alice",
+				Content:  "## Steps to reproduce\n",
+			},
+		},
+		{
+			name:     "block style",
+			fileName: ".gogs/PULL_REQUEST_TEMPLATE.md",
+			content:  "---\r\nlabels:\r\n  - review\r\n  - 'kind/feature'\r\nassignees:\r\n  - bob\r\n  - carol\r\n---\r\nDescription\r\n",
+			want: &IssueTemplate{
+				FileName: ".gogs/PULL_REQUEST_TEMPLATE.md",
+				Name:     "PULL_REQUEST_TEMPLATE",
+				Labels:   []string{"review", "kind/feature"},
+				Assignee: "bob",
+				Content:  "Description\n",
+			},
+		},
+		{
+			name:     "unclosed front matter",
+			fileName: ".gogs/ISSUE_TEMPLATE/feature.md",
+			content:  "---\nname: Feature\n",
+			want: &IssueTemplate{
+				FileName: ".gogs/ISSUE_TEMPLATE/feature.md",
+				Name:     "feature",
+				Content:  "---\nname: Feature\n",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, parseIssueTemplate(test.fileName, test.content))
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/db"
+	"gogs.io/gogs/internal/form"
+	"gogs.io/gogs/internal/tool"
+)
+
+// getTimeTrackerIssue returns the issue of current request if time tracking is
+// enabled for the repository.
+func getTimeTrackerIssue(c *context.Context) *db.Issue {
+	if !c.Repo.Repository.IsTimeTrackerEnabled() {
+		c.NotFound()
+		return nil
+	}
+	return getActionIssue(c)
+}
+
+func ToggleIssueStopwatch(c *context.Context) {
+	issue := getTimeTrackerIssue(c)
+	if c.Written() {
+		return
+	}
+
+	_, err := db.GetStopwatch(issue.ID, c.User.ID)
+	if err == nil {
+		t, err := issue.StopStopwatch(c.User)
+		if err != nil {
+			c.Error(err, "stop stopwatch")
+			return
+		}
+		c.Flash.Success(c.Tr("repo.issues.tracker.time_added", tool.FormatDuration(t.Seconds)))
+	} else if db.IsErrStopwatchNotExist(err) {
+		if err = issue.StartStopwatch(c.User); err != nil {
+			c.Error(err, "start stopwatch")
+			return
+		}
+	} else {
+		c.Error(err, "get stopwatch")
+		return
+	}
+
+	c.RawRedirect(c.Repo.MakeURL(fmt.Sprintf("issues/%d", issue.Index)))
+}
+
+func CancelIssueStopwatch(c *context.Context) {
+	issue := getTimeTrackerIssue(c)
+	if c.Written() {
+		return
+	}
+
+	if err := issue.CancelStopwatch(c.User); err != nil {
+		c.Error(err, "cancel stopwatch")
+		return
+	}
+
+	c.RawRedirect(c.Repo.MakeURL(fmt.Sprintf("issues/%d", issue.Index)))
+}
+
+func AddIssueTrackedTime(c *context.Context, f form.AddTrackedTime) {
+	issue := getTimeTrackerIssue(c)
+	if c.Written() {
+		return
+	}
+	redirectTo := c.Repo.MakeURL(fmt.Sprintf("issues/%d", issue.Index))
+
+	seconds := f.Hours*tool.Hour + f.Minutes*tool.Minute
+	if c.HasError() || f.Hours < 0 || f.Minutes < 0 || seconds <= 0 {
+		c.Flash.Error(c.Tr("repo.issues.tracker.invalid_time"))
+		c.RawRedirect(redirectTo)
+		return
+	}
+
+	if _, err := db.AddTrackedTime(issue, c.User, seconds); err != nil {
+		c.Error(err, "add tracked time")
+		return
+	}
+
+	c.Flash.Success(c.Tr("repo.issues.tracker.time_added", tool.FormatDuration(seconds)))
+	c.RawRedirect(redirectTo)
+}
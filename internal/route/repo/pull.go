@@ -5,6 +5,7 @@
 package repo
 
 import (
+	"fmt"
 	"net/http"
 	"path"
 	"strings"
@@ -19,6 +20,7 @@ import (
 	"gogs.io/gogs/internal/db"
 	"gogs.io/gogs/internal/form"
 	"gogs.io/gogs/internal/gitutil"
+	"gogs.io/gogs/internal/markup"
 )
 
 const (
@@ -243,6 +245,18 @@ func PrepareViewPullInfo(c *context.Context, issue *db.Issue) *gitutil.PullReque
 	return prMeta
 }
 
+// squashCommitDescription returns the default commit description for squash merge,
+// which lists summaries of all commits of the pull request in chronological order.
+func squashCommitDescription(commits []*git.Commit) string {
+	var buf strings.Builder
+	for i := len(commits) - 1; i >= 0; i-- {
+		buf.WriteString("* ")
+		buf.WriteString(commits[i].Summary())
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
 func ViewPullCommits(c *context.Context) {
 	c.Data["PageIsPullList"] = true
 	c.Data["PageIsPullCommits"] = true
@@ -293,6 +307,10 @@ func ViewPullCommits(c *context.Context) {
 
 	c.Data["Commits"] = db.ValidateCommitsWithEmails(commits)
 	c.Data["CommitsCount"] = len(commits)
+	setCommitStatuses(c, commits, pull.BaseRepoID, pull.HeadRepoID)
+	if c.Written() {
+		return
+	}
 
 	c.Success(PULL_COMMITS)
 }
@@ -375,6 +393,22 @@ func ViewPullFiles(c *context.Context) {
 		return
 	}
 
+	if !pull.HasMerged {
+		codeComments, err := db.GetCodeCommentsByIssueID(issue.ID, c.User)
+		if err != nil {
+			c.Error(err, "get code comments by issue ID")
+			return
+		}
+		for _, comments := range codeComments {
+			for _, comment := range comments {
+				comment.RenderedContent = string(markup.Markdown(comment.Content, c.Repo.RepoLink, c.Repo.Repository.ComposeMetas()))
+			}
+		}
+		c.Data["CodeComments"] = codeComments
+		c.Data["CanCodeComment"] = c.IsLogged && !issue.IsClosed
+		c.Data["CodeCommentCommitID"] = endCommitID
+	}
+
 	c.Data["IsSplitStyle"] = c.Query("style") == "split"
 	c.Data["IsImageFile"] = commit.IsImageFile
 	c.Data["IsImageFileByIndex"] = commit.IsImageFileByIndex
@@ -419,6 +453,19 @@ func MergePullRequest(c *context.Context) {
 	pr.Issue = issue
 	pr.Issue.Repo = c.Repo.Repository
 	if err = pr.Merge(c.User, c.Repo.GitRepo, db.MergeStyle(c.Query("merge_style")), c.Query("commit_description")); err != nil {
+		if db.IsErrMergeRequirementsNotMet(err) {
+			c.Flash.Error(c.Tr("repo.pulls.merge_requirements_not_met"))
+			c.Redirect(c.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+			return
+		} else if db.IsErrPullRequestNotFastForward(err) {
+			c.Flash.Error(c.Tr("repo.pulls.not_fast_forward"))
+			c.Redirect(c.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+			return
+		} else if db.IsErrPullRequestIsDraft(err) {
+			c.Flash.Error(c.Tr("repo.pulls.is_draft_desc"))
+			c.Redirect(c.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+			return
+		}
 		c.Error(err, "merge")
 		return
 	}
@@ -427,6 +474,193 @@ func MergePullRequest(c *context.Context) {
 	c.Redirect(c.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
 }
 
+func SetPullRequestDraft(c *context.Context) {
+	issue := checkPullInfo(c)
+	if c.Written() {
+		return
+	}
+	if issue.IsClosed || issue.PullRequest.HasMerged {
+		c.NotFound()
+		return
+	}
+	if !issue.IsPoster(c.User.ID) && !c.Repo.IsWriter() {
+		c.Status(http.StatusForbidden)
+		return
+	}
+
+	pr := issue.PullRequest
+	if err := pr.SetDraft(c.User, c.QueryBool("draft")); err != nil {
+		c.Error(err, "set draft")
+		return
+	}
+
+	log.Trace("Pull request draft state changed [%d]: %v", pr.ID, pr.IsDraft)
+	c.Redirect(c.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+}
+
+func ScheduleAutoMerge(c *context.Context) {
+	issue := checkPullInfo(c)
+	if c.Written() {
+		return
+	}
+	if issue.IsClosed || issue.PullRequest.HasMerged {
+		c.NotFound()
+		return
+	}
+
+	pr := issue.PullRequest
+	if err := pr.ScheduleAutoMerge(c.User, db.MergeStyle(c.Query("merge_style")), c.Query("commit_description")); err != nil {
+		c.Error(err, "schedule auto-merge")
+		return
+	}
+
+	log.Trace("Pull request auto-merge scheduled: %d", pr.ID)
+	c.Flash.Success(c.Tr("repo.pulls.auto_merge_scheduled_success"))
+	c.Redirect(c.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+}
+
+func CancelAutoMerge(c *context.Context) {
+	issue := checkPullInfo(c)
+	if c.Written() {
+		return
+	}
+
+	pr := issue.PullRequest
+	if pr.IsAutoMergeScheduled() {
+		if err := pr.CancelAutoMerge(); err != nil {
+			c.Error(err, "cancel auto-merge")
+			return
+		}
+		log.Trace("Pull request auto-merge canceled: %d", pr.ID)
+	}
+
+	c.Redirect(c.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+}
+
+func CreateReview(c *context.Context, f form.CreateReview) {
+	issue := checkPullInfo(c)
+	if c.Written() {
+		return
+	}
+	if issue.IsClosed {
+		c.NotFound()
+		return
+	}
+	if issue.CanComment(c.Repo.IsWriter()) != nil {
+		c.PlainText(http.StatusForbidden, c.Tr("repo.issues.lock.comment_forbidden"))
+		return
+	}
+
+	redirectTo := c.Repo.MakeURL(fmt.Sprintf("pulls/%d", issue.Index))
+	if c.HasError() {
+		c.Flash.Error(c.Data["ErrorMsg"].(string))
+		c.RawRedirect(redirectTo)
+		return
+	}
+
+	commitSHA, err := issue.PullRequest.HeadCommitID()
+	if err != nil {
+		c.Error(err, "get head commit ID")
+		return
+	}
+
+	review, err := db.CreateReview(db.CreateReviewOptions{
+		Doer:      c.User,
+		Repo:      c.Repo.Repository,
+		Issue:     issue,
+		State:     db.ToReviewState(f.State),
+		Content:   f.Content,
+		CommitSHA: commitSHA,
+	})
+	if err != nil {
+		if db.IsErrReviewOwnPullRequest(err) {
+			c.Flash.Error(c.Tr("repo.pulls.review_own_pull_request"))
+			c.RawRedirect(redirectTo)
+			return
+		}
+		c.Error(err, "create review")
+		return
+	}
+
+	log.Trace("Review created [%d]: %s", review.ID, review.State.Name())
+	if review.IsPending() {
+		c.Flash.Info(c.Tr("repo.pulls.review_pending_saved"))
+		c.RawRedirect(redirectTo)
+		return
+	}
+	c.RawRedirect(redirectTo + "#" + review.HashTag())
+}
+
+func CreateCodeComment(c *context.Context, f form.CreateCodeComment) {
+	issue := checkPullInfo(c)
+	if c.Written() {
+		return
+	}
+	if issue.IsClosed || issue.PullRequest.HasMerged {
+		c.NotFound()
+		return
+	}
+	if issue.CanComment(c.Repo.IsWriter()) != nil {
+		c.PlainText(http.StatusForbidden, c.Tr("repo.issues.lock.comment_forbidden"))
+		return
+	}
+
+	redirectTo := c.Repo.MakeURL(fmt.Sprintf("pulls/%d/files", issue.Index))
+	if c.HasError() {
+		c.Flash.Error(c.Data["ErrorMsg"].(string))
+		c.RawRedirect(redirectTo)
+		return
+	}
+
+	comment, err := db.CreateCodeComment(db.CreateCodeCommentOptions{
+		Doer:      c.User,
+		Repo:      c.Repo.Repository,
+		Issue:     issue,
+		CommitSHA: f.CommitSHA,
+		TreePath:  f.TreePath,
+		Side:      db.DiffSide(f.Side),
+		Line:      f.Line,
+		Content:   f.Content,
+	})
+	if err != nil {
+		if db.IsErrCodeCommentLineNotExist(err) {
+			c.Flash.Error(c.Tr("repo.pulls.code_comment_line_not_exist"))
+			c.RawRedirect(redirectTo)
+			return
+		} else if db.IsErrCodeCommentCommitNotExist(err) {
+			c.Flash.Error(c.Tr("repo.pulls.code_comment_commit_not_exist"))
+			c.RawRedirect(redirectTo)
+			return
+		}
+		c.Error(err, "create code comment")
+		return
+	}
+
+	log.Trace("Code comment created: %d/%d/%d", c.Repo.Repository.ID, issue.ID, comment.ID)
+	c.RawRedirect(redirectTo + "#" + comment.HashTag())
+}
+
+func DismissReview(c *context.Context) {
+	issue := checkPullInfo(c)
+	if c.Written() {
+		return
+	}
+
+	review, err := db.GetReviewOfIssueByID(issue.ID, c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get review of issue by ID")
+		return
+	}
+
+	if err = review.Dismiss(c.User); err != nil {
+		c.Error(err, "dismiss review")
+		return
+	}
+
+	log.Trace("Review dismissed [%d]: %s", review.ID, c.User.Name)
+	c.RawRedirect(c.Repo.MakeURL(fmt.Sprintf("pulls/%d", issue.Index)) + "#" + review.HashTag())
+}
+
 func ParseCompareInfo(c *context.Context) (*db.User, *db.Repository, *git.Repository, *gitutil.PullRequestMeta, string, string) {
 	baseRepo := c.Repo.Repository
 
@@ -611,7 +845,6 @@ func CompareAndPullRequest(c *context.Context) {
 	c.Data["PageIsComparePull"] = true
 	c.Data["IsDiffCompare"] = true
 	c.Data["RequireHighlightJS"] = true
-	setTemplateIfExists(c, PULL_REQUEST_TEMPLATE_KEY, PullRequestTemplateCandidates)
 	renderAttachmentSettings(c)
 
 	headUser, headRepo, headGitRepo, prInfo, baseBranch, headBranch := ParseCompareInfo(c)
@@ -659,6 +892,12 @@ func CompareAndPullRequest(c *context.Context) {
 		c.Data["title"] = r.Replace(customTitle)
 	}
 
+	if tmpl := getIssueTemplate(c, PULL_REQUEST_TEMPLATE_FILE); tmpl != nil {
+		setIssueTemplate(c, PULL_REQUEST_TEMPLATE_KEY, tmpl)
+	} else {
+		setTemplateIfExists(c, PULL_REQUEST_TEMPLATE_KEY, PullRequestTemplateCandidates)
+	}
+
 	c.Success(COMPARE_PULL)
 }
 
@@ -690,6 +929,7 @@ func CompareAndPullRequestPost(c *context.Context, f form.NewIssue) {
 
 	if c.HasError() {
 		form.Assign(f, c.Data)
+		c.Data["template"] = f.Template
 
 		// This stage is already stop creating new pull request, so it does not matter if it has
 		// something to compare or not.
@@ -708,6 +948,14 @@ func CompareAndPullRequestPost(c *context.Context, f form.NewIssue) {
 		return
 	}
 
+	var tmpl *IssueTemplate
+	if f.Template != "" {
+		tmpl = getIssueTemplate(c, f.Template)
+	}
+	if tmpl != nil && assigneeID == 0 {
+		assigneeID = templateAssigneeID(repo, tmpl)
+	}
+
 	pullIssue := &db.Issue{
 		RepoID:      repo.ID,
 		Index:       repo.NextIssueIndex(),
@@ -729,6 +977,7 @@ func CompareAndPullRequestPost(c *context.Context, f form.NewIssue) {
 		BaseRepo:     repo,
 		MergeBase:    meta.MergeBase,
 		Type:         db.PULL_REQUEST_GOGS,
+		IsDraft:      f.IsDraft,
 	}
 	// FIXME: check error in the case two people send pull request at almost same time, give nice error prompt
 	// instead of 500.
@@ -739,6 +988,9 @@ func CompareAndPullRequestPost(c *context.Context, f form.NewIssue) {
 		c.Error(err, "push to base repository")
 		return
 	}
+	if tmpl != nil {
+		applyTemplateLabels(c, pullIssue.ID, tmpl)
+	}
 
 	log.Trace("Pull request created: %d/%d", repo.ID, pullIssue.ID)
 	c.Redirect(c.Repo.RepoLink + "/pulls/" + com.ToStr(pullIssue.Index))
@@ -5,6 +5,7 @@
 package repo
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"strings"
@@ -33,6 +34,7 @@ const (
 	SETTINGS_GITHOOKS         = "repo/settings/githooks"
 	SETTINGS_GITHOOK_EDIT     = "repo/settings/githook_edit"
 	SETTINGS_DEPLOY_KEYS      = "repo/settings/deploy_keys"
+	SETTINGS_ISSUES           = "repo/settings/issues"
 )
 
 func Settings(c *context.Context) {
@@ -152,9 +154,13 @@ func SettingsPost(c *context.Context, f form.RepoSetting) {
 		repo.ExternalTrackerURL = f.ExternalTrackerURL
 		repo.ExternalTrackerFormat = f.TrackerURLFormat
 		repo.ExternalTrackerStyle = f.TrackerIssueStyle
+		repo.IssuesBlockCloseByDependencies = f.IssuesBlockCloseByDependencies
+		repo.EnableTimeTracker = f.EnableTimeTracker
 		repo.EnablePulls = f.EnablePulls
 		repo.PullsIgnoreWhitespace = f.PullsIgnoreWhitespace
 		repo.PullsAllowRebase = f.PullsAllowRebase
+		repo.PullsAllowSquash = f.PullsAllowSquash
+		repo.PullsAllowFastForward = f.PullsAllowFastForward
 
 		if !repo.EnableWiki || repo.EnableExternalWiki {
 			repo.AllowPublicWiki = false
@@ -556,7 +562,14 @@ func SettingsProtectedBranchPost(c *context.Context, f form.ProtectBranch) {
 
 	protectBranch.Protected = f.Protected
 	protectBranch.RequirePullRequest = f.RequirePullRequest
+	protectBranch.RequiredApprovals = f.RequiredApprovals
+	if protectBranch.RequiredApprovals < 0 {
+		protectBranch.RequiredApprovals = 0
+	}
+	protectBranch.RequireCodeOwnerApproval = f.RequireCodeOwnerApproval
+	protectBranch.RequiredStatusChecks = f.RequiredStatusChecks
 	protectBranch.EnableWhitelist = f.EnableWhitelist
+	protectBranch.WhitelistCanOverride = f.WhitelistCanOverride
 	if c.Repo.Owner.IsOrganization() {
 		err = db.UpdateOrgProtectBranch(c.Repo.Repository, protectBranch, f.WhitelistUsers, f.WhitelistTeams)
 	} else {
@@ -689,3 +702,47 @@ func DeleteDeployKey(c *context.Context) {
 		"redirect": c.Repo.RepoLink + "/settings/keys",
 	})
 }
+
+func SettingsIssues(c *context.Context) {
+	c.Title("repo.settings.issues")
+	c.PageIs("SettingsIssues")
+	c.Success(SETTINGS_ISSUES)
+}
+
+// SettingsIssuesExport serves an archive of issues of the repository that can be
+// imported to another repository.
+func SettingsIssuesExport(c *context.Context) {
+	var buf bytes.Buffer
+	if err := db.ExportIssues(c.Repo.Repository, &buf); err != nil {
+		c.Error(err, "export issues")
+		return
+	}
+
+	name := fmt.Sprintf("%s-%s-issues-%s.zip", c.Repo.Owner.Name, c.Repo.Repository.Name, time.Now().Format("20060102"))
+	c.ServeContent(name, bytes.NewReader(buf.Bytes()))
+}
+
+func SettingsIssuesImportPost(c *context.Context) {
+	file, header, err := c.Req.FormFile("archive")
+	if err != nil {
+		c.Flash.Error(c.Tr("repo.settings.issues.import_no_file"))
+		c.Redirect(c.Repo.RepoLink + "/settings/issues")
+		return
+	}
+	defer file.Close()
+
+	n, err := db.ImportIssues(c.Repo.Repository, file, header.Size)
+	if err != nil {
+		if db.IsErrIssueArchiveInvalid(err) {
+			c.Flash.Error(c.Tr("repo.settings.issues.import_invalid", err.Error()))
+			c.Redirect(c.Repo.RepoLink + "/settings/issues")
+			return
+		}
+		c.Error(err, "import issues")
+		return
+	}
+
+	log.Trace("Issues imported [repo_id: %d]: %d", c.Repo.Repository.ID, n)
+	c.Flash.Success(c.Tr("repo.settings.issues.import_success", n))
+	c.Redirect(c.Repo.RepoLink + "/settings/issues")
+}
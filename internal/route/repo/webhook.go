@@ -27,6 +27,8 @@ const (
 	tmplRepoSettingsWebhookNew = "repo/settings/webhook/new"
 	tmplOrgSettingsWebhooks    = "org/settings/webhooks"
 	tmplOrgSettingsWebhookNew  = "org/settings/webhook_new"
+	tmplAdminHooks             = "admin/hook/list"
+	tmplAdminHookNew           = "admin/hook/new"
 )
 
 func InjectOrgRepoContext() macaron.Handler {
@@ -43,18 +45,20 @@ func InjectOrgRepoContext() macaron.Handler {
 type orgRepoContext struct {
 	OrgID    int64
 	RepoID   int64
-	Link     string
+	IsSystem bool
+	Link     string // Link of the webhook list page
 	TmplList string
 	TmplNew  string
 }
 
-// getOrgRepoContext determines whether this is a repo context or organization context.
+// getOrgRepoContext determines whether this is a repo context, organization context
+// or system context of the admin panel.
 func getOrgRepoContext(c *context.Context) (*orgRepoContext, error) {
 	if len(c.Repo.RepoLink) > 0 {
 		c.PageIs("RepositoryContext")
 		return &orgRepoContext{
 			RepoID:   c.Repo.Repository.ID,
-			Link:     c.Repo.RepoLink,
+			Link:     c.Repo.RepoLink + "/settings/hooks",
 			TmplList: tmplRepoSettingsWebhooks,
 			TmplNew:  tmplRepoSettingsWebhookNew,
 		}, nil
@@ -64,15 +68,37 @@ func getOrgRepoContext(c *context.Context) (*orgRepoContext, error) {
 		c.PageIs("OrganizationContext")
 		return &orgRepoContext{
 			OrgID:    c.Org.Organization.ID,
-			Link:     c.Org.OrgLink,
+			Link:     c.Org.OrgLink + "/settings/hooks",
 			TmplList: tmplOrgSettingsWebhooks,
 			TmplNew:  tmplOrgSettingsWebhookNew,
 		}, nil
 	}
 
+	if c.IsLogged && c.User.IsAdmin && c.Data["PageIsAdminHooks"] == true {
+		c.PageIs("SystemContext")
+		return &orgRepoContext{
+			IsSystem: true,
+			Link:     conf.Server.Subpath + "/admin/hooks",
+			TmplList: tmplAdminHooks,
+			TmplNew:  tmplAdminHookNew,
+		}, nil
+	}
+
 	return nil, errors.New("unable to determine context")
 }
 
+// getWebhook returns the webhook with given ID in the context.
+func getWebhook(orCtx *orgRepoContext, id int64) (*db.Webhook, error) {
+	switch {
+	case orCtx.RepoID > 0:
+		return db.GetWebhookOfRepoByID(orCtx.RepoID, id)
+	case orCtx.OrgID > 0:
+		return db.GetWebhookByOrgID(orCtx.OrgID, id)
+	default:
+		return db.GetSystemWebhookByID(id)
+	}
+}
+
 func Webhooks(c *context.Context, orCtx *orgRepoContext) {
 	c.Title("repo.settings.hooks")
 	c.PageIs("SettingsHooks")
@@ -80,12 +106,16 @@ func Webhooks(c *context.Context, orCtx *orgRepoContext) {
 
 	var err error
 	var ws []*db.Webhook
-	if orCtx.RepoID > 0 {
+	switch {
+	case orCtx.RepoID > 0:
 		c.Data["Description"] = c.Tr("repo.settings.hooks_desc")
 		ws, err = db.GetWebhooksByRepoID(orCtx.RepoID)
-	} else {
+	case orCtx.OrgID > 0:
 		c.Data["Description"] = c.Tr("org.settings.hooks_desc")
 		ws, err = db.GetWebhooksByOrgID(orCtx.OrgID)
+	default:
+		c.Data["Description"] = c.Tr("admin.hooks.desc")
+		ws, err = db.GetSystemWebhooks()
 	}
 	if err != nil {
 		c.Error(err, "get webhooks")
@@ -176,7 +206,7 @@ func validateAndCreateWebhook(c *context.Context, orCtx *orgRepoContext, w *db.W
 	}
 
 	c.Flash.Success(c.Tr("repo.settings.add_hook_success"))
-	c.Redirect(orCtx.Link + "/settings/hooks")
+	c.Redirect(orCtx.Link)
 }
 
 func toHookEvent(f form.Webhook) *db.HookEvent {
@@ -185,14 +215,18 @@ func toHookEvent(f form.Webhook) *db.HookEvent {
 		SendEverything: f.SendEverything(),
 		ChooseEvents:   f.ChooseEvents(),
 		HookEvents: db.HookEvents{
-			Create:       f.Create,
-			Delete:       f.Delete,
-			Fork:         f.Fork,
-			Push:         f.Push,
-			Issues:       f.Issues,
-			IssueComment: f.IssueComment,
-			PullRequest:  f.PullRequest,
-			Release:      f.Release,
+			Create:            f.Create,
+			Delete:            f.Delete,
+			Fork:              f.Fork,
+			Push:              f.Push,
+			Issues:            f.Issues,
+			IssueComment:      f.IssueComment,
+			PullRequest:       f.PullRequest,
+			PullRequestReview: f.PullRequestReview,
+			Release:           f.Release,
+			Repository:        f.Repository,
+			User:              f.User,
+			Organization:      f.Organization,
 		},
 	}
 }
@@ -209,14 +243,15 @@ func WebhooksNewPost(c *context.Context, orCtx *orgRepoContext, f form.NewWebhoo
 	}
 
 	w := &db.Webhook{
-		RepoID:       orCtx.RepoID,
-		OrgID:        orCtx.OrgID,
-		URL:          f.PayloadURL,
-		ContentType:  contentType,
-		Secret:       f.Secret,
-		HookEvent:    toHookEvent(f.Webhook),
-		IsActive:     f.Active,
-		HookTaskType: db.GOGS,
+		RepoID:          orCtx.RepoID,
+		OrgID:           orCtx.OrgID,
+		IsSystemWebhook: orCtx.IsSystem,
+		URL:             f.PayloadURL,
+		ContentType:     contentType,
+		Secret:          f.Secret,
+		HookEvent:       toHookEvent(f.Webhook),
+		IsActive:        f.Active,
+		HookTaskType:    db.GOGS,
 	}
 	validateAndCreateWebhook(c, orCtx, w)
 }
@@ -242,14 +277,15 @@ func WebhooksSlackNewPost(c *context.Context, orCtx *orgRepoContext, f form.NewS
 	}
 
 	w := &db.Webhook{
-		RepoID:       orCtx.RepoID,
-		URL:          f.PayloadURL,
-		ContentType:  db.JSON,
-		HookEvent:    toHookEvent(f.Webhook),
-		IsActive:     f.Active,
-		HookTaskType: db.SLACK,
-		Meta:         string(p),
-		OrgID:        orCtx.OrgID,
+		RepoID:          orCtx.RepoID,
+		URL:             f.PayloadURL,
+		ContentType:     db.JSON,
+		HookEvent:       toHookEvent(f.Webhook),
+		IsActive:        f.Active,
+		HookTaskType:    db.SLACK,
+		Meta:            string(p),
+		OrgID:           orCtx.OrgID,
+		IsSystemWebhook: orCtx.IsSystem,
 	}
 	validateAndCreateWebhook(c, orCtx, w)
 }
@@ -274,14 +310,15 @@ func WebhooksDiscordNewPost(c *context.Context, orCtx *orgRepoContext, f form.Ne
 	}
 
 	w := &db.Webhook{
-		RepoID:       orCtx.RepoID,
-		URL:          f.PayloadURL,
-		ContentType:  db.JSON,
-		HookEvent:    toHookEvent(f.Webhook),
-		IsActive:     f.Active,
-		HookTaskType: db.DISCORD,
-		Meta:         string(p),
-		OrgID:        orCtx.OrgID,
+		RepoID:          orCtx.RepoID,
+		URL:             f.PayloadURL,
+		ContentType:     db.JSON,
+		HookEvent:       toHookEvent(f.Webhook),
+		IsActive:        f.Active,
+		HookTaskType:    db.DISCORD,
+		Meta:            string(p),
+		OrgID:           orCtx.OrgID,
+		IsSystemWebhook: orCtx.IsSystem,
 	}
 	validateAndCreateWebhook(c, orCtx, w)
 }
@@ -293,13 +330,14 @@ func WebhooksDingtalkNewPost(c *context.Context, orCtx *orgRepoContext, f form.N
 	c.Data["HookType"] = "dingtalk"
 
 	w := &db.Webhook{
-		RepoID:       orCtx.RepoID,
-		URL:          f.PayloadURL,
-		ContentType:  db.JSON,
-		HookEvent:    toHookEvent(f.Webhook),
-		IsActive:     f.Active,
-		HookTaskType: db.DINGTALK,
-		OrgID:        orCtx.OrgID,
+		RepoID:          orCtx.RepoID,
+		URL:             f.PayloadURL,
+		ContentType:     db.JSON,
+		HookEvent:       toHookEvent(f.Webhook),
+		IsActive:        f.Active,
+		HookTaskType:    db.DINGTALK,
+		OrgID:           orCtx.OrgID,
+		IsSystemWebhook: orCtx.IsSystem,
 	}
 	validateAndCreateWebhook(c, orCtx, w)
 }
@@ -307,13 +345,7 @@ func WebhooksDingtalkNewPost(c *context.Context, orCtx *orgRepoContext, f form.N
 func loadWebhook(c *context.Context, orCtx *orgRepoContext) *db.Webhook {
 	c.RequireHighlightJS()
 
-	var err error
-	var w *db.Webhook
-	if orCtx.RepoID > 0 {
-		w, err = db.GetWebhookOfRepoByID(c.Repo.Repository.ID, c.ParamsInt64(":id"))
-	} else {
-		w, err = db.GetWebhookByOrgID(c.Org.Organization.ID, c.ParamsInt64(":id"))
-	}
+	w, err := getWebhook(orCtx, c.ParamsInt64(":id"))
 	if err != nil {
 		c.NotFoundOrError(err, "get webhook")
 		return nil
@@ -332,8 +364,8 @@ func loadWebhook(c *context.Context, orCtx *orgRepoContext) *db.Webhook {
 	default:
 		c.Data["HookType"] = "gogs"
 	}
-	c.Data["FormURL"] = fmt.Sprintf("%s/settings/hooks/%s/%d", orCtx.Link, c.Data["HookType"], w.ID)
-	c.Data["DeleteURL"] = fmt.Sprintf("%s/settings/hooks/delete", orCtx.Link)
+	c.Data["FormURL"] = fmt.Sprintf("%s/%s/%d", orCtx.Link, c.Data["HookType"], w.ID)
+	c.Data["DeleteURL"] = fmt.Sprintf("%s/delete", orCtx.Link)
 
 	c.Data["History"], err = w.History(1)
 	if err != nil {
@@ -380,7 +412,7 @@ func validateAndUpdateWebhook(c *context.Context, orCtx *orgRepoContext, w *db.W
 	}
 
 	c.Flash.Success(c.Tr("repo.settings.update_hook_success"))
-	c.Redirect(fmt.Sprintf("%s/settings/hooks/%d", orCtx.Link, w.ID))
+	c.Redirect(fmt.Sprintf("%s/%d", orCtx.Link, w.ID))
 }
 
 func WebhooksEditPost(c *context.Context, orCtx *orgRepoContext, f form.NewWebhook) {
@@ -568,8 +600,8 @@ func TestWebhook(c *context.Context) {
 	c.Status(http.StatusOK)
 }
 
-func RedeliveryWebhook(c *context.Context) {
-	webhook, err := db.GetWebhookOfRepoByID(c.Repo.Repository.ID, c.ParamsInt64(":id"))
+func RedeliveryWebhook(c *context.Context, orCtx *orgRepoContext) {
+	webhook, err := getWebhook(orCtx, c.ParamsInt64(":id"))
 	if err != nil {
 		c.NotFoundOrError(err, "get webhook")
 		return
@@ -581,23 +613,24 @@ func RedeliveryWebhook(c *context.Context) {
 		return
 	}
 
-	hookTask.IsDelivered = false
-	if err = db.UpdateHookTask(hookTask); err != nil {
-		c.Error(err, "update hook task")
+	if _, err = webhook.Redeliver(hookTask); err != nil {
+		c.Error(err, "redeliver hook task")
 		return
 	}
 
-	go db.HookQueue.Add(c.Repo.Repository.ID)
 	c.Flash.Info(c.Tr("repo.settings.webhook.redelivery_success", hookTask.UUID))
 	c.Status(http.StatusOK)
 }
 
 func DeleteWebhook(c *context.Context, orCtx *orgRepoContext) {
 	var err error
-	if orCtx.RepoID > 0 {
+	switch {
+	case orCtx.RepoID > 0:
 		err = db.DeleteWebhookOfRepoByID(orCtx.RepoID, c.QueryInt64("id"))
-	} else {
+	case orCtx.OrgID > 0:
 		err = db.DeleteWebhookOfOrgByID(orCtx.OrgID, c.QueryInt64("id"))
+	default:
+		err = db.DeleteSystemWebhookByID(c.QueryInt64("id"))
 	}
 	if err != nil {
 		c.Error(err, "delete webhook")
@@ -606,6 +639,6 @@ func DeleteWebhook(c *context.Context, orCtx *orgRepoContext) {
 	c.Flash.Success(c.Tr("repo.settings.webhook_deletion_success"))
 
 	c.JSONSuccess(map[string]interface{}{
-		"redirect": orCtx.Link + "/settings/hooks",
+		"redirect": orCtx.Link,
 	})
 }
@@ -0,0 +1,101 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/unknwon/paginater"
+
+	"gogs.io/gogs/internal/conf"
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/db"
+)
+
+const (
+	NOTIFICATIONS = "user/notifications"
+)
+
+// notificationsLink returns the link to the inbox tab of given status.
+func notificationsLink(status db.NotificationStatus) string {
+	return conf.Server.Subpath + "/notifications?status=" + status.String()
+}
+
+func Notifications(c *context.Context) {
+	c.Title("notifications")
+	c.PageIs("Notifications")
+
+	status := db.ParseNotificationStatus(c.Query("status"))
+	if status == 0 {
+		status = db.NOTIFICATION_STATUS_UNREAD
+	}
+	page := c.QueryInt("page")
+	if page <= 0 {
+		page = 1
+	}
+
+	notifications, err := db.Notifications(&db.NotificationsOptions{
+		UserID:   c.User.ID,
+		Statuses: []db.NotificationStatus{status},
+		Page:     page,
+		PageSize: conf.UI.IssuePagingNum,
+	})
+	if err != nil {
+		c.Error(err, "list notifications")
+		return
+	}
+
+	unreadCount := db.CountNotifications(c.User.ID, db.NOTIFICATION_STATUS_UNREAD)
+	readCount := db.CountNotifications(c.User.ID, db.NOTIFICATION_STATUS_READ)
+	pinnedCount := db.CountNotifications(c.User.ID, db.NOTIFICATION_STATUS_PINNED)
+	total := unreadCount
+	switch status {
+	case db.NOTIFICATION_STATUS_READ:
+		total = readCount
+	case db.NOTIFICATION_STATUS_PINNED:
+		total = pinnedCount
+	}
+
+	c.Data["Notifications"] = notifications
+	c.Data["Status"] = status.String()
+	c.Data["UnreadCount"] = unreadCount
+	c.Data["ReadCount"] = readCount
+	c.Data["PinnedCount"] = pinnedCount
+	c.Data["Page"] = paginater.New(int(total), conf.UI.IssuePagingNum, page, 5)
+	c.Success(NOTIFICATIONS)
+}
+
+// NotificationStatusPost changes status of a notification to the one in the "status"
+// form field, and redirects back to the inbox tab in the "tab" form field.
+func NotificationStatusPost(c *context.Context) {
+	n, err := db.GetNotificationByID(c.User.ID, c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get notification by ID")
+		return
+	}
+
+	status := db.ParseNotificationStatus(c.Query("status"))
+	if status == 0 {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	if err = n.SetStatus(status); err != nil {
+		c.Error(err, "set notification status")
+		return
+	}
+
+	c.RawRedirect(notificationsLink(db.ParseNotificationStatus(c.Query("tab"))))
+}
+
+func NotificationsReadAllPost(c *context.Context) {
+	if err := db.MarkNotificationsRead(c.User.ID, time.Now()); err != nil {
+		c.Error(err, "mark notifications read")
+		return
+	}
+
+	c.Flash.Success(c.Tr("notification.read_all_success"))
+	c.RawRedirect(notificationsLink(db.NOTIFICATION_STATUS_UNREAD))
+}
@@ -79,11 +79,16 @@ func FuncMap() []template.FuncMap {
 			"NewLine2br":       NewLine2br,
 			"TimeSince":        tool.TimeSince,
 			"RawTimeSince":     tool.RawTimeSince,
+			"FormatDuration":   tool.FormatDuration,
+			"ReactionEmoji":    db.ReactionEmoji,
 			"FileSize":         tool.FileSize,
 			"Subtract":         tool.Subtract,
 			"Add": func(a, b int) int {
 				return a + b
 			},
+			"AllowedReactions": func() []string {
+				return db.AllowedReactions
+			},
 			"ActionIcon": ActionIcon,
 			"DateFmtLong": func(t time.Time) string {
 				return t.Format(time.RFC1123Z)
@@ -304,6 +304,24 @@ func TimeSincePro(then time.Time) string {
 	return strings.TrimPrefix(timeStr, ", ")
 }
 
+// FormatDuration returns a short string of given seconds in hours and minutes,
+// e.g. "1h 30m". Durations shorter than a minute are shown in seconds.
+func FormatDuration(seconds int64) string {
+	if seconds < Minute {
+		return fmt.Sprintf("%ds", seconds)
+	}
+
+	hours, minutes := seconds/Hour, seconds%Hour/Minute
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%dm", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%dh", hours)
+	default:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	}
+}
+
 func timeSince(then time.Time, lang string) string {
 	now := time.Now()
 
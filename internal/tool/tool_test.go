@@ -0,0 +1,30 @@
+// Copyright 2020 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package tool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		seconds int64
+		want    string
+	}{
+		{seconds: 0, want: "0s"},
+		{seconds: 45, want: "45s"},
+		{seconds: 60, want: "1m"},
+		{seconds: 30*Minute + 59, want: "30m"},
+		{seconds: 2 * Hour, want: "2h"},
+		{seconds: 26*Hour + 5*Minute, want: "26h 5m"},
+	}
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			assert.Equal(t, test.want, FormatDuration(test.seconds))
+		})
+	}
+}
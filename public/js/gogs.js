@@ -221,6 +221,47 @@ function initCommentForm() {
     ).val("");
   });
 
+  // Assignees
+  var $assigneeMenu = $(".select-assignees .menu");
+  var $assigneeList = $(".ui.assignees.list");
+  $assigneeMenu
+    .find(".item:not(.no-select) .octicon:not(.octicon-check)")
+    .each(function() {
+      $(this).html("&nbsp;");
+    });
+  $assigneeMenu.find(".item:not(.no-select)").click(function() {
+    var isChecked = $(this).hasClass("checked");
+    $(this).toggleClass("checked");
+    $(this)
+      .find(".octicon")
+      .toggleClass("octicon-check", !isChecked)
+      .html(isChecked ? "&nbsp;" : "");
+    $($(this).data("id-selector")).toggleClass("hide", isChecked);
+    updateIssueMeta(
+      $assigneeMenu.data("update-url"),
+      isChecked ? "detach" : "attach",
+      $(this).data("id")
+    );
+
+    $assigneeList
+      .find(".no-select")
+      .toggleClass("hide", $assigneeMenu.find(".item.checked").length > 0);
+    return false;
+  });
+  $assigneeMenu.find(".no-select.item").click(function() {
+    updateIssueMeta($assigneeMenu.data("update-url"), "clear", "");
+
+    $assigneeMenu.find(".item.checked").each(function() {
+      $(this).removeClass("checked");
+      $(this)
+        .find(".octicon")
+        .removeClass("octicon-check")
+        .html("&nbsp;");
+      $($(this).data("id-selector")).addClass("hide");
+    });
+    $assigneeList.find(".no-select").removeClass("hide");
+  });
+
   function selectItem(select_id, input_id) {
     var $menu = $(select_id + " .menu");
     var $list = $(".ui" + select_id + ".list");
@@ -554,6 +595,18 @@ function initRepository() {
       return false;
     });
 
+    // Reactions
+    $(".reactions .reaction, .reactions .select-reaction .item").click(function() {
+      var $this = $(this);
+      $.post($this.closest(".reactions").data("url") + "/" + $this.data("action"), {
+        _csrf: csrf,
+        content: $this.data("content")
+      }).done(function() {
+        window.location.reload();
+      });
+      return false;
+    });
+
     // Change status
     var $statusButton = $("#status-button");
     $("#comment-form .edit_area").keyup(function() {
@@ -628,10 +681,23 @@ function initRepository() {
   }
   if ($(".repository.view.pull").length > 0) {
     $(".comment.merge.box input[name=merge_style]").change(function() {
-      if ($(this).val() === "create_merge_commit") {
-        $(".commit.description.field").show();
-      } else {
-        $(".commit.description.field").hide();
+      var $description = $("#commit_description");
+      var squashDescription = $description.data("squash-description");
+      switch ($(this).val()) {
+        case "create_merge_commit":
+          if ($description.val() === squashDescription) {
+            $description.val("");
+          }
+          $(".commit.description.field").show();
+          break;
+        case "squash":
+          if ($description.val() === "") {
+            $description.val(squashDescription);
+          }
+          $(".commit.description.field").show();
+          break;
+        default:
+          $(".commit.description.field").hide();
       }
     });
   }
@@ -1334,6 +1400,41 @@ function initCodeView() {
   }
 }
 
+function initCodeComments() {
+  var $form = $("#code-comment-form");
+  if ($form.length === 0) {
+    return;
+  }
+
+  $(".code-diff").on("click", ".lines-num[data-line-number]", function() {
+    var $row = $(this).parent();
+    if ($row.next().hasClass("code-comment-form")) {
+      $row.next().find("textarea").focus();
+      return;
+    }
+
+    // Plain and added lines are anchored to the head version.
+    var side = "right";
+    var $num = $row.children(".lines-num-new[data-line-number]");
+    if ($row.hasClass("del-code") || $num.length === 0) {
+      side = "left";
+      $num = $row.children(".lines-num-old[data-line-number]");
+    }
+
+    var $newForm = $form.children("form").clone();
+    $newForm.find("input[name=tree_path]").val($row.closest(".diff-file-box").data("path"));
+    $newForm.find("input[name=side]").val(side);
+    $newForm.find("input[name=line]").val($num.data("line-number"));
+    $newForm.find(".cancel.button").click(function() {
+      $(this).closest("tr").remove();
+    });
+
+    var $td = $('<td colspan="' + $row.children().length + '"></td>').append($newForm);
+    $('<tr class="code-comment-form"></tr>').append($td).insertAfter($row);
+    $newForm.find("textarea").focus();
+  });
+}
+
 function initUserSettings() {
   console.log("initUserSettings");
 
@@ -1674,6 +1775,7 @@ $(document).ready(function() {
   initOrganization();
   initAdmin();
   initCodeView();
+  initCodeComments();
 
   // Repo clone url.
   if ($("#repo-clone-url").length > 0) {